@@ -1,6 +1,10 @@
 package webrtc
 
-import "github.com/pion/dtls/v2"
+import (
+	"time"
+
+	"github.com/pion/dtls/v2"
+)
 
 const (
 	// Unknown defines default public constant to use for "enum" like struct
@@ -23,7 +27,19 @@ const (
 
 	mediaSectionApplication = "application"
 
+	// sctpMaxMessageSizeAttr advertises the largest data channel message
+	// an endpoint receives, see RFC 8841
+	sctpMaxMessageSizeAttr = "max-message-size"
+
+	// sctpDefaultMaxMessageSize is the max-message-size of a remote peer
+	// that doesn't advertise it
+	sctpDefaultMaxMessageSize = 65536
+
 	rtpOutboundMTU = 1200
+
+	// synchronizationSourceTimeout is how long a source is returned by
+	// RTPReceiver.GetSynchronizationSources after its latest packet
+	synchronizationSourceTimeout = 10 * time.Second
 )
 
 func defaultSrtpProtectionProfiles() []dtls.SRTPProtectionProfile {
@@ -3,6 +3,7 @@
 package webrtc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,16 +14,27 @@ import (
 
 	"github.com/pion/datachannel"
 	"github.com/pion/logging"
+	"github.com/pion/sctp"
 	"github.com/pion/webrtc/v3/pkg/rtcerr"
 )
 
-const dataChannelBufferSize = math.MaxUint16 // message size limit for Chromium
+const (
+	dataChannelBufferSize = math.MaxUint16 // message size limit for Chromium
+
+	// defaultDataChannelReceiveQueueSize is the number of messages queued for ReadMessage by default
+	defaultDataChannelReceiveQueueSize = 64
+)
+
 var errSCTPNotEstablished = errors.New("SCTP not established")
 
 // DataChannel represents a WebRTC DataChannel
 // The DataChannel interface represents a network channel
 // which can be used for bidirectional peer-to-peer transfers of arbitrary data
 type DataChannel struct {
+	// bytesDropped is first so it is 64-bit aligned for atomic access
+	bytesDropped    uint64
+	messagesDropped uint32
+
 	mu sync.RWMutex
 
 	statsID                    string
@@ -32,6 +44,8 @@ type DataChannel struct {
 	maxRetransmits             *uint16
 	protocol                   string
 	negotiated                 bool
+	fragmented                 bool
+	priority                   PriorityType
 	id                         *uint16
 	readyState                 atomic.Value // DataChannelState
 	bufferedAmountLowThreshold uint64
@@ -45,15 +59,50 @@ type DataChannel struct {
 	// "blob". This attribute controls how binary data is exposed to scripts.
 	// binaryType                 string
 
-	onMessageHandler    func(DataChannelMessage)
+	onMessageHandler func(DataChannelMessage)
+	// onMessageChanged is closed and replaced whenever the message handler is set
+	onMessageChanged chan struct{}
+	// messages are the received messages queued for ReadMessage
+	messages            chan DataChannelMessage
 	openHandlerOnce     sync.Once
 	onOpenHandler       func()
 	onCloseHandler      func()
 	onBufferedAmountLow func()
 	onErrorHandler      func(error)
+	onRestartHandler    func([]DataChannelMessage)
+
+	// bufferedAmountLow is signaled by every bufferedamountlow event and every
+	// queued message the scheduler writes, and closing is closed once the
+	// DataChannel closes, to pace the sending of fragments. fragmentWaiting
+	// raises the threshold of the SCTP stream while a fragment waits.
+	bufferedAmountLow chan struct{}
+	fragmentWaiting   bool
+	closing           chan struct{}
+	closingOnce       sync.Once
+	sendMu            sync.Mutex
+
+	// reassembly is the message being reassembled from the received fragments,
+	// only used by the readLoop
+	reassembly        []byte
+	reassemblyDropped bool
 
 	sctpTransport *SCTPTransport
 	dataChannel   *datachannel.DataChannel
+	// stream is the SCTP stream of dataChannel, whose ordering is overridden
+	// by SendWithOptions
+	stream *sctp.Stream
+	// remoteOpened is set once the remote peer is known to have opened the data
+	// channel, before which messages must be ordered not to overtake the
+	// DATA_CHANNEL_OPEN message
+	remoteOpened bool
+	// generation is the restart of the SCTP association the data channel was opened
+	// on, and suspended is set while the association restarts
+	generation uint64
+	suspended  bool
+	// inflight are the last messages written, which the remote peer may not have
+	// received, tracked when SCTP restarts are enabled and OnRestart is set
+	inflight       []DataChannelMessage
+	inflightAmount uint64
 
 	// A reference to the associated api object used by this datachannel
 	api *API
@@ -90,14 +139,33 @@ func (api *API) newDataChannel(params *DataChannelParameters, log logging.Levele
 		label:             params.Label,
 		protocol:          params.Protocol,
 		negotiated:        params.Negotiated,
+		fragmented:        params.Fragmented,
+		priority:          params.Priority,
 		id:                params.ID,
 		ordered:           params.Ordered,
 		maxPacketLifeTime: params.MaxPacketLifeTime,
 		maxRetransmits:    params.MaxRetransmits,
+		bufferedAmountLow: make(chan struct{}, 1),
+		closing:           make(chan struct{}),
+		onMessageChanged:  make(chan struct{}),
 		api:               api,
 		log:               log,
 	}
 
+	if d.priority == PriorityType(Unknown) {
+		d.priority = PriorityTypeLow
+	}
+
+	if d.fragmented && (!d.ordered || d.maxPacketLifeTime != nil || d.maxRetransmits != nil) {
+		return nil, &rtcerr.TypeError{Err: ErrFragmentedUnreliable}
+	}
+
+	receiveQueueSize := api.settingEngine.dataChannelReceiveQueueSize
+	if receiveQueueSize <= 0 {
+		receiveQueueSize = defaultDataChannelReceiveQueueSize
+	}
+	d.messages = make(chan DataChannelMessage, receiveQueueSize)
+
 	d.setReadyState(DataChannelStateConnecting)
 	return d, nil
 }
@@ -115,6 +183,34 @@ func (d *DataChannel) open(sctpTransport *SCTPTransport) error {
 		return nil
 	}
 	d.sctpTransport = sctpTransport
+	d.remoteOpened = d.negotiated
+	d.generation = sctpTransport.generation()
+
+	if d.id == nil {
+		err := d.sctpTransport.generateAndSetDataChannelID(d.sctpTransport.dtlsTransport.role(), &d.id)
+		if err != nil {
+			return err
+		}
+	}
+	stream, err := association.OpenStream(*d.id, sctp.PayloadTypeWebRTCBinary)
+	if err != nil {
+		d.mu.Unlock()
+		return err
+	}
+	dc, err := datachannel.Client(stream, d.config())
+	if err != nil {
+		d.mu.Unlock()
+		return err
+	}
+	d.mu.Unlock()
+
+	d.handleOpen(dc, stream)
+	return nil
+}
+
+// config returns the configuration of the data channel opened over SCTP. Caller
+// must hold the lock.
+func (d *DataChannel) config() *datachannel.Config {
 	var channelType datachannel.ChannelType
 	var reliabilityParameter uint32
 
@@ -142,35 +238,15 @@ func (d *DataChannel) open(sctpTransport *SCTPTransport) error {
 		}
 	}
 
-	cfg := &datachannel.Config{
+	return &datachannel.Config{
 		ChannelType:          channelType,
-		Priority:             datachannel.ChannelPriorityNormal,
+		Priority:             d.priority.weight(),
 		ReliabilityParameter: reliabilityParameter,
 		Label:                d.label,
-		Protocol:             d.protocol,
+		Protocol:             d.wireProtocol(),
 		Negotiated:           d.negotiated,
 		LoggerFactory:        d.api.settingEngine.LoggerFactory,
 	}
-
-	if d.id == nil {
-		err := d.sctpTransport.generateAndSetDataChannelID(d.sctpTransport.dtlsTransport.role(), &d.id)
-		if err != nil {
-			return err
-		}
-	}
-	dc, err := datachannel.Dial(association, *d.id, cfg)
-	if err != nil {
-		d.mu.Unlock()
-		return err
-	}
-
-	// bufferedAmountLowThreshold and onBufferedAmountLow might be set earlier
-	dc.SetBufferedAmountLowThreshold(d.bufferedAmountLowThreshold)
-	dc.OnBufferedAmountLow(d.onBufferedAmountLow)
-	d.mu.Unlock()
-
-	d.handleOpen(dc)
-	return nil
 }
 
 // Transport returns the SCTPTransport instance the DataChannel is sending over.
@@ -246,27 +322,99 @@ func (d *DataChannel) onClose() {
 // in size. Check out the detach API if you want to use larger
 // message sizes. Note that browser support for larger messages
 // is also limited.
+// While no handler is set, messages are queued for ReadMessage.
 func (d *DataChannel) OnMessage(f func(msg DataChannelMessage)) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	d.onMessageHandler = f
+
+	if d.onMessageChanged != nil {
+		close(d.onMessageChanged)
+		d.onMessageChanged = make(chan struct{})
+	}
 }
 
+// onMessage passes a received message to the message handler, or queues it for
+// ReadMessage. It blocks while the queue is full, so the data channel stops being
+// read and the remote peer is slowed down.
 func (d *DataChannel) onMessage(msg DataChannelMessage) {
-	d.mu.RLock()
-	handler := d.onMessageHandler
-	d.mu.RUnlock()
+	for {
+		d.mu.RLock()
+		handler, changed := d.onMessageHandler, d.onMessageChanged
+		d.mu.RUnlock()
 
-	if handler == nil {
-		return
+		if handler != nil {
+			handler(msg)
+			return
+		}
+		if d.messages == nil {
+			return
+		}
+
+		select {
+		case d.messages <- msg:
+			return
+		case <-changed:
+		case <-d.closing:
+			return
+		}
+	}
+}
+
+// ReadMessage reads the next message received while no OnMessage handler
+// was set. Messages are queued from the time the data channel opens, up to
+// the size set with SettingEngine.SetDataChannelReceiveQueueSize. The data
+// channel isn't read while the queue is full. Once the DataChannel is closed,
+// the queued messages can still be read before io.EOF is returned.
+//
+// A data channel that is neither read nor has an OnMessage handler stops being
+// read once its queue is full. The data it is sent then fills the receive window
+// of the SCTP association, which the other data channels share.
+func (d *DataChannel) ReadMessage(ctx context.Context) (DataChannelMessage, error) {
+	if d.api.settingEngine.detach.DataChannels {
+		return DataChannelMessage{}, errReadMessageDetached
 	}
-	handler(msg)
+
+	select {
+	case msg := <-d.messages:
+		return msg, nil
+	default:
+	}
+
+	select {
+	case msg := <-d.messages:
+		return msg, nil
+	case <-d.closing:
+		select {
+		case msg := <-d.messages:
+			return msg, nil
+		default:
+			return DataChannelMessage{}, io.EOF
+		}
+	case <-ctx.Done():
+		return DataChannelMessage{}, ctx.Err()
+	}
+}
+
+// ReceiveQueueDepth returns the number of received messages queued for ReadMessage.
+func (d *DataChannel) ReceiveQueueDepth() int {
+	return len(d.messages)
 }
 
-func (d *DataChannel) handleOpen(dc *datachannel.DataChannel) {
+func (d *DataChannel) handleOpen(dc *datachannel.DataChannel, stream *sctp.Stream) {
 	d.mu.Lock()
 	d.dataChannel = dc
+	d.stream = stream
+	// bufferedAmountLowThreshold and onBufferedAmountLow might be set earlier
+	dc.SetBufferedAmountLowThreshold(d.streamBufferedAmountLowThreshold())
+	dc.OnBufferedAmountLow(d.handleBufferedAmountLow)
+	sctpTransport := d.sctpTransport
 	d.mu.Unlock()
+
+	// Detached data channels write to the SCTP stream directly
+	if sctpTransport != nil && !d.api.settingEngine.detach.DataChannels {
+		sctpTransport.scheduler.add(d, dc, d.priority)
+	}
 	d.setReadyState(DataChannelStateOpen)
 
 	d.onOpen()
@@ -279,6 +427,102 @@ func (d *DataChannel) handleOpen(dc *datachannel.DataChannel) {
 	}
 }
 
+// OnRestart sets an event handler which is invoked once the data channel is
+// re-created after its SCTP association restarted, see SCTPTransport.Restart.
+// lost are the last messages sent before the restart which the remote peer may
+// not have received, oldest first. Messages sent while the association restarts
+// are sent once the data channel is re-created. The messages sent are only copied
+// to be reported while a handler is set.
+func (d *DataChannel) OnRestart(f func(lost []DataChannelMessage)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onRestartHandler = f
+
+	if f == nil {
+		d.inflight, d.inflightAmount = nil, 0
+	}
+}
+
+// restartable returns whether the data channel is re-created when the SCTP
+// association restarts. Detached data channels are read by the application, and
+// fragmented data channels would lose the fragments of their messages.
+func (d *DataChannel) restartable() bool {
+	return d.negotiated && !d.fragmented && !d.api.settingEngine.detach.DataChannels
+}
+
+// suspend queues the messages sent while the SCTP association restarts
+func (d *DataChannel) suspend() {
+	if !d.restartable() || d.ReadyState() != DataChannelStateOpen {
+		return
+	}
+
+	d.mu.Lock()
+	d.suspended = true
+	sctpTransport := d.sctpTransport
+	d.mu.Unlock()
+
+	sctpTransport.scheduler.suspend(d)
+}
+
+func (d *DataChannel) isSuspended() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.suspended
+}
+
+// reopen re-creates a suspended data channel over the stream of the restarted
+// SCTP association, and reports the messages that may have been lost
+func (d *DataChannel) reopen(stream *sctp.Stream, generation uint64) error {
+	if d.ReadyState() != DataChannelStateOpen {
+		d.closeSuspended()
+		return nil
+	}
+
+	d.mu.Lock()
+	dc, err := datachannel.Client(stream, d.config())
+	if err != nil {
+		d.mu.Unlock()
+		d.closeSuspended()
+		return err
+	}
+
+	d.trimInflight(d.dataChannel)
+	lost := d.inflight
+	d.inflight, d.inflightAmount = nil, 0
+	d.dataChannel, d.stream = dc, stream
+	d.generation, d.suspended = generation, false
+	dc.SetBufferedAmountLowThreshold(d.streamBufferedAmountLowThreshold())
+	dc.OnBufferedAmountLow(d.handleBufferedAmountLow)
+	sctpTransport, handler := d.sctpTransport, d.onRestartHandler
+	d.mu.Unlock()
+
+	sctpTransport.scheduler.resume(d, dc)
+	go d.readLoop()
+
+	if handler != nil {
+		go handler(lost)
+	}
+	return nil
+}
+
+// closeSuspended closes a data channel that couldn't be re-created once the SCTP
+// association restarted
+func (d *DataChannel) closeSuspended() {
+	d.mu.Lock()
+	suspended := d.suspended
+	d.suspended = false
+	d.mu.Unlock()
+	if !suspended {
+		return
+	}
+
+	d.setReadyState(DataChannelStateClosed)
+	d.markClosing()
+	d.sctpTransport.scheduler.remove(d)
+	d.onClose()
+}
+
 // OnError sets an event handler which is invoked when
 // the underlying data transport cannot be read.
 func (d *DataChannel) OnError(f func(err error)) {
@@ -304,23 +548,63 @@ var rlBufPool = sync.Pool{New: func() interface{} {
 }}
 
 func (d *DataChannel) readLoop() {
+	// Messages larger than the pooled buffers are read into the buffers of the
+	// SCTPTransport, which are reused once data channels close. Fragmented data
+	// channels only receive fragments, which always fit into the pooled buffers.
+	pool := &rlBufPool
+	if d.sctpTransport.localMaxMessageSize() > dataChannelBufferSize && !d.fragmented {
+		pool = &d.sctpTransport.readBuffers
+	}
+	getBuffer := func() []byte {
+		return pool.Get().([]byte)
+	}
+	putBuffer := func(buffer []byte) {
+		pool.Put(buffer) // nolint:staticcheck
+	}
+
+	d.mu.RLock()
+	dc, remoteOpened, generation := d.dataChannel, d.remoteOpened, d.generation
+	d.mu.RUnlock()
+
 	for {
-		buffer := rlBufPool.Get().([]byte)
-		n, isString, err := d.dataChannel.ReadDataChannel(buffer)
+		buffer := getBuffer()
+		n, isString, err := dc.ReadDataChannel(buffer)
+		if err == nil && !remoteOpened {
+			d.mu.Lock()
+			d.remoteOpened, remoteOpened = true, true
+			d.mu.Unlock()
+		}
 		if err != nil {
-			rlBufPool.Put(buffer) // nolint:staticcheck
+			putBuffer(buffer)
+			// The data channel is re-created once the association restarted
+			if d.isSuspended() && d.sctpTransport.restartedSince(generation) {
+				return
+			}
+
 			d.setReadyState(DataChannelStateClosed)
-			if err != io.EOF {
+			d.markClosing()
+			d.sctpTransport.scheduler.remove(d)
+			// pion/datachannel resets the outgoing stream once the incoming one is reset,
+			// which fails if the association closed meanwhile
+			if err != io.EOF && !errors.Is(err, sctp.ErrResetPacketInStateNotExist) {
 				d.onError(err)
 			}
 			d.onClose()
 			return
 		}
 
+		if d.fragmented {
+			m, ok := d.handleFragment(buffer[:n], isString)
+			putBuffer(buffer)
+			if ok {
+				d.onMessage(m) // nolint:staticcheck
+			}
+			continue
+		}
+
 		m := DataChannelMessage{Data: make([]byte, n), IsString: isString}
 		copy(m.Data, buffer[:n])
-		// The 'staticcheck' pragma is a false positive on the part of the CI linter.
-		rlBufPool.Put(buffer) // nolint:staticcheck
+		putBuffer(buffer)
 
 		// NB: Why was DataChannelMessage not passed as a pointer value?
 		d.onMessage(m) // nolint:staticcheck
@@ -334,8 +618,11 @@ func (d *DataChannel) Send(data []byte) error {
 		return err
 	}
 
-	_, err = d.dataChannel.WriteDataChannel(data, false)
-	return err
+	if d.fragmented {
+		return d.sendFragmented(data, false)
+	}
+
+	return d.write(data, false, nil)
 }
 
 // SendText sends the text message to the DataChannel peer
@@ -345,10 +632,131 @@ func (d *DataChannel) SendText(s string) error {
 		return err
 	}
 
-	_, err = d.dataChannel.WriteDataChannel([]byte(s), true)
+	if d.fragmented {
+		return d.sendFragmented([]byte(s), true)
+	}
+
+	return d.write([]byte(s), true, nil)
+}
+
+// SendWithOptions sends the binary message to the DataChannel peer, overriding
+// the ordering of the data channel for this message, or limiting how long it
+// waits behind the messages queued before it. It doesn't change how SCTP
+// retransmits the message: MaxRetransmits and MaxPacketLifeTime aren't implemented
+// and fail with ErrSendReliabilityNotImplemented. Messages dropped before they are
+// sent are counted by DataChannelStats.MessagesDropped.
+// Ordering is only overridden once the remote peer opened the data channel:
+// right away for negotiated channels and channels announced by the remote
+// peer, otherwise once a message was received from the remote peer.
+// Fragmented and detached data channels don't support options.
+func (d *DataChannel) SendWithOptions(data []byte, options *DataChannelSendOptions) error {
+	err := d.ensureOpen()
+	if err != nil {
+		return err
+	}
+
+	if !options.overrides() {
+		return d.Send(data)
+	}
+
+	switch {
+	case options.partiallyReliable():
+		return ErrSendReliabilityNotImplemented
+	case d.fragmented:
+		return &rtcerr.TypeError{Err: ErrFragmentedUnreliable}
+	case d.api.settingEngine.detach.DataChannels:
+		return errSendWithOptionsDetached
+	}
+
+	return d.write(data, false, options)
+}
+
+// write sends a message through the scheduler of the SCTP transport, which
+// may queue it while other data channels are sending
+func (d *DataChannel) write(data []byte, isString bool, options *DataChannelSendOptions) error {
+	d.mu.RLock()
+	sctpTransport, dc := d.sctpTransport, d.dataChannel
+	d.mu.RUnlock()
+
+	return sctpTransport.scheduler.send(d, dc, data, isString, options)
+}
+
+// writeDataChannel writes a message to the SCTP stream. A message whose ordering
+// differs from the data channel is written with the ordering of its SCTP stream
+// overridden, which applies to the message only, as SCTP sets the ordering of
+// every chunk when the message is written. Caller must serialize the writes.
+func (d *DataChannel) writeDataChannel(dc *datachannel.DataChannel, data []byte, isString bool, ordered *bool) error {
+	d.mu.RLock()
+	stream, remoteOpened := d.stream, d.remoteOpened
+	d.mu.RUnlock()
+
+	// Until the remote peer acknowledged the DATA_CHANNEL_OPEN message, the ordering
+	// of the stream is committed by pion/datachannel, which doesn't report it, so it
+	// is left untouched until the first message is received
+	if ordered == nil || *ordered == d.ordered || stream == nil || !remoteOpened {
+		_, err := dc.WriteDataChannel(data, isString)
+		d.trackInflight(dc, data, isString, err)
+		return err
+	}
+
+	reliabilityType, reliabilityValue := d.sctpReliability()
+	stream.SetReliabilityParams(!*ordered, reliabilityType, reliabilityValue)
+	_, err := dc.WriteDataChannel(data, isString)
+	stream.SetReliabilityParams(!d.ordered, reliabilityType, reliabilityValue)
+	d.trackInflight(dc, data, isString, err)
 	return err
 }
 
+// trackInflight keeps the messages written which the remote peer may not have
+// received, to report them once the SCTP association restarted. They are the last
+// messages written, covering the amount of data SCTP buffers until it's acknowledged.
+// Only the data channels with an OnRestart handler copy them.
+func (d *DataChannel) trackInflight(dc *datachannel.DataChannel, data []byte, isString bool, err error) {
+	if err != nil || !d.api.settingEngine.sctp.Restart || !d.restartable() {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.onRestartHandler == nil {
+		return
+	}
+
+	d.inflight = append(d.inflight, DataChannelMessage{Data: append([]byte{}, data...), IsString: isString})
+	d.inflightAmount += uint64(len(data))
+	d.trimInflight(dc)
+}
+
+// trimInflight drops the messages acknowledged by the remote peer. Caller must hold
+// the lock.
+func (d *DataChannel) trimInflight(dc *datachannel.DataChannel) {
+	buffered := dc.BufferedAmount()
+	for len(d.inflight) != 0 && d.inflightAmount-uint64(len(d.inflight[0].Data)) >= buffered {
+		d.inflightAmount -= uint64(len(d.inflight[0].Data))
+		d.inflight[0] = DataChannelMessage{}
+		d.inflight = d.inflight[1:]
+	}
+}
+
+// sctpReliability returns the partial reliability of the SCTP stream
+func (d *DataChannel) sctpReliability() (byte, uint32) {
+	switch {
+	case d.maxRetransmits != nil:
+		return sctp.ReliabilityTypeRexmit, uint32(*d.maxRetransmits)
+	case d.maxPacketLifeTime != nil:
+		return sctp.ReliabilityTypeTimed, uint32(*d.maxPacketLifeTime)
+	default:
+		return sctp.ReliabilityTypeReliable, 0
+	}
+}
+
+// onDropped counts a message dropped before it was sent
+func (d *DataChannel) onDropped(data []byte) {
+	atomic.AddUint32(&d.messagesDropped, 1)
+	atomic.AddUint64(&d.bytesDropped, uint64(len(data)))
+}
+
 func (d *DataChannel) ensureOpen() error {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
@@ -374,6 +782,10 @@ func (d *DataChannel) Detach() (datachannel.ReadWriteCloser, error) {
 		return nil, errDetachNotEnabled
 	}
 
+	if d.fragmented {
+		return nil, errDetachFragmented
+	}
+
 	if d.dataChannel == nil {
 		return nil, errDetachBeforeOpened
 	}
@@ -395,10 +807,14 @@ func (d *DataChannel) Close() error {
 	}
 
 	d.setReadyState(DataChannelStateClosing)
+	d.markClosing()
 	if !haveSctpTransport {
 		return nil
 	}
 
+	// Queued messages are dropped, as the stream is reset right away
+	d.sctpTransport.scheduler.remove(d)
+
 	return d.dataChannel.Close()
 }
 
@@ -448,6 +864,23 @@ func (d *DataChannel) Protocol() string {
 	return d.protocol
 }
 
+// Priority represents the priority of this DataChannel.
+func (d *DataChannel) Priority() PriorityType {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.priority
+}
+
+// Fragmented represents whether messages sent and received on this
+// DataChannel are fragmented, see DataChannelInit.Fragmented.
+func (d *DataChannel) Fragmented() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.fragmented
+}
+
 // Negotiated represents whether this DataChannel was negotiated by the
 // application (true), or not (false).
 func (d *DataChannel) Negotiated() bool {
@@ -490,12 +923,14 @@ func (d *DataChannel) ReadyState() DataChannelState {
 // closes.
 func (d *DataChannel) BufferedAmount() uint64 {
 	d.mu.RLock()
-	defer d.mu.RUnlock()
+	sctpTransport, dc := d.sctpTransport, d.dataChannel
+	d.mu.RUnlock()
 
-	if d.dataChannel == nil {
+	if dc == nil {
 		return 0
 	}
-	return d.dataChannel.BufferedAmount()
+	// Messages queued by the scheduler count as buffered too
+	return dc.BufferedAmount() + sctpTransport.scheduler.queuedAmount(d)
 }
 
 // BufferedAmountLowThreshold represents the threshold at which the
@@ -508,10 +943,7 @@ func (d *DataChannel) BufferedAmountLowThreshold() uint64 {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	if d.dataChannel == nil {
-		return d.bufferedAmountLowThreshold
-	}
-	return d.dataChannel.BufferedAmountLowThreshold()
+	return d.bufferedAmountLowThreshold
 }
 
 // SetBufferedAmountLowThreshold is used to update the threshold.
@@ -523,7 +955,7 @@ func (d *DataChannel) SetBufferedAmountLowThreshold(th uint64) {
 	d.bufferedAmountLowThreshold = th
 
 	if d.dataChannel != nil {
-		d.dataChannel.SetBufferedAmountLowThreshold(th)
+		d.dataChannel.SetBufferedAmountLowThreshold(d.streamBufferedAmountLowThreshold())
 	}
 }
 
@@ -535,8 +967,42 @@ func (d *DataChannel) OnBufferedAmountLow(f func()) {
 	defer d.mu.Unlock()
 
 	d.onBufferedAmountLow = f
-	if d.dataChannel != nil {
-		d.dataChannel.OnBufferedAmountLow(f)
+}
+
+func (d *DataChannel) handleBufferedAmountLow() {
+	d.mu.RLock()
+	sctpTransport, dc := d.sctpTransport, d.dataChannel
+	fragmentWaiting, threshold := d.fragmentWaiting, d.bufferedAmountLowThreshold
+	d.mu.RUnlock()
+
+	d.onScheduled()
+
+	// A waiting fragment raised the threshold of the SCTP stream, the amount
+	// buffered may still be above the threshold of the application
+	if fragmentWaiting && dc != nil && dc.BufferedAmount() > threshold {
+		return
+	}
+
+	if sctpTransport != nil {
+		sctpTransport.scheduler.onBufferedAmountLow()
+
+		// The amount buffered isn't low while the scheduler queues messages, it
+		// fires the event itself once they are sent
+		if sctpTransport.scheduler.queuedAmount(d) != 0 {
+			return
+		}
+	}
+
+	d.onBufferedAmountLowHandler()
+}
+
+func (d *DataChannel) onBufferedAmountLowHandler() {
+	d.mu.RLock()
+	handler := d.onBufferedAmountLow
+	d.mu.RUnlock()
+
+	if handler != nil {
+		handler()
 	}
 }
 
@@ -571,7 +1037,10 @@ func (d *DataChannel) collectStats(collector *statsReportCollector) {
 		stats.BytesSent = d.dataChannel.BytesSent()
 		stats.MessagesReceived = d.dataChannel.MessagesReceived()
 		stats.BytesReceived = d.dataChannel.BytesReceived()
+		stats.MessagesQueued = uint32(len(d.messages))
 	}
+	stats.MessagesDropped = atomic.LoadUint32(&d.messagesDropped)
+	stats.BytesDropped = atomic.LoadUint64(&d.bytesDropped)
 
 	collector.Collect(stats.ID, stats)
 }
@@ -4,6 +4,7 @@ package webrtc
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/binary"
 	"io"
@@ -19,6 +20,7 @@ import (
 	"github.com/pion/datachannel"
 	"github.com/pion/logging"
 	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3/pkg/rtcerr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -577,3 +579,332 @@ func TestDataChannel_NonStandardSessionDescription(t *testing.T) {
 	<-onDataChannelCalled
 	closePairNow(t, offerPC, answerPC)
 }
+
+func TestDataChannel_Fragmented(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	t.Run("Large messages", func(t *testing.T) {
+		offerPC, answerPC, err := newPair()
+		assert.NoError(t, err)
+
+		large := make([]byte, 1024*1024)
+		_, err = rand.Read(large)
+		assert.NoError(t, err)
+
+		fragmented, protocol := true, "myprotocol"
+		dc, err := offerPC.CreateDataChannel(expectedLabel, &DataChannelInit{
+			Fragmented: &fragmented,
+			Protocol:   &protocol,
+		})
+		assert.NoError(t, err)
+		assert.True(t, dc.Fragmented())
+		assert.Equal(t, protocol, dc.Protocol())
+
+		// A small message on another channel isn't blocked by the large message
+		small, err := offerPC.CreateDataChannel("small", nil)
+		assert.NoError(t, err)
+
+		received := make(chan DataChannelMessage, 3)
+		smallReceived := make(chan struct{})
+		answerPC.OnDataChannel(func(d *DataChannel) {
+			switch d.Label() {
+			case expectedLabel:
+				assert.True(t, d.Fragmented())
+				assert.Equal(t, protocol, d.Protocol())
+				d.OnMessage(func(msg DataChannelMessage) {
+					received <- msg
+				})
+			case "small":
+				d.OnMessage(func(msg DataChannelMessage) {
+					close(smallReceived)
+				})
+			}
+		})
+
+		dc.OnOpen(func() {
+			assert.NoError(t, dc.Send(large))
+			assert.NoError(t, dc.Send([]byte{}))
+			assert.NoError(t, dc.SendText("done"))
+		})
+		small.OnOpen(func() {
+			assert.NoError(t, small.Send([]byte("small")))
+		})
+
+		assert.NoError(t, signalPair(offerPC, answerPC))
+
+		msg := <-received
+		assert.False(t, msg.IsString)
+		assert.Equal(t, large, msg.Data)
+		msg = <-received
+		assert.Equal(t, []byte{}, msg.Data)
+		msg = <-received
+		assert.True(t, msg.IsString)
+		assert.Equal(t, []byte("done"), msg.Data)
+		<-smallReceived
+
+		// The SCTP stream threshold is only raised while a fragment waits
+		assert.Equal(t, uint64(0), dc.BufferedAmountLowThreshold())
+		dc.mu.RLock()
+		assert.Equal(t, uint64(0), dc.dataChannel.BufferedAmountLowThreshold())
+		dc.mu.RUnlock()
+
+		closePairNow(t, offerPC, answerPC)
+	})
+
+	t.Run("Max message size", func(t *testing.T) {
+		s := SettingEngine{}
+		s.SetDataChannelMaxFragmentedMessageSize(dataChannelFragmentSize)
+		offerPC, answerPC, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+		assert.NoError(t, err)
+
+		fragmented := true
+		dc, err := offerPC.CreateDataChannel(expectedLabel, &DataChannelInit{Fragmented: &fragmented})
+		assert.NoError(t, err)
+
+		received := make(chan []byte, 1)
+		errs := make(chan error, 1)
+		answerPC.OnDataChannel(func(d *DataChannel) {
+			d.OnError(func(err error) {
+				errs <- err
+			})
+			d.OnMessage(func(msg DataChannelMessage) {
+				received <- msg.Data
+			})
+		})
+
+		dc.OnOpen(func() {
+			assert.NoError(t, dc.Send(make([]byte, 2*dataChannelFragmentSize)))
+			assert.NoError(t, dc.Send([]byte("fits")))
+		})
+
+		assert.NoError(t, signalPair(offerPC, answerPC))
+
+		assert.Equal(t, ErrDataChannelMessageTooLarge, <-errs)
+		assert.Equal(t, []byte("fits"), <-received)
+
+		closePairNow(t, offerPC, answerPC)
+	})
+
+	t.Run("Unreliable", func(t *testing.T) {
+		pc, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		fragmented, ordered := true, false
+		_, err = pc.CreateDataChannel(expectedLabel, &DataChannelInit{
+			Fragmented: &fragmented,
+			Ordered:    &ordered,
+		})
+		assert.Equal(t, &rtcerr.TypeError{Err: ErrFragmentedUnreliable}, err)
+
+		assert.NoError(t, pc.Close())
+	})
+}
+
+func TestParseWireProtocol(t *testing.T) {
+	for _, test := range []struct {
+		wire       string
+		protocol   string
+		fragmented bool
+	}{
+		{"", "", false},
+		{"chat", "chat", false},
+		{dataChannelFragmentedProtocol, "", true},
+		{dataChannelFragmentedProtocol + "/chat", "chat", true},
+		{dataChannelFragmentedProtocol + "chat", dataChannelFragmentedProtocol + "chat", false},
+	} {
+		protocol, fragmented := parseWireProtocol(test.wire)
+		assert.Equal(t, test.protocol, protocol)
+		assert.Equal(t, test.fragmented, fragmented)
+	}
+}
+
+func TestDataChannel_ReadMessage(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const queueSize, messageCount = 4, 20
+
+	s := SettingEngine{}
+	s.SetDataChannelReceiveQueueSize(queueSize)
+	offerPC, answerPC, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+	assert.NoError(t, err)
+
+	dc, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+	dc.OnOpen(func() {
+		for i := 0; i < messageCount; i++ {
+			assert.NoError(t, dc.Send([]byte{byte(i)}))
+		}
+	})
+
+	accepted := make(chan *DataChannel, 1)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		accepted <- d
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	d := <-accepted
+
+	// The data channel isn't read while the queue is full
+	for d.ReceiveQueueDepth() != queueSize {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, queueSize, d.ReceiveQueueDepth())
+
+	stats, ok := answerPC.GetStats().GetDataChannelStats(d)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(queueSize), stats.MessagesQueued)
+
+	// Messages received once a handler is set aren't queued anymore
+	received := make(chan byte, messageCount)
+	d.OnMessage(func(msg DataChannelMessage) {
+		received <- msg.Data[0]
+	})
+
+	for i := 0; i < queueSize; i++ {
+		msg, err := d.ReadMessage(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{byte(i)}, msg.Data)
+	}
+	for i := queueSize; i < messageCount; i++ {
+		assert.Equal(t, byte(i), <-received)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = d.ReadMessage(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	assert.NoError(t, d.Close())
+	_, err = d.ReadMessage(context.Background())
+	assert.Equal(t, io.EOF, err)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDataChannel_ReadMessageLater(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const messageCount = 10
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	dc, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+	dc.OnOpen(func() {
+		for i := 0; i < messageCount; i++ {
+			assert.NoError(t, dc.Send([]byte{byte(i)}))
+		}
+	})
+
+	accepted := make(chan *DataChannel, 1)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		accepted <- d
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	d := <-accepted
+
+	// The messages are queued before ReadMessage is called for the first time
+	for d.ReceiveQueueDepth() != messageCount {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stats, ok := answerPC.GetStats().GetDataChannelStats(d)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(messageCount), stats.MessagesQueued)
+
+	for i := 0; i < messageCount; i++ {
+		msg, err := d.ReadMessage(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{byte(i)}, msg.Data)
+	}
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDataChannel_SendWithOptions(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	dc, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+	fragmented := true
+	fragmentedDC, err := offerPC.CreateDataChannel("fragmented", &DataChannelInit{Fragmented: &fragmented})
+	assert.NoError(t, err)
+
+	received := make(chan DataChannelMessage, 1024)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		if d.Label() != expectedLabel {
+			return
+		}
+		d.OnMessage(func(msg DataChannelMessage) {
+			received <- msg
+		})
+		d.OnOpen(func() {
+			assert.NoError(t, d.SendText("hello"))
+		})
+	})
+
+	// The ordering of the data channel is overridden once the remote peer opened it
+	greeted := make(chan struct{})
+	dc.OnMessage(func(DataChannelMessage) {
+		close(greeted)
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-greeted
+
+	unordered, queueTime := false, uint16(1)
+
+	err = fragmentedDC.SendWithOptions([]byte{}, &DataChannelSendOptions{Ordered: &unordered})
+	assert.Equal(t, &rtcerr.TypeError{Err: ErrFragmentedUnreliable}, err)
+
+	maxRetransmits := uint16(0)
+	err = dc.SendWithOptions([]byte{}, &DataChannelSendOptions{MaxRetransmits: &maxRetransmits})
+	assert.Equal(t, ErrSendReliabilityNotImplemented, err)
+
+	assert.NoError(t, dc.SendWithOptions([]byte("unordered"), &DataChannelSendOptions{Ordered: &unordered}))
+	assert.Equal(t, []byte("unordered"), (<-received).Data)
+
+	// Once the scheduler queues messages, messages that can't wait are dropped
+	const queuedCount = 256
+	chunk := make([]byte, dataChannelFragmentSize)
+	for i := 0; i < queuedCount; i++ {
+		assert.NoError(t, dc.Send(chunk))
+	}
+	assert.NoError(t, dc.SendWithOptions([]byte("now"), &DataChannelSendOptions{DropIfQueued: true}))
+	assert.NoError(t, dc.SendWithOptions([]byte("expiring"), &DataChannelSendOptions{MaxQueueTime: &queueTime}))
+	assert.NoError(t, dc.SendText("done"))
+
+	for i := 0; i < queuedCount; i++ {
+		assert.Equal(t, chunk, (<-received).Data)
+	}
+	assert.Equal(t, []byte("done"), (<-received).Data)
+
+	stats, ok := offerPC.GetStats().GetDataChannelStats(dc)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(2), stats.MessagesDropped)
+	assert.Equal(t, uint64(len("now")+len("expiring")), stats.BytesDropped)
+
+	closePairNow(t, offerPC, answerPC)
+}
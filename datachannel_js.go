@@ -106,6 +106,15 @@ func (d *DataChannel) SendText(s string) (err error) {
 	return nil
 }
 
+// SendWithOptions sends the binary message to the DataChannel peer. Overriding
+// the reliability of the data channel isn't supported by the Wasm bindings.
+func (d *DataChannel) SendWithOptions(data []byte, options *DataChannelSendOptions) error {
+	if options.overrides() {
+		return errSendOptionsNotSupported
+	}
+	return d.Send(data)
+}
+
 // Detach allows you to detach the underlying datachannel. This provides
 // an idiomatic API to work with, however it disables the OnMessage callback.
 // Before calling Detach you have to enable this behavior by calling
@@ -192,6 +201,11 @@ func (d *DataChannel) Protocol() string {
 	return d.underlying.Get("protocol").String()
 }
 
+// Priority represents the priority of this DataChannel.
+func (d *DataChannel) Priority() PriorityType {
+	return newPriorityType(d.underlying.Get("priority").String())
+}
+
 // Negotiated represents whether this DataChannel was negotiated by the
 // application (true), or not (false).
 func (d *DataChannel) Negotiated() bool {
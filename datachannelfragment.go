@@ -0,0 +1,204 @@
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"strings"
+)
+
+const (
+	// dataChannelFragmentedProtocol is the sub-protocol announcing a fragmented
+	// data channel, followed by a slash and the protocol of the application if any
+	dataChannelFragmentedProtocol = "x-pion-fragmented"
+
+	// dataChannelFragmentSize is the largest payload of a fragment, which every
+	// max-message-size allows
+	dataChannelFragmentSize = 16384
+
+	// dataChannelFragmentedBufferedAmount is the amount of data buffered by a
+	// fragmented data channel above which no more fragments are queued, so the
+	// messages of other data channels aren't queued behind a whole large message
+	dataChannelFragmentedBufferedAmount = 4 * dataChannelFragmentSize
+
+	// dataChannelFragmentedLowThreshold is the buffered amount a fragment waits
+	// for once dataChannelFragmentedBufferedAmount is exceeded, so the data
+	// channel doesn't drain completely between fragments
+	dataChannelFragmentedLowThreshold = dataChannelFragmentedBufferedAmount / 2
+
+	// defaultDataChannelMaxFragmentedMessageSize is the largest message reassembled
+	// by default
+	defaultDataChannelMaxFragmentedMessageSize = 16 * 1024 * 1024
+
+	// fragmentFlagFinal marks the last fragment of a message in the one byte
+	// header of every fragment
+	fragmentFlagFinal = 0x01
+)
+
+// wireProtocol returns the sub-protocol sent to the remote peer when opening the data channel
+func (d *DataChannel) wireProtocol() string {
+	if !d.fragmented {
+		return d.protocol
+	}
+	if d.protocol == "" {
+		return dataChannelFragmentedProtocol
+	}
+	return dataChannelFragmentedProtocol + "/" + d.protocol
+}
+
+// parseWireProtocol returns the sub-protocol of the application, and whether the
+// remote peer announced a fragmented data channel
+func parseWireProtocol(protocol string) (string, bool) {
+	if protocol == dataChannelFragmentedProtocol {
+		return "", true
+	}
+	if strings.HasPrefix(protocol, dataChannelFragmentedProtocol+"/") {
+		return protocol[len(dataChannelFragmentedProtocol)+1:], true
+	}
+	return protocol, false
+}
+
+// sendFragmented sends a message split into fragments of at most dataChannelFragmentSize
+// bytes. Fragments are only queued while the data channel buffers little data, so the
+// messages of other data channels interleave with them.
+func (d *DataChannel) sendFragmented(data []byte, isString bool) error {
+	d.sendMu.Lock()
+	defer d.sendMu.Unlock()
+
+	// The scheduler and the SCTP stream copy every message, so the fragment buffer is reused
+	fragment := make([]byte, 0, dataChannelFragmentSize+1)
+	for {
+		size, header := len(data), byte(fragmentFlagFinal)
+		if size > dataChannelFragmentSize {
+			size, header = dataChannelFragmentSize, 0
+		}
+
+		if err := d.waitFragmentWritable(); err != nil {
+			return err
+		}
+
+		fragment = append(append(fragment[:0], header), data[:size]...)
+		if err := d.write(fragment, isString, nil); err != nil {
+			return err
+		}
+
+		data = data[size:]
+		if header&fragmentFlagFinal != 0 {
+			return nil
+		}
+	}
+}
+
+// waitFragmentWritable blocks while the data channel buffers too much data to queue
+// another fragment. The threshold of the SCTP stream is raised to
+// dataChannelFragmentedLowThreshold while waiting, so the bufferedamountlow event
+// fires before the data channel is drained.
+func (d *DataChannel) waitFragmentWritable() error {
+	for {
+		if d.ReadyState() != DataChannelStateOpen {
+			return io.ErrClosedPipe
+		}
+
+		limit := d.BufferedAmountLowThreshold()
+		if limit < dataChannelFragmentedBufferedAmount {
+			limit = dataChannelFragmentedBufferedAmount
+		}
+		if d.BufferedAmount() <= limit {
+			return nil
+		}
+
+		d.setFragmentWaiting(true)
+		// The amount may have dropped before the threshold was raised
+		if d.BufferedAmount() <= limit {
+			d.setFragmentWaiting(false)
+			continue
+		}
+
+		select {
+		case <-d.bufferedAmountLow:
+			d.setFragmentWaiting(false)
+		case <-d.closing:
+			d.setFragmentWaiting(false)
+			return io.ErrClosedPipe
+		}
+	}
+}
+
+// setFragmentWaiting updates the threshold of the SCTP stream for a waiting fragment
+func (d *DataChannel) setFragmentWaiting(waiting bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.fragmentWaiting = waiting
+	if d.dataChannel != nil {
+		d.dataChannel.SetBufferedAmountLowThreshold(d.streamBufferedAmountLowThreshold())
+	}
+}
+
+// streamBufferedAmountLowThreshold returns the threshold of the SCTP stream, which
+// is the threshold of the application unless a fragment waits. d.mu must be held.
+func (d *DataChannel) streamBufferedAmountLowThreshold() uint64 {
+	if d.fragmentWaiting && d.bufferedAmountLowThreshold < dataChannelFragmentedLowThreshold {
+		return dataChannelFragmentedLowThreshold
+	}
+	return d.bufferedAmountLowThreshold
+}
+
+// handleFragment adds a received fragment to the message being reassembled, and returns
+// the message once its final fragment is received. Messages larger than the max fragmented
+// message size are dropped.
+func (d *DataChannel) handleFragment(fragment []byte, isString bool) (DataChannelMessage, bool) {
+	if len(fragment) == 0 {
+		d.onError(errFragmentEmpty)
+		return DataChannelMessage{}, false
+	}
+
+	maxSize := d.api.settingEngine.dataChannelMaxFragmentedMessageSize
+	if maxSize == 0 {
+		maxSize = defaultDataChannelMaxFragmentedMessageSize
+	}
+
+	payload := fragment[1:]
+	if !d.reassemblyDropped {
+		if uint64(len(d.reassembly)+len(payload)) > maxSize {
+			d.reassembly, d.reassemblyDropped = nil, true
+			d.onError(ErrDataChannelMessageTooLarge)
+		} else {
+			d.reassembly = append(d.reassembly, payload...)
+		}
+	}
+
+	if fragment[0]&fragmentFlagFinal == 0 {
+		return DataChannelMessage{}, false
+	}
+
+	m := DataChannelMessage{IsString: isString, Data: d.reassembly}
+	dropped := d.reassemblyDropped
+	d.reassembly, d.reassemblyDropped = nil, false
+	if dropped {
+		return DataChannelMessage{}, false
+	}
+
+	if m.Data == nil {
+		m.Data = []byte{}
+	}
+	return m, true
+}
+
+// onScheduled wakes up the fragmented messages being sent, once the data channel
+// sent its data or the scheduler wrote one of its queued messages
+func (d *DataChannel) onScheduled() {
+	select {
+	case d.bufferedAmountLow <- struct{}{}:
+	default:
+	}
+}
+
+// markClosing aborts the fragmented messages being sent
+func (d *DataChannel) markClosing() {
+	d.closingOnce.Do(func() {
+		if d.closing != nil {
+			close(d.closing)
+		}
+	})
+}
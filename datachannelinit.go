@@ -30,4 +30,17 @@ type DataChannelInit struct {
 
 	// ID overrides the default selection of ID for this channel.
 	ID *uint16
+
+	// Priority sets the share of the SCTP association given to this channel when
+	// several data channels are sending, the remote peer learns it when the
+	// channel is announced in-band. The default value is PriorityTypeLow.
+	Priority *PriorityType
+
+	// Fragmented enables messages larger than the max-message-size of the SCTP
+	// transport. Messages are split into fragments by the sender and reassembled
+	// by the receiver, see SettingEngine.SetDataChannelMaxFragmentedMessageSize.
+	// Fragmentation is announced with a sub-protocol, so the remote peer has to
+	// be a Pion peer too. Only reliable and ordered data channels can be
+	// fragmented, and they can't be detached. Not supported by the Wasm bindings.
+	Fragmented *bool
 }
@@ -0,0 +1,332 @@
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/datachannel"
+)
+
+const (
+	// dataChannelSchedulerBufferedAmount is the amount of data buffered by the SCTP
+	// association above which messages are queued by the scheduler
+	dataChannelSchedulerBufferedAmount = 128 * 1024
+
+	// dataChannelSchedulerQuantum is the number of bytes a data channel may send
+	// per round and per unit of its weight, so PriorityTypeLow sends 16 KiB per round
+	dataChannelSchedulerQuantum = 64
+
+	// dataChannelSchedulerInterval is how often queued messages are sent while the
+	// SCTP association buffers too much data
+	dataChannelSchedulerInterval = 5 * time.Millisecond
+)
+
+// dataChannelScheduler shares the SCTP association between data channels by
+// priority. The SCTP association sends messages in the order they are written,
+// so the scheduler only writes messages while it buffers little data. The
+// messages sent meanwhile are queued per data channel, and written by deficit
+// round robin, every data channel sending a share proportional to its weight.
+type dataChannelScheduler struct {
+	mu       sync.Mutex
+	channels map[*DataChannel]*scheduledChannel
+	// active are the data channels with queued messages, in round robin order
+	active  []*scheduledChannel
+	next    int
+	running bool
+	wake    chan struct{}
+}
+
+type scheduledChannel struct {
+	d           *DataChannel
+	dataChannel *datachannel.DataChannel
+	weight      int
+	deficit     int
+	queue       []scheduledMessage
+	queued      uint64
+	// aboveThreshold is set while the messages queued take the amount buffered
+	// above the threshold of the data channel, which SCTP isn't aware of
+	aboveThreshold bool
+	// suspended is set while the SCTP association restarts, the messages are queued
+	// until the data channel is re-created
+	suspended bool
+}
+
+type scheduledMessage struct {
+	data     []byte
+	isString bool
+	// ordered overrides the ordering of the data channel, and the message is
+	// dropped if it is still queued after its deadline, when set
+	ordered  *bool
+	deadline time.Time
+}
+
+func newDataChannelScheduler() *dataChannelScheduler {
+	return &dataChannelScheduler{
+		channels: map[*DataChannel]*scheduledChannel{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// add schedules the messages of an open data channel
+func (s *dataChannelScheduler) add(d *DataChannel, dc *datachannel.DataChannel, priority PriorityType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.channels[d] = &scheduledChannel{d: d, dataChannel: dc, weight: int(priority.weight())}
+}
+
+// remove drops the queued messages of a closed data channel
+func (s *dataChannelScheduler) remove(d *DataChannel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.channels[d]
+	if !ok {
+		return
+	}
+	delete(s.channels, d)
+	s.deactivate(c)
+}
+
+// suspend queues the messages of a data channel until it is resumed
+func (s *dataChannelScheduler) suspend(d *DataChannel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.channels[d]; ok {
+		c.suspended = true
+		s.removeActive(c)
+	}
+}
+
+// resume sends the messages queued while a data channel was suspended, over the
+// data channel re-created once the SCTP association restarted
+func (s *dataChannelScheduler) resume(d *DataChannel, dc *datachannel.DataChannel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.channels[d]
+	if !ok {
+		return
+	}
+	c.dataChannel, c.suspended = dc, false
+	if len(c.queue) == 0 {
+		return
+	}
+
+	s.active = append(s.active, c)
+	s.dispatch()
+	if len(s.active) != 0 && !s.running {
+		s.running = true
+		go s.run()
+	}
+}
+
+// queuedAmount returns the number of bytes queued for a data channel
+func (s *dataChannelScheduler) queuedAmount(d *DataChannel) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.channels[d]; ok {
+		return c.queued
+	}
+	return 0
+}
+
+// send writes a message, or queues it if the SCTP association buffers too much data
+// or other messages are queued already. Errors writing a queued message are reported
+// to the OnError handler of the data channel. Messages whose options don't let them
+// wait are dropped instead of being queued.
+func (s *dataChannelScheduler) send(d *DataChannel, dc *datachannel.DataChannel, data []byte, isString bool, options *DataChannelSendOptions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ordered *bool
+	if options != nil {
+		ordered = options.Ordered
+	}
+
+	c, ok := s.channels[d]
+	if !ok || (!c.suspended && len(s.active) == 0 && s.bufferedAmount() < dataChannelSchedulerBufferedAmount) {
+		return d.writeDataChannel(dc, data, isString, ordered)
+	}
+
+	// The caller may reuse data once send returns
+	m := scheduledMessage{data: append([]byte{}, data...), isString: isString, ordered: ordered}
+	if options != nil {
+		if options.DropIfQueued || (options.MaxQueueTime != nil && *options.MaxQueueTime == 0) {
+			d.onDropped(data)
+			return nil
+		}
+		if options.MaxQueueTime != nil {
+			m.deadline = time.Now().Add(time.Duration(*options.MaxQueueTime) * time.Millisecond)
+		}
+	}
+
+	c.queue = append(c.queue, m)
+	c.queued += uint64(len(data))
+	if c.queued+c.dataChannel.BufferedAmount() > c.dataChannel.BufferedAmountLowThreshold() {
+		c.aboveThreshold = true
+	}
+	if len(c.queue) == 1 && !c.suspended {
+		s.active = append(s.active, c)
+	}
+
+	s.dispatch()
+	if len(s.active) != 0 && !s.running {
+		s.running = true
+		go s.run()
+	}
+	return nil
+}
+
+// onBufferedAmountLow wakes the scheduler up once a data channel sent its data
+func (s *dataChannelScheduler) onBufferedAmountLow() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *dataChannelScheduler) run() {
+	ticker := time.NewTicker(dataChannelSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		}
+
+		s.mu.Lock()
+		s.dispatch()
+		low := s.belowThreshold()
+		running := len(s.active) != 0
+		s.running = running
+		s.mu.Unlock()
+
+		for _, d := range low {
+			d.onBufferedAmountLowHandler()
+		}
+		if !running {
+			return
+		}
+	}
+}
+
+// belowThreshold returns the data channels whose amount buffered fell to their
+// threshold while messages were queued. Once no message is queued, the SCTP stream
+// fires the event itself. Caller must hold the lock.
+func (s *dataChannelScheduler) belowThreshold() (low []*DataChannel) {
+	for _, c := range s.channels {
+		if !c.aboveThreshold || c.suspended {
+			continue
+		}
+
+		buffered := c.dataChannel.BufferedAmount()
+		if c.queued+buffered <= c.dataChannel.BufferedAmountLowThreshold() {
+			c.aboveThreshold = false
+			low = append(low, c.d)
+		} else if c.queued == 0 {
+			c.aboveThreshold = false
+		}
+	}
+	return low
+}
+
+// dispatch writes queued messages until the SCTP association buffers too much
+// data. Every data channel visited is given its quantum once it can't send its
+// next message. Caller must hold the lock.
+func (s *dataChannelScheduler) dispatch() {
+	s.dropExpired(time.Now())
+
+	for len(s.active) != 0 && s.bufferedAmount() < dataChannelSchedulerBufferedAmount {
+		if s.next >= len(s.active) {
+			s.next = 0
+		}
+
+		c := s.active[s.next]
+		m := c.queue[0]
+		if c.deficit < len(m.data) {
+			c.deficit += c.weight * dataChannelSchedulerQuantum
+			s.next++
+			continue
+		}
+
+		c.deficit -= len(m.data)
+		c.queue[0] = scheduledMessage{}
+		c.queue = c.queue[1:]
+		c.queued -= uint64(len(m.data))
+		if len(c.queue) == 0 {
+			s.deactivate(c)
+		}
+
+		if err := c.d.writeDataChannel(c.dataChannel, m.data, m.isString, m.ordered); err != nil {
+			c.d.onError(err)
+		}
+		c.d.onScheduled()
+	}
+}
+
+// dropExpired drops the queued messages whose deadline passed. Caller must
+// hold the lock.
+func (s *dataChannelScheduler) dropExpired(now time.Time) {
+	// Iterating backwards, as deactivate removes the channel from s.active
+	for i := len(s.active) - 1; i >= 0; i-- {
+		c := s.active[i]
+		queue := c.queue[:0]
+		for _, m := range c.queue {
+			if m.deadline.IsZero() || now.Before(m.deadline) {
+				queue = append(queue, m)
+				continue
+			}
+			c.queued -= uint64(len(m.data))
+			c.d.onDropped(m.data)
+		}
+
+		for i := len(queue); i < len(c.queue); i++ {
+			c.queue[i] = scheduledMessage{}
+		}
+		c.queue = queue
+		if len(c.queue) == 0 {
+			s.deactivate(c)
+		}
+	}
+}
+
+// deactivate removes a data channel from the round robin and drops its queued
+// messages. Caller must hold the lock.
+func (s *dataChannelScheduler) deactivate(c *scheduledChannel) {
+	s.removeActive(c)
+	c.queue, c.queued, c.deficit = nil, 0, 0
+}
+
+// removeActive removes a data channel from the round robin. Caller must hold the lock.
+func (s *dataChannelScheduler) removeActive(c *scheduledChannel) {
+	for i, active := range s.active {
+		if active != c {
+			continue
+		}
+
+		s.active = append(s.active[:i], s.active[i+1:]...)
+		if i < s.next {
+			s.next--
+		}
+		break
+	}
+}
+
+// bufferedAmount returns the amount of data buffered by the SCTP streams of the
+// scheduled data channels, but the streams of the previous association while it
+// restarts. Caller must hold the lock.
+func (s *dataChannelScheduler) bufferedAmount() uint64 {
+	var amount uint64
+	for _, c := range s.channels {
+		if !c.suspended {
+			amount += c.dataChannel.BufferedAmount()
+		}
+	}
+	return amount
+}
@@ -0,0 +1,89 @@
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/transport/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataChannelScheduler(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const bulkMessages = 256
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	veryLow, high := PriorityTypeVeryLow, PriorityTypeHigh
+	bulk, err := offerPC.CreateDataChannel("bulk", &DataChannelInit{Priority: &veryLow})
+	assert.NoError(t, err)
+	control, err := offerPC.CreateDataChannel("control", &DataChannelInit{Priority: &high})
+	assert.NoError(t, err)
+	assert.Equal(t, PriorityTypeVeryLow, bulk.Priority())
+	assert.Equal(t, PriorityTypeHigh, control.Priority())
+
+	bulkReceived := make(chan int, bulkMessages)
+	controlReceived := make(chan int, 1)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		switch d.Label() {
+		case "bulk":
+			assert.Equal(t, PriorityTypeVeryLow, d.Priority())
+			received := 0
+			d.OnMessage(func(msg DataChannelMessage) {
+				received++
+				bulkReceived <- received
+			})
+		case "control":
+			assert.Equal(t, PriorityTypeHigh, d.Priority())
+			d.OnMessage(func(msg DataChannelMessage) {
+				controlReceived <- len(bulkReceived)
+			})
+		}
+	})
+
+	// The threshold is above the amount buffered by SCTP, so the event is fired
+	// once the scheduler sent enough of the queued messages
+	bulkLow := make(chan struct{}, 1)
+	bulk.SetBufferedAmountLowThreshold(512 * 1024)
+	bulk.OnBufferedAmountLow(func() {
+		select {
+		case bulkLow <- struct{}{}:
+		default:
+		}
+	})
+
+	controlOpen := make(chan struct{})
+	control.OnOpen(func() {
+		close(controlOpen)
+	})
+	bulk.OnOpen(func() {
+		<-controlOpen
+
+		// The bulk messages are queued by the scheduler, as they are written faster than sent
+		buf := make([]byte, 16384)
+		for i := 0; i < bulkMessages; i++ {
+			assert.NoError(t, bulk.Send(buf))
+		}
+		assert.Greater(t, bulk.BufferedAmount(), uint64(dataChannelSchedulerBufferedAmount))
+		assert.NoError(t, control.SendText("urgent"))
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+
+	// The control message overtakes the queued bulk messages
+	assert.Less(t, <-controlReceived, bulkMessages)
+	for received := 0; received < bulkMessages; {
+		received = <-bulkReceived
+	}
+	<-bulkLow
+
+	closePairNow(t, offerPC, answerPC)
+}
@@ -0,0 +1,45 @@
+package webrtc
+
+// DataChannelSendOptions changes how a single message sent with SendWithOptions
+// is sent. Fields left unset keep the behavior of the data channel. Only the
+// ordering applies on the wire: once the message is handed to SCTP, it is
+// retransmitted following the reliability of the data channel. MaxQueueTime
+// and DropIfQueued only apply while the message waits behind other messages.
+//
+// Per-message partial reliability (MaxRetransmits, MaxPacketLifeTime) is not
+// implemented. pion/sctp checks the reliability of the SCTP stream when a chunk
+// is retransmitted, not when it is written, so it can't be overridden for a
+// single message. Setting either fails with ErrSendReliabilityNotImplemented.
+type DataChannelSendOptions struct {
+	// Ordered indicates if the message may be delivered out of order, with
+	// respect to the other messages of the data channel.
+	Ordered *bool
+
+	// MaxQueueTime limits the time (in milliseconds) the message may wait to
+	// be handed to SCTP while other messages are queued. The message is
+	// dropped once it expires.
+	MaxQueueTime *uint16
+
+	// DropIfQueued drops the message if it can't be handed to SCTP without
+	// waiting for the messages queued before it.
+	DropIfQueued bool
+
+	// MaxRetransmits limits the number of times the message is retransmitted.
+	// Not implemented yet.
+	MaxRetransmits *uint16
+
+	// MaxPacketLifeTime limits the time (in milliseconds) during which the
+	// message is retransmitted. Not implemented yet.
+	MaxPacketLifeTime *uint16
+}
+
+// overrides returns whether the options change how the message is sent
+func (o *DataChannelSendOptions) overrides() bool {
+	return o != nil && (o.Ordered != nil || o.MaxQueueTime != nil || o.DropIfQueued || o.partiallyReliable())
+}
+
+// partiallyReliable returns whether the options override the partial reliability
+// of the data channel
+func (o *DataChannelSendOptions) partiallyReliable() bool {
+	return o.MaxRetransmits != nil || o.MaxPacketLifeTime != nil
+}
@@ -8,8 +8,10 @@ import (
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -20,6 +22,7 @@ import (
 	"github.com/pion/logging"
 	"github.com/pion/rtcp"
 	"github.com/pion/srtp/v2"
+	"github.com/pion/transport/packetio"
 	"github.com/pion/webrtc/v3/internal/mux"
 	"github.com/pion/webrtc/v3/internal/util"
 	"github.com/pion/webrtc/v3/pkg/rtcerr"
@@ -50,10 +53,29 @@ type DTLSTransport struct {
 
 	dtlsMatcher mux.MatchFunc
 
+	transportCCSequence uint32 // accessed atomically
+
+	// senderReportTracks maps the SSRC of every TrackRemote to the track, so it gets
+	// the Sender Reports received for it
+	senderReportTracks sync.Map
+
+	// rtpBuffers maps the SSRC of every SRTP read stream to its buffer, so tracks
+	// know if a packet can be read without blocking
+	rtpBuffers sync.Map
+
 	api *API
 	log logging.LeveledLogger
 }
 
+const (
+	// The buffer sizes of the read streams of pion/srtp
+	srtpBufferSize  = 1000 * 1000
+	srtcpBufferSize = 100 * 1000
+
+	rtcpHeaderSize          = 4
+	rtcpSenderReportMinSize = 28
+)
+
 // NewDTLSTransport creates a new DTLSTransport.
 // This constructor is part of the ORTC API. It is not
 // meant to be used together with the basic WebRTC API.
@@ -98,6 +120,12 @@ func (t *DTLSTransport) ICETransport() *ICETransport {
 	return t.iceTransport
 }
 
+// nextTransportCCSequence returns the next transport-wide sequence number. It is shared
+// between every RTP stream sent over this transport.
+func (t *DTLSTransport) nextTransportCCSequence() uint16 {
+	return uint16(atomic.AddUint32(&t.transportCCSequence, 1))
+}
+
 // onStateChange requires the caller holds the lock
 func (t *DTLSTransport) onStateChange(state DTLSTransportState) {
 	t.state = state
@@ -176,7 +204,7 @@ func (t *DTLSTransport) GetRemoteCertificate() []byte {
 func (t *DTLSTransport) startSRTP() error {
 	srtpConfig := &srtp.Config{
 		Profile:       t.srtpProtectionProfile,
-		BufferFactory: t.api.settingEngine.BufferFactory,
+		BufferFactory: t.bufferFactory,
 		LoggerFactory: t.api.settingEngine.LoggerFactory,
 	}
 	if t.api.settingEngine.replayProtection.SRTP != nil {
@@ -229,6 +257,129 @@ func (t *DTLSTransport) startSRTP() error {
 	return nil
 }
 
+// bufferFactory creates the buffers of the SRTP and SRTCP read streams, with the
+// BufferFactory of the SettingEngine if set and the sizes of pion/srtp otherwise.
+// RTCP is scanned for Sender Reports when it is received, so they are handled even
+// if RTCP isn't read.
+func (t *DTLSTransport) bufferFactory(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	var buffer io.ReadWriteCloser
+	if factory := t.api.settingEngine.BufferFactory; factory != nil {
+		buffer = factory(packetType, ssrc)
+	} else {
+		b := packetio.NewBuffer()
+		if packetType == packetio.RTCPBufferPacket {
+			b.SetLimitSize(srtcpBufferSize)
+		} else {
+			b.SetLimitSize(srtpBufferSize)
+		}
+		buffer = b
+	}
+
+	if packetType != packetio.RTCPBufferPacket {
+		b := &rtpBuffer{ReadWriteCloser: buffer, transport: t, ssrc: SSRC(ssrc)}
+		t.rtpBuffers.Store(b.ssrc, b)
+		return b
+	}
+	return &senderReportBuffer{ReadWriteCloser: buffer, transport: t}
+}
+
+// rtpBuffered returns true if a packet of the SRTP read stream of ssrc can be read
+// without blocking
+func (t *DTLSTransport) rtpBuffered(ssrc SSRC) bool {
+	b, ok := t.rtpBuffers.Load(ssrc)
+	return ok && atomic.LoadInt32(&b.(*rtpBuffer).packets) > 0
+}
+
+func (t *DTLSTransport) addSenderReportTrack(track *TrackRemote) {
+	t.senderReportTracks.Store(track.SSRC(), track)
+}
+
+func (t *DTLSTransport) removeSenderReportTrack(track *TrackRemote) {
+	t.senderReportTracks.Delete(track.SSRC())
+}
+
+// handleSenderReports passes the Sender Reports of a compound RTCP packet to the tracks
+// they describe. The packet is parsed in place, as it is unmarshaled again when read.
+func (t *DTLSTransport) handleSenderReports(raw []byte) {
+	for len(raw) >= rtcpHeaderSize {
+		length := (int(binary.BigEndian.Uint16(raw[2:])) + 1) * 4
+		if length > len(raw) {
+			return
+		}
+
+		if raw[1] == uint8(rtcp.TypeSenderReport) && length >= rtcpSenderReportMinSize {
+			if track, ok := t.senderReportTracks.Load(SSRC(binary.BigEndian.Uint32(raw[4:]))); ok {
+				track.(*TrackRemote).handleSenderReport(binary.BigEndian.Uint64(raw[8:]), binary.BigEndian.Uint32(raw[16:]))
+			}
+		}
+		raw = raw[length:]
+	}
+}
+
+// senderReportBuffer is the buffer of a SRTCP read stream, which passes the Sender
+// Reports written to it to the DTLSTransport
+type senderReportBuffer struct {
+	io.ReadWriteCloser
+	transport *DTLSTransport
+}
+
+func (b *senderReportBuffer) Write(raw []byte) (int, error) {
+	b.transport.handleSenderReports(raw)
+	return b.ReadWriteCloser.Write(raw)
+}
+
+// SetReadDeadline is used by the read stream if the buffer implements it
+func (b *senderReportBuffer) SetReadDeadline(deadline time.Time) error {
+	if buffer, ok := b.ReadWriteCloser.(interface {
+		SetReadDeadline(time.Time) error
+	}); ok {
+		return buffer.SetReadDeadline(deadline)
+	}
+	return nil
+}
+
+// rtpBuffer is the buffer of a SRTP read stream, which counts the packets that can
+// be read from it
+type rtpBuffer struct {
+	io.ReadWriteCloser
+	transport *DTLSTransport
+	ssrc      SSRC
+	packets   int32 // accessed atomically
+}
+
+func (b *rtpBuffer) Write(raw []byte) (int, error) {
+	n, err := b.ReadWriteCloser.Write(raw)
+	if err == nil {
+		atomic.AddInt32(&b.packets, 1)
+	}
+	return n, err
+}
+
+func (b *rtpBuffer) Read(buf []byte) (int, error) {
+	n, err := b.ReadWriteCloser.Read(buf)
+	if err == nil || errors.Is(err, io.ErrShortBuffer) {
+		atomic.AddInt32(&b.packets, -1)
+	}
+	return n, err
+}
+
+func (b *rtpBuffer) Close() error {
+	if current, ok := b.transport.rtpBuffers.Load(b.ssrc); ok && current == b {
+		b.transport.rtpBuffers.Delete(b.ssrc)
+	}
+	return b.ReadWriteCloser.Close()
+}
+
+// SetReadDeadline is used by the read stream if the buffer implements it
+func (b *rtpBuffer) SetReadDeadline(deadline time.Time) error {
+	if buffer, ok := b.ReadWriteCloser.(interface {
+		SetReadDeadline(time.Time) error
+	}); ok {
+		return buffer.SetReadDeadline(deadline)
+	}
+	return nil
+}
+
 func (t *DTLSTransport) getSRTPSession() (*srtp.SessionSRTP, error) {
 	if value := t.srtpSession.Load(); value != nil {
 		return value.(*srtp.SessionSRTP), nil
@@ -287,8 +438,10 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 			return DTLSRole(0), nil, &rtcerr.InvalidStateError{Err: fmt.Errorf("%w: %s", errInvalidDTLSStart, t.state)}
 		}
 
-		t.srtpEndpoint = t.iceTransport.newEndpoint(mux.MatchSRTP)
-		t.srtcpEndpoint = t.iceTransport.newEndpoint(mux.MatchSRTCP)
+		if !t.api.settingEngine.disableMedia {
+			t.srtpEndpoint = t.iceTransport.newEndpoint(mux.MatchSRTP)
+			t.srtcpEndpoint = t.iceTransport.newEndpoint(mux.MatchSRTCP)
+		}
 		t.remoteParameters = remoteParameters
 
 		cert := t.certificates[0]
@@ -392,6 +545,9 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 	t.conn = dtlsConn
 	t.onStateChange(DTLSTransportStateConnected)
 
+	if t.api.settingEngine.disableMedia {
+		return nil
+	}
 	return t.startSRTP()
 }
 
@@ -0,0 +1,480 @@
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/randutil"
+	"github.com/pion/rtp"
+)
+
+// dtmfTones are the DTMF tones, indexed by their RFC 4733 event code
+const dtmfTones = "0123456789*#ABCD"
+
+// dtmfPause is not a tone, but a pause of dtmfPauseDuration between tones
+const dtmfPause = ','
+
+const (
+	dtmfMinDuration     = 40 * time.Millisecond
+	dtmfMaxDuration     = 6000 * time.Millisecond
+	dtmfMinInterToneGap = 30 * time.Millisecond
+	dtmfPauseDuration   = 2 * time.Second
+
+	// dtmfPacketInterval is how often a telephone-event packet is sent while a tone is played
+	dtmfPacketInterval = 50 * time.Millisecond
+
+	// dtmfEndRetransmissions is how often the final packet of an event is sent,
+	// https://tools.ietf.org/html/rfc4733#section-2.5.1.4
+	dtmfEndRetransmissions = 3
+
+	// dtmfVolume is the power level of sent tones in -dBm0
+	dtmfVolume = 10
+)
+
+const (
+	telephoneEventSize        = 4
+	telephoneEventEndBit      = 0x80
+	telephoneEventVolumeMask  = 0x3F
+	telephoneEventMaxDuration = 0xFFFF
+)
+
+// telephoneEvent is the payload of a RFC 4733 telephone-event packet
+type telephoneEvent struct {
+	event    uint8
+	end      bool
+	volume   uint8
+	duration uint16
+}
+
+func (e telephoneEvent) marshal() []byte {
+	/*
+	 * https://tools.ietf.org/html/rfc4733#section-2.3
+	 *
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |     event     |E|R| volume    |          duration             |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	out := make([]byte, telephoneEventSize)
+	out[0] = e.event
+	out[1] = e.volume & telephoneEventVolumeMask
+	if e.end {
+		out[1] |= telephoneEventEndBit
+	}
+	binary.BigEndian.PutUint16(out[2:], e.duration)
+	return out
+}
+
+func (e *telephoneEvent) unmarshal(payload []byte) error {
+	if len(payload) < telephoneEventSize {
+		return errTelephoneEventTooShort
+	}
+
+	e.event = payload[0]
+	e.end = payload[1]&telephoneEventEndBit != 0
+	e.volume = payload[1] & telephoneEventVolumeMask
+	e.duration = binary.BigEndian.Uint16(payload[2:])
+	return nil
+}
+
+// telephoneEventSegment splits the elapsed duration of an event into the timestamp offset
+// of its current segment and the duration within that segment. Events that don't fit into
+// the duration field are sent in segments, https://tools.ietf.org/html/rfc4733#section-2.5.1.3
+func telephoneEventSegment(elapsed uint32) (offset uint32, duration uint16) {
+	if elapsed == 0 {
+		return 0, 0
+	}
+
+	segment := (elapsed - 1) / telephoneEventMaxDuration
+	offset = segment * telephoneEventMaxDuration
+	return offset, uint16(elapsed - offset)
+}
+
+func isTelephoneEvent(codec RTPCodecParameters) bool {
+	return strings.EqualFold(codec.MimeType, MimeTypeTelephoneEvent)
+}
+
+// DTMFEvent is a DTMF tone that was received as a RFC 4733 telephone-event
+type DTMFEvent struct {
+	// Tone is the DTMF tone, one of "0123456789*#ABCD"
+	Tone string
+
+	// Duration is how long the tone was played
+	Duration time.Duration
+
+	// Volume is the power level of the tone in -dBm0, from 0 to 63
+	Volume uint8
+}
+
+// DTMFSender sends DTMF tones as RFC 4733 telephone-events. The events are sent with the
+// SSRC of the audio RTPSender it belongs to, interleaved with its audio packets.
+type DTMFSender struct {
+	mu sync.Mutex
+
+	writer      interceptor.RTPWriter
+	ssrc        SSRC
+	payloadType PayloadType
+	clockRate   uint32
+	canSend     bool
+
+	// sequenceOffset is added to the sequence numbers of audio packets, it is
+	// increased for every telephone-event packet that is sent in between
+	sequenceOffset     uint16
+	lastSequenceNumber uint16
+	hasWrittenAudio    bool
+
+	// mapping of the latest audio packet to the time it was sent, used to give
+	// events a timestamp that fits into the audio stream
+	lastTimestamp     uint32
+	lastTimestampTime time.Time
+
+	toneBuffer   string
+	duration     time.Duration
+	interToneGap time.Duration
+	playing      bool
+	onToneChange func(tone string)
+
+	closed chan struct{}
+}
+
+func newDTMFSender() *DTMFSender {
+	return &DTMFSender{closed: make(chan struct{})}
+}
+
+// bind starts sending audio packets to writer. Telephone-events can be sent if one was
+// negotiated with the same clock rate as codec.
+func (s *DTMFSender) bind(writer interceptor.RTPWriter, ssrc SSRC, codec RTPCodecParameters, codecs []RTPCodecParameters) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writer = writer
+	s.ssrc = ssrc
+	for _, c := range codecs {
+		if isTelephoneEvent(c) && c.ClockRate == codec.ClockRate {
+			s.payloadType, s.clockRate, s.canSend = c.PayloadType, c.ClockRate, true
+			break
+		}
+	}
+
+	random := randutil.NewMathRandomGenerator()
+	s.lastSequenceNumber = uint16(random.Uint32())
+	s.lastTimestamp = random.Uint32()
+	s.lastTimestampTime = time.Now()
+}
+
+// writeRTP writes an audio packet, moving its sequence number behind the
+// telephone-event packets that have been sent so far
+func (s *DTMFSender) writeRTP(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sequenceOffset != 0 {
+		// The header may be reused by the caller, so it must not be modified
+		rewritten := *header
+		rewritten.SequenceNumber += s.sequenceOffset
+		header = &rewritten
+	}
+
+	if !s.hasWrittenAudio || int16(header.SequenceNumber-s.lastSequenceNumber) > 0 {
+		s.lastSequenceNumber = header.SequenceNumber
+	}
+	s.hasWrittenAudio = true
+	s.lastTimestamp = header.Timestamp
+	s.lastTimestampTime = time.Now()
+
+	return s.writer.Write(header, payload, attributes)
+}
+
+// writeEvent sends a single telephone-event packet with the next sequence number of the audio stream
+func (s *DTMFSender) writeEvent(timestamp uint32, event telephoneEvent, marker bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastSequenceNumber++
+	s.sequenceOffset++
+
+	_, err := s.writer.Write(&rtp.Header{
+		Version:        2,
+		Marker:         marker,
+		PayloadType:    uint8(s.payloadType),
+		SequenceNumber: s.lastSequenceNumber,
+		Timestamp:      timestamp,
+		SSRC:           uint32(s.ssrc),
+	}, event.marshal(), interceptor.Attributes{})
+	return err
+}
+
+// CanInsertDTMF returns true if telephone-events have been negotiated for the audio
+// codec of the RTPSender, so InsertDTMF can be used.
+func (s *DTMFSender) CanInsertDTMF() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.closed:
+		return false
+	default:
+	}
+	return s.canSend
+}
+
+// InsertDTMF schedules tones to be sent. Valid tones are "0123456789*#ABCD", lower case
+// letters are accepted as well, and a ',' pauses for two seconds. Every tone is played for
+// duration, followed by a pause of interToneGap. The duration is clamped between 40ms and
+// 6000ms, and the gap is at least 30ms.
+//
+// Calling InsertDTMF replaces the tones that haven't been played yet. An empty
+// string cancels them.
+func (s *DTMFSender) InsertDTMF(tones string, duration, interToneGap time.Duration) error {
+	tones = strings.ToUpper(tones)
+	for _, tone := range tones {
+		if tone != dtmfPause && !strings.ContainsRune(dtmfTones, tone) {
+			return ErrDTMFInvalidTone
+		}
+	}
+
+	if !s.CanInsertDTMF() {
+		return ErrDTMFNotNegotiated
+	}
+
+	switch {
+	case duration < dtmfMinDuration:
+		duration = dtmfMinDuration
+	case duration > dtmfMaxDuration:
+		duration = dtmfMaxDuration
+	}
+	if interToneGap < dtmfMinInterToneGap {
+		interToneGap = dtmfMinInterToneGap
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.toneBuffer = tones
+	s.duration = duration
+	s.interToneGap = interToneGap
+	if !s.playing && tones != "" {
+		s.playing = true
+		go s.playTones()
+	}
+	return nil
+}
+
+// ToneBuffer returns the tones that are waiting to be played
+func (s *DTMFSender) ToneBuffer() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.toneBuffer
+}
+
+// OnToneChange sets an event handler which is invoked when a tone starts playing.
+// It is invoked with an empty string once all tones have been played.
+func (s *DTMFSender) OnToneChange(f func(tone string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onToneChange = f
+}
+
+// close stops playing tones
+func (s *DTMFSender) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+}
+
+// wait blocks for d, it returns false if the DTMFSender has been closed in the meantime
+func (s *DTMFSender) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-s.closed:
+		return false
+	}
+}
+
+// playTones plays the tone buffer until it is empty
+func (s *DTMFSender) playTones() {
+	for {
+		s.mu.Lock()
+		handler := s.onToneChange
+		if s.toneBuffer == "" {
+			s.playing = false
+			s.mu.Unlock()
+
+			if handler != nil {
+				handler("")
+			}
+			return
+		}
+
+		tone := s.toneBuffer[0]
+		s.toneBuffer = s.toneBuffer[1:]
+		duration, interToneGap := s.duration, s.interToneGap
+		s.mu.Unlock()
+
+		if handler != nil {
+			handler(string(tone))
+		}
+
+		if tone == dtmfPause {
+			if !s.wait(dtmfPauseDuration) {
+				return
+			}
+			continue
+		}
+
+		if err := s.playTone(uint8(strings.IndexByte(dtmfTones, tone)), duration); err != nil {
+			s.mu.Lock()
+			s.toneBuffer, s.playing = "", false
+			s.mu.Unlock()
+			return
+		}
+
+		if !s.wait(interToneGap) {
+			return
+		}
+	}
+}
+
+// playTone sends the packets of a single event, updating its duration every
+// dtmfPacketInterval until duration has passed
+func (s *DTMFSender) playTone(event uint8, duration time.Duration) error {
+	start := time.Now()
+
+	s.mu.Lock()
+	clockRate := float64(s.clockRate)
+	timestamp := s.lastTimestamp + uint32(start.Sub(s.lastTimestampTime).Seconds()*clockRate)
+	s.mu.Unlock()
+
+	total := uint32(duration.Seconds() * clockRate)
+	elapsed := uint32(0)
+
+	ticker := time.NewTicker(dtmfPacketInterval)
+	defer ticker.Stop()
+
+	for marker := true; ; marker = false {
+		offset, segmentDuration := telephoneEventSegment(elapsed)
+		end := elapsed >= total
+
+		packets := 1
+		if end {
+			packets = dtmfEndRetransmissions
+		}
+		for i := 0; i < packets; i++ {
+			if err := s.writeEvent(timestamp+offset, telephoneEvent{event: event, end: end, volume: dtmfVolume, duration: segmentDuration}, marker); err != nil {
+				return err
+			}
+		}
+
+		if end {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.closed:
+			return nil
+		}
+
+		if elapsed = uint32(time.Since(start).Seconds() * clockRate); elapsed > total {
+			elapsed = total
+		}
+	}
+}
+
+// telephoneEventReceiver turns the telephone-event packets of a TrackRemote into DTMFEvents
+type telephoneEventReceiver struct {
+	// clockRates maps the payload types of the negotiated telephone-events to their clock rate
+	clockRates map[PayloadType]uint32
+
+	hasEvent       bool
+	ended          bool
+	timestamp      uint32
+	event          telephoneEvent
+	durationOffset uint32
+}
+
+func newTelephoneEventReceiver(codecs []RTPCodecParameters) *telephoneEventReceiver {
+	clockRates := map[PayloadType]uint32{}
+	for _, c := range codecs {
+		if isTelephoneEvent(c) {
+			clockRates[c.PayloadType] = c.ClockRate
+		}
+	}
+
+	if len(clockRates) == 0 {
+		return nil
+	}
+	return &telephoneEventReceiver{clockRates: clockRates}
+}
+
+// isTelephoneEvent returns true if payloadType is a negotiated telephone-event
+func (r *telephoneEventReceiver) isTelephoneEvent(payloadType PayloadType) bool {
+	_, ok := r.clockRates[payloadType]
+	return ok
+}
+
+// handle processes a telephone-event packet and calls emit for every event that it completes.
+// Events are completed by their end packet, or by the start of the next event if all end
+// packets were lost.
+func (r *telephoneEventReceiver) handle(header *rtp.Header, payload []byte, emit func(DTMFEvent)) {
+	event := telephoneEvent{}
+	if err := event.unmarshal(payload); err != nil {
+		return
+	}
+	clockRate := r.clockRates[PayloadType(header.PayloadType)]
+
+	switch {
+	case r.hasEvent && header.Timestamp == r.timestamp:
+		// update of the current segment
+	case r.hasEvent && !r.ended && !header.Marker && event.event == r.event.event &&
+		header.Timestamp == r.timestamp+uint32(r.event.duration):
+		// next segment of a long event
+		r.durationOffset += uint32(r.event.duration)
+		r.timestamp = header.Timestamp
+	default:
+		if r.hasEvent && !r.ended {
+			r.emit(clockRate, emit)
+		}
+		r.hasEvent, r.ended = true, false
+		r.timestamp = header.Timestamp
+		r.durationOffset = 0
+	}
+
+	if r.ended {
+		return
+	}
+
+	r.event = event
+	if event.end {
+		r.ended = true
+		r.emit(clockRate, emit)
+	}
+}
+
+func (r *telephoneEventReceiver) emit(clockRate uint32, emit func(DTMFEvent)) {
+	if int(r.event.event) >= len(dtmfTones) || clockRate == 0 {
+		return
+	}
+
+	samples := r.durationOffset + uint32(r.event.duration)
+	emit(DTMFEvent{
+		Tone:     string(dtmfTones[r.event.event]),
+		Duration: time.Duration(uint64(samples) * uint64(time.Second) / uint64(clockRate)),
+		Volume:   r.event.volume,
+	})
+}
@@ -0,0 +1,277 @@
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelephoneEvent(t *testing.T) {
+	event := telephoneEvent{event: 11, end: true, volume: 10, duration: 800}
+	raw := event.marshal()
+	assert.Equal(t, []byte{0x0B, 0x8A, 0x03, 0x20}, raw)
+
+	parsed := telephoneEvent{}
+	assert.NoError(t, parsed.unmarshal(raw))
+	assert.Equal(t, event, parsed)
+
+	assert.Equal(t, errTelephoneEventTooShort, parsed.unmarshal(raw[:3]))
+}
+
+func TestTelephoneEventSegment(t *testing.T) {
+	for _, test := range []struct {
+		elapsed  uint32
+		offset   uint32
+		duration uint16
+	}{
+		{0, 0, 0},
+		{800, 0, 800},
+		{0xFFFF, 0, 0xFFFF},
+		{0x10000, 0xFFFF, 1},
+		{3 * 0xFFFF, 2 * 0xFFFF, 0xFFFF},
+	} {
+		offset, duration := telephoneEventSegment(test.elapsed)
+		assert.Equal(t, test.offset, offset, "elapsed %d", test.elapsed)
+		assert.Equal(t, test.duration, duration, "elapsed %d", test.elapsed)
+	}
+}
+
+func TestTelephoneEventReceiver(t *testing.T) {
+	assert.Nil(t, newTelephoneEventReceiver([]RTPCodecParameters{{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeOpus}}}))
+
+	newReceiver := func() *telephoneEventReceiver {
+		return newTelephoneEventReceiver([]RTPCodecParameters{{
+			RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeTelephoneEvent, ClockRate: 8000},
+			PayloadType:        126,
+		}})
+	}
+
+	type packet struct {
+		timestamp uint32
+		marker    bool
+		event     telephoneEvent
+	}
+	receive := func(r *telephoneEventReceiver, packets []packet) []DTMFEvent {
+		events := []DTMFEvent{}
+		for _, p := range packets {
+			r.handle(&rtp.Header{PayloadType: 126, Timestamp: p.timestamp, Marker: p.marker}, p.event.marshal(), func(e DTMFEvent) {
+				events = append(events, e)
+			})
+		}
+		return events
+	}
+
+	t.Run("Single event", func(t *testing.T) {
+		r := newReceiver()
+		assert.True(t, r.isTelephoneEvent(126))
+		assert.False(t, r.isTelephoneEvent(0))
+
+		events := receive(r, []packet{
+			{1000, true, telephoneEvent{event: 1, volume: 10, duration: 0}},
+			{1000, false, telephoneEvent{event: 1, volume: 10, duration: 400}},
+			{1000, false, telephoneEvent{event: 1, end: true, volume: 10, duration: 800}},
+			{1000, false, telephoneEvent{event: 1, end: true, volume: 10, duration: 800}},
+			{1000, false, telephoneEvent{event: 1, end: true, volume: 10, duration: 800}},
+		})
+		assert.Equal(t, []DTMFEvent{{Tone: "1", Duration: 100 * time.Millisecond, Volume: 10}}, events)
+	})
+
+	t.Run("Lost end", func(t *testing.T) {
+		r := newReceiver()
+		events := receive(r, []packet{
+			{1000, true, telephoneEvent{event: 10, volume: 10, duration: 400}},
+			{3000, true, telephoneEvent{event: 11, end: true, volume: 10, duration: 800}},
+		})
+		assert.Equal(t, []DTMFEvent{
+			{Tone: "*", Duration: 50 * time.Millisecond, Volume: 10},
+			{Tone: "#", Duration: 100 * time.Millisecond, Volume: 10},
+		}, events)
+	})
+
+	t.Run("Long event", func(t *testing.T) {
+		r := newReceiver()
+		events := receive(r, []packet{
+			{1000, true, telephoneEvent{event: 15, volume: 10, duration: 0xFFFF}},
+			{1000 + 0xFFFF, false, telephoneEvent{event: 15, volume: 10, duration: 100}},
+			{1000 + 0xFFFF, false, telephoneEvent{event: 15, end: true, volume: 10, duration: 0xFFFF}},
+		})
+		assert.Equal(t, []DTMFEvent{{Tone: "D", Duration: time.Duration(2*0xFFFF) * time.Second / 8000, Volume: 10}}, events)
+	})
+
+	t.Run("Not a DTMF tone", func(t *testing.T) {
+		r := newReceiver()
+		events := receive(r, []packet{
+			{1000, true, telephoneEvent{event: 16, end: true, duration: 800}},
+		})
+		assert.Empty(t, events)
+	})
+}
+
+func TestDTMFSender(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcmu := RTPCodecParameters{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypePCMU, ClockRate: 8000}, PayloadType: 0}
+	telephoneEvent8000 := RTPCodecParameters{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeTelephoneEvent, ClockRate: 8000}, PayloadType: 126}
+	telephoneEvent48000 := RTPCodecParameters{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeTelephoneEvent, ClockRate: 48000}, PayloadType: 110}
+
+	type writtenPacket struct {
+		header  rtp.Header
+		payload []byte
+	}
+
+	newSender := func(codecs []RTPCodecParameters) (*DTMFSender, chan writtenPacket) {
+		written := make(chan writtenPacket, 100)
+		s := newDTMFSender()
+		s.bind(interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+			written <- writtenPacket{*header, append([]byte{}, payload...)}
+			return len(payload), nil
+		}), 5000, pcmu, codecs)
+		return s, written
+	}
+
+	t.Run("Not negotiated", func(t *testing.T) {
+		s, _ := newSender([]RTPCodecParameters{pcmu, telephoneEvent48000})
+		assert.False(t, s.CanInsertDTMF())
+		assert.Equal(t, ErrDTMFNotNegotiated, s.InsertDTMF("1", time.Second, time.Second))
+	})
+
+	t.Run("Invalid tone", func(t *testing.T) {
+		s, _ := newSender([]RTPCodecParameters{pcmu, telephoneEvent8000})
+		assert.Equal(t, ErrDTMFInvalidTone, s.InsertDTMF("12E", time.Second, time.Second))
+		assert.Equal(t, "", s.ToneBuffer())
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		s, _ := newSender([]RTPCodecParameters{pcmu, telephoneEvent8000})
+		assert.True(t, s.CanInsertDTMF())
+		s.close()
+		assert.False(t, s.CanInsertDTMF())
+	})
+
+	t.Run("Interleaved", func(t *testing.T) {
+		s, written := newSender([]RTPCodecParameters{pcmu, telephoneEvent48000, telephoneEvent8000})
+
+		_, err := s.writeRTP(&rtp.Header{PayloadType: 0, SequenceNumber: 100, Timestamp: 8000, SSRC: 5000}, []byte{0x00}, nil)
+		assert.NoError(t, err)
+		audio := (<-written).header
+		assert.Equal(t, uint16(100), audio.SequenceNumber)
+
+		toneChanges := make(chan string, 3)
+		s.OnToneChange(func(tone string) {
+			toneChanges <- tone
+		})
+		assert.NoError(t, s.InsertDTMF("a", 0, 0))
+		assert.Equal(t, "A", <-toneChanges)
+
+		var events []rtp.Header
+		for endPackets := 0; endPackets < dtmfEndRetransmissions; {
+			p := <-written
+			assert.Equal(t, uint8(126), p.header.PayloadType)
+			assert.Equal(t, uint32(5000), p.header.SSRC)
+			assert.Equal(t, audio.SequenceNumber+uint16(len(events))+1, p.header.SequenceNumber)
+			if len(events) == 0 {
+				assert.True(t, p.header.Marker)
+				assert.GreaterOrEqual(t, p.header.Timestamp, audio.Timestamp)
+			} else {
+				assert.False(t, p.header.Marker)
+				assert.Equal(t, events[0].Timestamp, p.header.Timestamp)
+			}
+			events = append(events, p.header)
+
+			event := telephoneEvent{}
+			assert.NoError(t, event.unmarshal(p.payload))
+			assert.Equal(t, uint8(12), event.event)
+			if event.end {
+				assert.Equal(t, uint16(320), event.duration)
+				endPackets++
+			}
+		}
+		assert.Equal(t, "", <-toneChanges)
+
+		// Audio continues after the telephone-events
+		_, err = s.writeRTP(&rtp.Header{PayloadType: 0, SequenceNumber: 101, Timestamp: 8160, SSRC: 5000}, []byte{0x00}, nil)
+		assert.NoError(t, err)
+		audio = (<-written).header
+		assert.Equal(t, events[len(events)-1].SequenceNumber+1, audio.SequenceNumber)
+		s.close()
+	})
+}
+
+func TestDTMFSender_PeerConnection(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypePCMU}, "audio", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+	assert.NotNil(t, sender.DTMF())
+
+	var tonesMu sync.Mutex
+	tones := ""
+	tonesReceived, tonesReceivedCancel := context.WithCancel(context.Background())
+	trackReceived, trackReceivedCancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		trackRemote.OnDTMF(func(e DTMFEvent) {
+			tonesMu.Lock()
+			defer tonesMu.Unlock()
+
+			tones += e.Tone
+			if tones == "1#" {
+				tonesReceivedCancel()
+			}
+		})
+		trackReceivedCancel()
+
+		for {
+			pkt, _, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			assert.NotEqual(t, uint8(126), pkt.PayloadType, "telephone-events must not be returned by ReadRTP")
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	go func() {
+		for {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				if writeErr := track.WriteSample(media.Sample{Data: make([]byte, 160), Duration: 20 * time.Millisecond}); writeErr != nil {
+					return
+				}
+			case <-tonesReceived.Done():
+				return
+			}
+		}
+	}()
+
+	// Tones are only delivered once the OnDTMF handler is set
+	<-trackReceived.Done()
+	assert.True(t, sender.DTMF().CanInsertDTMF())
+	assert.NoError(t, sender.DTMF().InsertDTMF("1#", 40*time.Millisecond, 30*time.Millisecond))
+
+	<-tonesReceived.Done()
+	closePairNow(t, pcOffer, pcAnswer)
+}
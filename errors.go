@@ -58,6 +58,15 @@ var (
 	// exceeded. The limit is hardcoded to 65535 according to specifications.
 	ErrStringSizeLimit = errors.New("data channel label exceeds size limit")
 
+	// ErrDTMFInvalidTone indicates that DTMFSender.InsertDTMF was called with a
+	// character that isn't a DTMF tone.
+	ErrDTMFInvalidTone = errors.New("invalid DTMF tone")
+
+	// ErrDTMFNotNegotiated indicates that DTMF tones can't be sent, because no
+	// telephone-event with the clock rate of the audio codec has been negotiated
+	// or the RTPSender has been stopped.
+	ErrDTMFNotNegotiated = errors.New("telephone-event has not been negotiated")
+
 	// ErrMaxDataChannelID indicates that the maximum number ID that could be
 	// specified for a data channel has been exceeded.
 	ErrMaxDataChannelID = errors.New("maximum number ID for datachannel specified")
@@ -73,6 +82,14 @@ var (
 	// and is mutually exclusive.
 	ErrRetransmitsOrPacketLifeTime = errors.New("both MaxPacketLifeTime and MaxRetransmits was set")
 
+	// ErrMediaDisabled indicates that a track or a transceiver was added to a
+	// PeerConnection whose media was disabled with SettingEngine.DisableMedia.
+	ErrMediaDisabled = errors.New("media is disabled by the SettingEngine")
+
+	// ErrSCTPRestartDisabled indicates that SCTPTransport.Restart was called while
+	// restarts weren't enabled with SettingEngine.EnableSCTPRestart.
+	ErrSCTPRestartDisabled = errors.New("SCTP restart is disabled by the SettingEngine")
+
 	// ErrCodecNotFound is returned when a codec search to the Media Engine fails
 	ErrCodecNotFound = errors.New("codec not found")
 
@@ -91,6 +108,20 @@ var (
 	// longer then 65535 bytes
 	ErrProtocolTooLarge = errors.New("protocol is larger then 65535 bytes")
 
+	// ErrFragmentedUnreliable indicates that an attempt to create a fragmented data
+	// channel was made while the channel is unordered or partially reliable, which
+	// would lose or reorder fragments.
+	ErrFragmentedUnreliable = errors.New("fragmented data channels must be reliable and ordered")
+
+	// ErrSendReliabilityNotImplemented indicates that SendWithOptions was called with
+	// MaxRetransmits or MaxPacketLifeTime, as the partial reliability of a single
+	// message can't be overridden yet.
+	ErrSendReliabilityNotImplemented = errors.New("per-message MaxRetransmits and MaxPacketLifeTime are not implemented")
+
+	// ErrDataChannelMessageTooLarge indicates that a message received on a fragmented
+	// data channel was dropped, because it is larger than the max fragmented message size
+	ErrDataChannelMessageTooLarge = errors.New("fragmented message exceeds the max message size")
+
 	// ErrSenderNotCreatedByConnection indicates RemoveTrack was called with a RtpSender not created
 	// by this PeerConnection
 	ErrSenderNotCreatedByConnection = errors.New("RtpSender not created by this PeerConnection")
@@ -149,6 +180,12 @@ var (
 
 	errDetachNotEnabled                 = errors.New("enable detaching by calling webrtc.DetachDataChannels()")
 	errDetachBeforeOpened               = errors.New("datachannel not opened yet, try calling Detach from OnOpen")
+	errReadMessageDetached              = errors.New("ReadMessage can't be used with detached data channels")
+	errDetachFragmented                 = errors.New("fragmented data channels can't be detached")
+	errFragmentedNotSupported           = errors.New("fragmented data channels are not supported by the Wasm bindings")
+	errFragmentEmpty                    = errors.New("received an empty fragment")
+	errSendWithOptionsDetached          = errors.New("send options can't be used with detached data channels")
+	errSendOptionsNotSupported          = errors.New("send options are not supported by the Wasm bindings")
 	errDtlsTransportNotStarted          = errors.New("the DTLS transport has not started yet")
 	errDtlsKeyExtractionFailed          = errors.New("failed extracting keys from DTLS for SRTP")
 	errFailedToStartSRTP                = errors.New("failed to start SRTP")
@@ -205,6 +242,16 @@ var (
 
 	errSCTPTransportDTLS = errors.New("DTLS not established")
 
+	errTrackLocalForwarderSourceNil      = errors.New("TrackRemote and RTPReceiver must not be nil")
+	errTrackLocalForwarderSourceExists   = errors.New("TrackRemote has already been added as a source")
+	errTrackLocalForwarderSourceNotFound = errors.New("TrackRemote has not been added as a source")
+
+	errH264ProfileLevelIDInvalid = errors.New("invalid H264 profile-level-id")
+
+	errTelephoneEventTooShort = errors.New("telephone-event payload is too short")
+
+	errREDPayloadTooShort = errors.New("RED payload is too short")
+
 	errSDPZeroTransceivers                 = errors.New("addTransceiverSDP() called with 0 transceivers")
 	errSDPMediaSectionMediaDataChanInvalid = errors.New("invalid Media Section. Media + DataChannel both enabled")
 	errSDPMediaSectionMultipleTrackInvalid = errors.New("invalid Media Section. Can not have multiple tracks in one MediaSection in UnifiedPlan")
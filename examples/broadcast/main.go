@@ -14,7 +14,13 @@ import (
 )
 
 const (
-	rtcpPLIInterval = time.Second * 3
+	// gopCacheSize is the amount of packets since the last keyframe that are
+	// sent to new viewers, so they don't have to wait for the next keyframe
+	gopCacheSize = 512
+
+	// keyframeRequestInterval is the minimum time between two PLIs sent to
+	// the broadcaster, no matter how many viewers request a keyframe
+	keyframeRequestInterval = time.Second
 )
 
 func main() { // nolint:gocognit
@@ -48,22 +54,23 @@ func main() { // nolint:gocognit
 	// Set a handler for when a new remote track starts, this just distributes all our packets
 	// to connected peers
 	peerConnection.OnTrack(func(remoteTrack *webrtc.TrackRemote, receiver *webrtc.RTPReceiver) {
-		// Send a PLI on an interval so that the publisher is pushing a keyframe every rtcpPLIInterval
-		// This can be less wasteful by processing incoming RTCP events, then we would emit a NACK/PLI when a viewer requests it
-		go func() {
-			ticker := time.NewTicker(rtcpPLIInterval)
-			for range ticker.C {
-				if rtcpSendErr := peerConnection.WriteRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: uint32(remoteTrack.SSRC())}}); rtcpSendErr != nil {
-					fmt.Println(rtcpSendErr)
-				}
-			}
-		}()
-
 		// Create a local track, all our SFU clients will be fed via this track
-		localTrack, newTrackErr := webrtc.NewTrackLocalStaticRTP(remoteTrack.Codec().RTPCodecCapability, "video", "pion")
+		// New viewers are sent the packets since the last keyframe when they join
+		localTrack, newTrackErr := webrtc.NewTrackLocalStaticRTP(remoteTrack.Codec().RTPCodecCapability, "video", "pion",
+			webrtc.WithGOPCache(gopCacheSize),
+			webrtc.WithKeyframeRequestInterval(keyframeRequestInterval),
+		)
 		if newTrackErr != nil {
 			panic(newTrackErr)
 		}
+
+		// Send a PLI to the broadcaster when viewers need a keyframe. Requests of all
+		// viewers are coalesced, so the broadcaster isn't flooded with PLIs
+		localTrack.OnKeyframeRequest(func() {
+			if rtcpSendErr := peerConnection.WriteRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: uint32(remoteTrack.SSRC())}}); rtcpSendErr != nil {
+				fmt.Println(rtcpSendErr)
+			}
+		})
 		localTrackChan <- localTrack
 
 		rtpBuf := make([]byte, 1400)
@@ -130,13 +137,14 @@ func main() { // nolint:gocognit
 
 		// Read incoming RTCP packets
 		// Before these packets are returned they are processed by interceptors. For things
-		// like NACK this needs to be called.
+		// like NACK this needs to be called. Keyframe requests are passed to the local track.
 		go func() {
-			rtcpBuf := make([]byte, 1500)
 			for {
-				if _, _, rtcpErr := rtpSender.Read(rtcpBuf); rtcpErr != nil {
+				pkts, _, rtcpErr := rtpSender.ReadRTCP()
+				if rtcpErr != nil {
 					return
 				}
+				localTrack.HandleRTCP(pkts)
 			}
 		}()
 
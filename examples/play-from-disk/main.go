@@ -47,8 +47,30 @@ func main() {
 	iceConnectedCtx, iceConnectedCtxCancel := context.WithCancel(context.Background())
 
 	if haveVideoFile {
+		// Open a IVF file and start reading using our IVFReader
+		file, ivfErr := os.Open(videoFileName)
+		if ivfErr != nil {
+			panic(ivfErr)
+		}
+
+		ivf, header, ivfErr := ivfreader.NewWith(file)
+		if ivfErr != nil {
+			panic(ivfErr)
+		}
+
+		// Determine the codec of the video track from the FourCC of the IVF file
+		var videoCodec webrtc.RTPCodecCapability
+		switch header.FourCC {
+		case "VP80":
+			videoCodec = webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}
+		case "AV01":
+			videoCodec = webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeAV1}
+		default:
+			panic("Unsupported FourCC `" + header.FourCC + "`")
+		}
+
 		// Create a video track
-		videoTrack, videoTrackErr := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: "video/vp8"}, "video", "pion")
+		videoTrack, videoTrackErr := webrtc.NewTrackLocalStaticSample(videoCodec, "video", "pion")
 		if videoTrackErr != nil {
 			panic(videoTrackErr)
 		}
@@ -71,17 +93,6 @@ func main() {
 		}()
 
 		go func() {
-			// Open a IVF file and start reading using our IVFReader
-			file, ivfErr := os.Open(videoFileName)
-			if ivfErr != nil {
-				panic(ivfErr)
-			}
-
-			ivf, header, ivfErr := ivfreader.NewWith(file)
-			if ivfErr != nil {
-				panic(ivfErr)
-			}
-
 			// Wait for connection established
 			<-iceConnectedCtx.Done()
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pion/interceptor"
@@ -42,13 +43,19 @@ func main() {
 	m := &webrtc.MediaEngine{}
 
 	// Setup the codecs you want to use.
-	// We'll use a VP8 and Opus but you can also define your own
+	// We'll use a VP8, AV1 and Opus but you can also define your own
 	if err := m.RegisterCodec(webrtc.RTPCodecParameters{
 		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000, Channels: 0, SDPFmtpLine: "", RTCPFeedback: nil},
 		PayloadType:        96,
 	}, webrtc.RTPCodecTypeVideo); err != nil {
 		panic(err)
 	}
+	if err := m.RegisterCodec(webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeAV1, ClockRate: 90000, Channels: 0, SDPFmtpLine: "", RTCPFeedback: nil},
+		PayloadType:        45,
+	}, webrtc.RTPCodecTypeVideo); err != nil {
+		panic(err)
+	}
 	if err := m.RegisterCodec(webrtc.RTPCodecParameters{
 		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 0, SDPFmtpLine: "", RTCPFeedback: nil},
 		PayloadType:        111,
@@ -96,10 +103,9 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	ivfFile, err := ivfwriter.New("output.ivf")
-	if err != nil {
-		panic(err)
-	}
+	// The IVF file is created once we know if the video track is VP8 or AV1
+	var ivfMu sync.Mutex
+	var ivfFile *ivfwriter.IVFWriter
 
 	// Set a handler for when a new remote track starts, this handler saves buffers to disk as
 	// an ivf file, since we could have multiple video tracks we provide a counter.
@@ -120,9 +126,18 @@ func main() {
 		if strings.EqualFold(codec.MimeType, webrtc.MimeTypeOpus) {
 			fmt.Println("Got Opus track, saving to disk as output.opus (48 kHz, 2 channels)")
 			saveToDisk(oggFile, track)
-		} else if strings.EqualFold(codec.MimeType, webrtc.MimeTypeVP8) {
-			fmt.Println("Got VP8 track, saving to disk as output.ivf")
-			saveToDisk(ivfFile, track)
+		} else if strings.EqualFold(codec.MimeType, webrtc.MimeTypeVP8) || strings.EqualFold(codec.MimeType, webrtc.MimeTypeAV1) {
+			fmt.Printf("Got %s track, saving to disk as output.ivf\n", codec.MimeType)
+
+			writer, ivfErr := ivfwriter.New("output.ivf", ivfwriter.WithCodec(codec.MimeType))
+			if ivfErr != nil {
+				panic(ivfErr)
+			}
+
+			ivfMu.Lock()
+			ivfFile = writer
+			ivfMu.Unlock()
+			saveToDisk(writer, track)
 		}
 	})
 
@@ -140,7 +155,11 @@ func main() {
 				panic(closeErr)
 			}
 
-			closeErr = ivfFile.Close()
+			ivfMu.Lock()
+			if ivfFile != nil {
+				closeErr = ivfFile.Close()
+			}
+			ivfMu.Unlock()
 			if closeErr != nil {
 				panic(closeErr)
 			}
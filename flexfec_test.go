@@ -0,0 +1,121 @@
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	mock_interceptor "github.com/pion/interceptor/pkg/mock"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3/pkg/flexfec"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlexFECPeerConnection(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const fecPayloadType = 49
+
+	// Every fifth video packet the offerer sends is lost, FEC packets always arrive.
+	// The interceptor is added first, so it sees the FEC packets written by the FlexFEC interceptor.
+	var sentMu sync.Mutex
+	sent := 0
+	ir := &interceptor.Registry{}
+	ir.Add(&mock_interceptor.Interceptor{
+		BindLocalStreamFn: func(_ *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+			return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+				if header.PayloadType == fecPayloadType {
+					return writer.Write(header, payload, attributes)
+				}
+
+				sentMu.Lock()
+				sent++
+				lost := sent%5 == 0
+				sentMu.Unlock()
+				if lost {
+					return len(payload), nil
+				}
+				return writer.Write(header, payload, attributes)
+			})
+		},
+	})
+
+	mOffer := &MediaEngine{}
+	assert.NoError(t, mOffer.RegisterDefaultCodecs())
+	assert.NoError(t, ConfigureFlexFEC03(fecPayloadType, mOffer, ir, flexfec.NumMediaPackets(4), flexfec.NumFECPackets(1)))
+	pcOffer, err := NewAPI(WithMediaEngine(mOffer), WithInterceptorRegistry(ir)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	mAnswer := &MediaEngine{}
+	assert.NoError(t, mAnswer.RegisterDefaultCodecs())
+	assert.NoError(t, ConfigureFlexFEC03(fecPayloadType, mAnswer, &interceptor.Registry{}))
+	pcAnswer, err := NewAPI(WithMediaEngine(mAnswer)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+	assert.NotZero(t, sender.GetParameters().Encodings[0].FEC.SSRC)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, offer.SDP, "a=ssrc-group:FEC-FR")
+
+	received, receivedCancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		sequenceNumbers := map[uint16]bool{}
+		var first uint16
+		var offset byte
+		for len(sequenceNumbers) < 40 {
+			p, _, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			assert.Equal(t, uint8(96), p.PayloadType)
+			assert.False(t, sequenceNumbers[p.SequenceNumber], "duplicate packet")
+			if len(sequenceNumbers) == 0 {
+				first = p.SequenceNumber
+				offset = byte(p.SequenceNumber) - p.Payload[len(p.Payload)-1]
+			}
+			assert.Equal(t, byte(p.SequenceNumber)-offset, p.Payload[len(p.Payload)-1], "wrong payload")
+			sequenceNumbers[p.SequenceNumber] = true
+		}
+
+		// Only the packets of groups that weren't complete yet may be missing
+		for i := uint16(0); i < 20; i++ {
+			assert.True(t, sequenceNumbers[first+i], "packet %d wasn't recovered", first+i)
+		}
+		receivedCancel()
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			select {
+			case <-received.Done():
+				return
+			case <-ticker.C:
+				// Every packet carries a counter, so the payloads of recovered packets can be checked
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{byte(i)}, Duration: 10 * time.Millisecond}))
+			}
+		}
+	}()
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -6,6 +6,14 @@ import (
 
 type fmtp map[string]string
 
+// Parameters of H265 and VP9 fmtp lines, https://tools.ietf.org/html/rfc7798#section-7.1
+// and https://tools.ietf.org/html/draft-ietf-payload-vp9-16#section-6
+const (
+	fmtpProfileID    = "profile-id"
+	fmtpH265TierFlag = "tier-flag"
+	fmtpH265TxMode   = "tx-mode"
+)
+
 // parseFmtp parses fmtp string.
 func parseFmtp(line string) fmtp {
 	f := fmtp{}
@@ -35,3 +43,48 @@ func fmtpConsist(a, b fmtp) bool {
 	}
 	return true
 }
+
+// codecFmtpConsist checks that two FMTP parameters of the given codec are not
+// inconsistent, taking the semantics of the codec parameters into account.
+func codecFmtpConsist(mimeType string, a, b fmtp) bool {
+	switch {
+	case strings.EqualFold(mimeType, MimeTypeH264):
+		return h264FmtpConsist(a, b)
+	case strings.EqualFold(mimeType, MimeTypeH265):
+		return h265FmtpConsist(a, b)
+	case strings.EqualFold(mimeType, MimeTypeVP9):
+		return vp9FmtpConsist(a, b)
+	default:
+		return fmtpConsist(a, b)
+	}
+}
+
+// vp9FmtpConsist checks that two VP9 FMTP parameters describe the same profile,
+// https://tools.ietf.org/html/draft-ietf-payload-vp9-16#section-6
+func vp9FmtpConsist(a, b fmtp) bool {
+	return fmtpParameterEqual(a, b, fmtpProfileID, "0")
+}
+
+// h265FmtpConsist checks that two H265 FMTP parameters describe the same profile,
+// tier and transmission mode. level-id is the highest level the sender of the fmtp
+// supports, so different levels are compatible. The other parameters describe the
+// stream and don't affect compatibility.
+func h265FmtpConsist(a, b fmtp) bool {
+	return fmtpParameterEqual(a, b, fmtpProfileID, "1") &&
+		fmtpParameterEqual(a, b, fmtpH265TierFlag, "0") &&
+		fmtpParameterEqual(a, b, fmtpH265TxMode, "SRST")
+}
+
+// fmtpParameterEqual checks that a parameter has the same value in both FMTP
+// parameters, using defaultValue if it is not set
+func fmtpParameterEqual(a, b fmtp, key, defaultValue string) bool {
+	va, ok := a[key]
+	if !ok {
+		va = defaultValue
+	}
+	vb, ok := b[key]
+	if !ok {
+		vb = defaultValue
+	}
+	return strings.EqualFold(va, vb)
+}
@@ -0,0 +1,216 @@
+package webrtc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Parameters of H264 fmtp lines, https://tools.ietf.org/html/rfc6184#section-8.1
+const (
+	fmtpH264ProfileLevelID           = "profile-level-id"
+	fmtpH264PacketizationMode        = "packetization-mode"
+	fmtpH264LevelAsymmetryAllowed    = "level-asymmetry-allowed"
+	fmtpH264DefaultProfileLevelID    = "42000a"
+	fmtpH264DefaultPacketizationMode = "0"
+)
+
+type h264Profile int
+
+const (
+	h264ProfileConstrainedBaseline h264Profile = iota + 1
+	h264ProfileBaseline
+	h264ProfileMain
+	h264ProfileConstrainedHigh
+	h264ProfileHigh
+	h264ProfilePredictiveHigh444
+)
+
+// h264Level1b is the level_idc used for level 1b. It is signaled as level_idc 11
+// with constraint_set3 in the Baseline and Main profiles, and as 9 otherwise.
+const h264Level1b = 9
+
+const h264ConstraintSet3Flag = 0x10
+
+// h264ProfilePattern matches a profile_idc and the profile-iop byte, where
+// the bits set in mask have to be equal to value
+type h264ProfilePattern struct {
+	profileIdc  byte
+	mask, value byte
+	profile     h264Profile
+}
+
+// The profile-iop patterns of the profiles used in WebRTC. Bits that are not in
+// the mask, like constraint_set3, are ignored. https://tools.ietf.org/html/rfc6184#section-8.1
+var h264ProfilePatterns = []h264ProfilePattern{ //nolint:gochecknoglobals
+	{0x42, 0x40, 0x40, h264ProfileConstrainedBaseline},
+	{0x4D, 0x80, 0x80, h264ProfileConstrainedBaseline},
+	{0x58, 0xC0, 0xC0, h264ProfileConstrainedBaseline},
+	{0x42, 0x40, 0x00, h264ProfileBaseline},
+	{0x58, 0xC0, 0x80, h264ProfileBaseline},
+	{0x4D, 0xAF, 0x00, h264ProfileMain},
+	{0x64, 0xFF, 0x00, h264ProfileHigh},
+	{0x64, 0xFF, 0x0C, h264ProfileConstrainedHigh},
+	{0xF4, 0xFF, 0x00, h264ProfilePredictiveHigh444},
+}
+
+// h264ProfileLevelID is a parsed profile-level-id
+type h264ProfileLevelID struct {
+	profile    h264Profile
+	profileIdc byte
+	profileIop byte
+	level      byte
+}
+
+// parseH264ProfileLevelID parses a profile-level-id, which is the hex encoded
+// profile_idc, profile-iop and level_idc
+func parseH264ProfileLevelID(value string) (h264ProfileLevelID, error) {
+	raw, err := hex.DecodeString(value)
+	if err != nil || len(raw) != 3 {
+		return h264ProfileLevelID{}, fmt.Errorf("%w: %s", errH264ProfileLevelIDInvalid, value)
+	}
+	profileIdc, profileIop, levelIdc := raw[0], raw[1], raw[2]
+
+	for _, pattern := range h264ProfilePatterns {
+		if pattern.profileIdc != profileIdc || profileIop&pattern.mask != pattern.value {
+			continue
+		}
+
+		level := levelIdc
+		switch pattern.profile {
+		case h264ProfileConstrainedBaseline, h264ProfileBaseline, h264ProfileMain:
+			if levelIdc == 11 && profileIop&h264ConstraintSet3Flag != 0 {
+				level = h264Level1b
+			}
+		default:
+		}
+		return h264ProfileLevelID{profile: pattern.profile, profileIdc: profileIdc, profileIop: profileIop, level: level}, nil
+	}
+
+	return h264ProfileLevelID{}, fmt.Errorf("%w: %s", errH264ProfileLevelIDInvalid, value)
+}
+
+// withLevel returns the profile-level-id with the level replaced
+func (p h264ProfileLevelID) withLevel(level byte) string {
+	profileIop, levelIdc := p.profileIop, level
+
+	switch p.profile {
+	case h264ProfileConstrainedBaseline, h264ProfileBaseline, h264ProfileMain:
+		profileIop &^= h264ConstraintSet3Flag
+		if level == h264Level1b {
+			profileIop |= h264ConstraintSet3Flag
+			levelIdc = 11
+		}
+	default:
+	}
+
+	return hex.EncodeToString([]byte{p.profileIdc, profileIop, levelIdc})
+}
+
+// compatible reports if two profiles can be negotiated with each other.
+// Constrained Baseline is a subset of Baseline, so they are treated as equal.
+func (p h264ProfileLevelID) compatible(o h264ProfileLevelID) bool {
+	baseline := func(profile h264Profile) bool {
+		return profile == h264ProfileConstrainedBaseline || profile == h264ProfileBaseline
+	}
+	return p.profile == o.profile || (baseline(p.profile) && baseline(o.profile))
+}
+
+// h264LevelLess reports if level a is lower than level b, taking level 1b into account
+func h264LevelLess(a, b byte) bool {
+	// level 1b is between level 1 (10) and 1.1 (11)
+	value := func(level byte) int {
+		if level == h264Level1b {
+			return 2*10 + 1
+		}
+		return 2 * int(level)
+	}
+	return value(a) < value(b)
+}
+
+// h264Fmtp returns the profile-level-id and packetization-mode of a H264 fmtp,
+// with the defaults of RFC 6184 if they are not set
+func h264Fmtp(f fmtp) (h264ProfileLevelID, string, error) {
+	value, ok := f[fmtpH264ProfileLevelID]
+	if !ok {
+		value = fmtpH264DefaultProfileLevelID
+	}
+	profileLevelID, err := parseH264ProfileLevelID(value)
+	if err != nil {
+		return h264ProfileLevelID{}, "", err
+	}
+
+	packetizationMode, ok := f[fmtpH264PacketizationMode]
+	if !ok {
+		packetizationMode = fmtpH264DefaultPacketizationMode
+	}
+	return profileLevelID, packetizationMode, nil
+}
+
+// h264FmtpConsist checks that two H264 FMTP parameters use the same packetization
+// mode and compatible profiles. The level doesn't affect compatibility, it is
+// negotiated by h264NegotiatedFmtpLine.
+func h264FmtpConsist(a, b fmtp) bool {
+	profileA, modeA, err := h264Fmtp(a)
+	if err != nil {
+		return false
+	}
+	profileB, modeB, err := h264Fmtp(b)
+	if err != nil {
+		return false
+	}
+
+	return modeA == modeB && profileA.compatible(profileB)
+}
+
+// h264NegotiatedFmtpLine returns the fmtp line of a remote H264 codec with the
+// level of the profile-level-id rewritten to the negotiated one, as described in
+// https://tools.ietf.org/html/rfc6184#section-8.2.2. If both sides allow level
+// asymmetry the local level is used, otherwise the lower of both levels.
+func h264NegotiatedFmtpLine(local, remote string) string {
+	localFmtp, remoteFmtp := parseFmtp(local), parseFmtp(remote)
+
+	localProfile, _, err := h264Fmtp(localFmtp)
+	if err != nil {
+		return remote
+	}
+	remoteProfile, _, err := h264Fmtp(remoteFmtp)
+	if err != nil {
+		return remote
+	}
+
+	level := remoteProfile.level
+	levelAsymmetryAllowed := localFmtp[fmtpH264LevelAsymmetryAllowed] == "1" && remoteFmtp[fmtpH264LevelAsymmetryAllowed] == "1"
+	if levelAsymmetryAllowed || h264LevelLess(localProfile.level, remoteProfile.level) {
+		level = localProfile.level
+	}
+	if level == remoteProfile.level {
+		return remote
+	}
+
+	return fmtpLineWithParameter(remote, fmtpH264ProfileLevelID, remoteProfile.withLevel(level))
+}
+
+// fmtpLineWithParameter returns line with the value of key replaced, or added
+// if it doesn't exist. The order of the other parameters is preserved.
+func fmtpLineWithParameter(line, key, value string) string {
+	parameters := []string{}
+	found := false
+	for _, p := range strings.Split(line, ";") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		if strings.EqualFold(strings.SplitN(p, "=", 2)[0], key) {
+			p = key + "=" + value
+			found = true
+		}
+		parameters = append(parameters, p)
+	}
+	if !found {
+		parameters = append(parameters, key+"="+value)
+	}
+
+	return strings.Join(parameters, ";")
+}
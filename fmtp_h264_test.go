@@ -0,0 +1,142 @@
+package webrtc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseH264ProfileLevelID(t *testing.T) {
+	testCases := map[string]struct {
+		input   string
+		profile h264Profile
+		level   byte
+	}{
+		"ConstrainedBaseline":        {"42e01f", h264ProfileConstrainedBaseline, 31},
+		"ConstrainedBaselineMain":    {"4d801f", h264ProfileConstrainedBaseline, 31},
+		"Baseline":                   {"42001f", h264ProfileBaseline, 31},
+		"Main":                       {"4d0032", h264ProfileMain, 50},
+		"High":                       {"640032", h264ProfileHigh, 50},
+		"ConstrainedHigh":            {"640c1f", h264ProfileConstrainedHigh, 31},
+		"PredictiveHigh444":          {"f4001f", h264ProfilePredictiveHigh444, 31},
+		"Level1bConstrainedBaseline": {"42f00b", h264ProfileConstrainedBaseline, h264Level1b},
+		"Level1bHigh":                {"640009", h264ProfileHigh, h264Level1b},
+		"Level1_1":                   {"42e00b", h264ProfileConstrainedBaseline, 11},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			p, err := parseH264ProfileLevelID(testCase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.profile != testCase.profile || p.level != testCase.level {
+				t.Errorf("Expected profile %d level %d, got profile %d level %d", testCase.profile, testCase.level, p.profile, p.level)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "42e0", "42e01f00", "zzzzzz", "6401ff"} {
+		if _, err := parseH264ProfileLevelID(invalid); !errors.Is(err, errH264ProfileLevelIDInvalid) {
+			t.Errorf("Expected '%s' to be invalid", invalid)
+		}
+	}
+}
+
+func TestH264FmtpConsist(t *testing.T) {
+	testCases := map[string]struct {
+		a, b    string
+		consist bool
+	}{
+		"DifferentLevel": {
+			a:       "packetization-mode=1;profile-level-id=42e01f",
+			b:       "packetization-mode=1;profile-level-id=42e034",
+			consist: true,
+		},
+		"BaselineAndConstrainedBaseline": {
+			a:       "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+			b:       "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f",
+			consist: true,
+		},
+		"DifferentProfile": {
+			a:       "packetization-mode=1;profile-level-id=42e01f",
+			b:       "packetization-mode=1;profile-level-id=640c1f",
+			consist: false,
+		},
+		"DifferentPacketizationMode": {
+			a:       "packetization-mode=1;profile-level-id=42e01f",
+			b:       "packetization-mode=0;profile-level-id=42e01f",
+			consist: false,
+		},
+		"DefaultPacketizationMode": {
+			a:       "profile-level-id=42e01f",
+			b:       "packetization-mode=0;profile-level-id=42e01f",
+			consist: true,
+		},
+		"DefaultProfile": {
+			a:       "",
+			b:       "profile-level-id=42001f",
+			consist: true,
+		},
+		"InvalidProfile": {
+			a:       "profile-level-id=xyz",
+			b:       "profile-level-id=xyz",
+			consist: false,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			if c := h264FmtpConsist(parseFmtp(testCase.a), parseFmtp(testCase.b)); c != testCase.consist {
+				t.Errorf("'%s' and '%s' are expected to be consistent=%v", testCase.a, testCase.b, testCase.consist)
+			}
+			if c := h264FmtpConsist(parseFmtp(testCase.b), parseFmtp(testCase.a)); c != testCase.consist {
+				t.Errorf("'%s' and '%s' are expected to be consistent=%v", testCase.b, testCase.a, testCase.consist)
+			}
+		})
+	}
+}
+
+func TestH264NegotiatedFmtpLine(t *testing.T) {
+	testCases := map[string]struct {
+		local, remote, expected string
+	}{
+		"LevelAsymmetryUsesLocalLevel": {
+			local:    "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+			remote:   "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e034",
+			expected: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+		},
+		"NoLevelAsymmetryUsesLowerLevel": {
+			local:    "packetization-mode=1;profile-level-id=42e034",
+			remote:   "packetization-mode=1;profile-level-id=42e01f",
+			expected: "packetization-mode=1;profile-level-id=42e01f",
+		},
+		"NoLevelAsymmetryLocalLower": {
+			local:    "packetization-mode=1;profile-level-id=42e015",
+			remote:   "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+			expected: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e015",
+		},
+		"RemoteProfileIsKept": {
+			local:    "packetization-mode=1;profile-level-id=42e015",
+			remote:   "packetization-mode=1;profile-level-id=42001f",
+			expected: "packetization-mode=1;profile-level-id=420015",
+		},
+		"Level1b": {
+			local:    "profile-level-id=42f00b",
+			remote:   "profile-level-id=42e01f",
+			expected: "profile-level-id=42f00b",
+		},
+		"DefaultProfileIsAdded": {
+			local:    "level-asymmetry-allowed=1;profile-level-id=42e01f",
+			remote:   "level-asymmetry-allowed=1",
+			expected: "level-asymmetry-allowed=1;profile-level-id=42001f",
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			if negotiated := h264NegotiatedFmtpLine(testCase.local, testCase.remote); negotiated != testCase.expected {
+				t.Errorf("Expected '%s', got '%s'", testCase.expected, negotiated)
+			}
+		})
+	}
+}
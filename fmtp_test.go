@@ -105,3 +105,65 @@ func TestFmtpConsist(t *testing.T) {
 		})
 	}
 }
+
+func TestCodecFmtpConsist(t *testing.T) {
+	testCases := map[string]struct {
+		mimeType string
+		a, b     string
+		consist  bool
+	}{
+		"H265DifferentLevel": {
+			mimeType: "video/H265",
+			a:        "profile-id=1;tier-flag=0;level-id=93",
+			b:        "profile-id=1;tier-flag=0;level-id=120",
+			consist:  true,
+		},
+		"H265DefaultProfile": {
+			mimeType: "video/h265",
+			a:        "level-id=93",
+			b:        "profile-id=1",
+			consist:  true,
+		},
+		"H265DifferentProfile": {
+			mimeType: "video/H265",
+			a:        "profile-id=1",
+			b:        "profile-id=2",
+			consist:  false,
+		},
+		"H265DefaultProfileMismatch": {
+			mimeType: "video/H265",
+			a:        "",
+			b:        "profile-id=2",
+			consist:  false,
+		},
+		"H265DifferentTier": {
+			mimeType: "video/H265",
+			a:        "profile-id=1;tier-flag=1",
+			b:        "profile-id=1",
+			consist:  false,
+		},
+		"H265DifferentSprop": {
+			mimeType: "video/H265",
+			a:        "profile-id=1;sprop-vps=QAEMAf//",
+			b:        "profile-id=1;sprop-vps=QAEMAf//AWAAAAMA",
+			consist:  true,
+		},
+		"OtherCodec": {
+			mimeType: "video/VP9",
+			a:        "profile-id=0",
+			b:        "profile-id=1",
+			consist:  false,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			if c := codecFmtpConsist(testCase.mimeType, parseFmtp(testCase.a), parseFmtp(testCase.b)); c != testCase.consist {
+				t.Errorf("'%s' and '%s' are expected to be consistent=%v", testCase.a, testCase.b, testCase.consist)
+			}
+			if c := codecFmtpConsist(testCase.mimeType, parseFmtp(testCase.b), parseFmtp(testCase.a)); c != testCase.consist {
+				t.Errorf("'%s' and '%s' are expected to be consistent=%v", testCase.b, testCase.a, testCase.consist)
+			}
+		})
+	}
+}
@@ -13,11 +13,11 @@ require (
 	github.com/pion/randutil v0.1.0
 	github.com/pion/rtcp v1.2.6
 	github.com/pion/rtp v1.6.5
-	github.com/pion/sctp v1.7.12
+	github.com/pion/sctp v1.8.13
 	github.com/pion/sdp/v3 v3.0.4
 	github.com/pion/srtp/v2 v2.0.2
 	github.com/pion/transport v0.12.3
 	github.com/sclevine/agouti v3.0.0+incompatible
-	github.com/stretchr/testify v1.7.0
-	golang.org/x/net v0.0.0-20210420210106-798c2154c571
+	github.com/stretchr/testify v1.9.0
+	golang.org/x/net v0.14.0
 )
@@ -9,6 +9,8 @@ import (
 	"github.com/pion/interceptor/pkg/nack"
 	"github.com/pion/interceptor/pkg/report"
 	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3/internal/senderreport"
+	"github.com/pion/webrtc/v3/pkg/flexfec"
 )
 
 // RegisterDefaultInterceptors will register some useful interceptors.
@@ -26,14 +28,16 @@ func RegisterDefaultInterceptors(mediaEngine *MediaEngine, interceptorRegistry *
 	return nil
 }
 
-// ConfigureRTCPReports will setup everything necessary for generating Sender and Receiver Reports
+// ConfigureRTCPReports will setup everything necessary for generating Sender and Receiver Reports.
+// Sender Reports map RTP timestamps to the capture time of the media when it is known,
+// like for samples written to a TrackLocalStaticSample with a Timestamp.
 func ConfigureRTCPReports(interceptorRegistry *interceptor.Registry) error {
 	reciver, err := report.NewReceiverInterceptor()
 	if err != nil {
 		return err
 	}
 
-	sender, err := report.NewSenderInterceptor()
+	sender, err := senderreport.NewSenderInterceptor()
 	if err != nil {
 		return err
 	}
@@ -43,6 +47,26 @@ func ConfigureRTCPReports(interceptorRegistry *interceptor.Registry) error {
 	return nil
 }
 
+// ConfigureFlexFEC03 will setup everything necessary for sending FlexFEC-03 forward error correction
+// with video, registering the codec with the given payload type and the interceptor generating the
+// FEC packets. Received FEC packets are used to recover lost packets whenever the codec is negotiated.
+func ConfigureFlexFEC03(payloadType PayloadType, mediaEngine *MediaEngine, interceptorRegistry *interceptor.Registry, options ...flexfec.Option) error {
+	generator, err := flexfec.NewInterceptor(options...)
+	if err != nil {
+		return err
+	}
+
+	if err := mediaEngine.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeFlexFEC03, 90000, 0, "repair-window=10000000", nil},
+		PayloadType:        payloadType,
+	}, RTPCodecTypeVideo); err != nil {
+		return err
+	}
+
+	interceptorRegistry.Add(generator)
+	return nil
+}
+
 // ConfigureNack will setup everything necessary for handling generating/responding to nack messages.
 func ConfigureNack(mediaEngine *MediaEngine, interceptorRegistry *interceptor.Registry) error {
 	generator, err := nack.NewGeneratorInterceptor()
@@ -65,13 +89,38 @@ func ConfigureNack(mediaEngine *MediaEngine, interceptorRegistry *interceptor.Re
 type interceptorToTrackLocalWriter struct{ interceptor atomic.Value } // interceptor.RTPWriter }
 
 func (i *interceptorToTrackLocalWriter) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	return i.writeRTPWithAttributes(header, payload, nil)
+}
+
+// writeRTPWithAttributes is like WriteRTP, but passes attributes to the interceptors
+func (i *interceptorToTrackLocalWriter) writeRTPWithAttributes(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+	if attributes == nil {
+		attributes = interceptor.Attributes{}
+	}
+
 	if writer, ok := i.interceptor.Load().(interceptor.RTPWriter); ok && writer != nil {
-		return writer.Write(header, payload, interceptor.Attributes{})
+		return writer.Write(header, payload, attributes)
 	}
 
 	return 0, nil
 }
 
+// trackLocalAttributesWriter is implemented by the TrackLocalWriters of a PeerConnection,
+// which are able to pass interceptor.Attributes along with a packet
+type trackLocalAttributesWriter interface {
+	writeRTPWithAttributes(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error)
+}
+
+// writeRTPWithAttributes writes a packet to writeStream, passing attributes to the
+// interceptors if it supports them
+func writeRTPWithAttributes(writeStream TrackLocalWriter, header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+	if writer, ok := writeStream.(trackLocalAttributesWriter); ok && attributes != nil {
+		return writer.writeRTPWithAttributes(header, payload, attributes)
+	}
+
+	return writeStream.WriteRTP(header, payload)
+}
+
 func (i *interceptorToTrackLocalWriter) Write(b []byte) (int, error) {
 	packet := &rtp.Packet{}
 	if err := packet.Unmarshal(b); err != nil {
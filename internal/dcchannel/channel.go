@@ -0,0 +1,134 @@
+// Package dcchannel exchanges messages over a DataChannel, detached or not, with
+// flow control. It is shared by the packages built on data channels.
+package dcchannel
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	// maxBufferedAmount is the amount of data buffered by the data channel above
+	// which writes block, as in the data-channels-flow-control example
+	maxBufferedAmount = 1024 * 1024
+	// bufferedAmountLowThreshold is the amount of data buffered by the data channel
+	// below which writes resume
+	bufferedAmountLowThreshold = maxBufferedAmount / 2
+)
+
+// Channel reads and writes the messages of a DataChannel
+type Channel struct {
+	dc *webrtc.DataChannel
+	// rwc is set before opened is closed when the data channel is detached
+	rwc io.ReadWriteCloser
+
+	mu       sync.Mutex
+	detached bool
+	pending  *webrtc.DataChannelMessage
+
+	// messages are the messages read by a goroutine, handed over one at a time,
+	// and readDone is closed once it stops
+	messages chan []byte
+	readDone chan struct{}
+
+	bufferedLow chan struct{}
+	opened      chan struct{}
+	closed      chan struct{}
+	released    chan struct{}
+
+	openOnce, closeOnce, releaseOnce sync.Once
+}
+
+// New returns a Channel over dc. It takes over the OnOpen, OnClose and
+// OnBufferedAmountLow handlers of dc, and dc must not have an OnMessage handler,
+// as the messages are read with DataChannel.ReadMessage. The Channel should be
+// created before dc opens, for example in the OnDataChannel handler. Detached
+// data channels are detached once they are open.
+func New(dc *webrtc.DataChannel) *Channel {
+	c := &Channel{
+		dc:          dc,
+		messages:    make(chan []byte),
+		readDone:    make(chan struct{}),
+		bufferedLow: make(chan struct{}, 1),
+		opened:      make(chan struct{}),
+		closed:      make(chan struct{}),
+		released:    make(chan struct{}),
+	}
+
+	dc.SetBufferedAmountLowThreshold(bufferedAmountLowThreshold)
+	dc.OnBufferedAmountLow(func() {
+		select {
+		case c.bufferedLow <- struct{}{}:
+		default:
+		}
+	})
+	dc.OnClose(c.markClosed)
+	dc.OnOpen(func() {
+		c.handleOpen()
+		c.openOnce.Do(func() { close(c.opened) })
+	})
+	c.startReading()
+
+	return c
+}
+
+// DataChannel returns the data channel of the Channel
+func (c *Channel) DataChannel() *webrtc.DataChannel {
+	return c.dc
+}
+
+func (c *Channel) markClosed() {
+	c.closeOnce.Do(func() { close(c.closed) })
+}
+
+// Write sends a message once the data channel is open, and blocks while the data
+// channel buffers too much data. It returns io.ErrClosedPipe once the data channel
+// is closed, and ctx.Err() once ctx is done.
+func (c *Channel) Write(ctx context.Context, data []byte) error {
+	select {
+	case <-c.closed:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	select {
+	case <-c.opened:
+	case <-c.closed:
+		return io.ErrClosedPipe
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	for c.dc.BufferedAmount() > maxBufferedAmount {
+		select {
+		case <-c.bufferedLow:
+		case <-c.closed:
+			return io.ErrClosedPipe
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if c.rwc != nil {
+		_, err := c.rwc.Write(data)
+		return err
+	}
+	return c.dc.Send(data)
+}
+
+// Release stops waiting for the messages to be read, once they won't be. The
+// messages received afterwards may be dropped.
+func (c *Channel) Release() {
+	c.releaseOnce.Do(func() { close(c.released) })
+}
+
+// Close releases the Channel and closes the data channel. Blocked writes return
+// io.ErrClosedPipe.
+func (c *Channel) Close() error {
+	c.Release()
+	c.markClosed()
+	return c.dc.Close()
+}
@@ -0,0 +1,63 @@
+// +build !js
+
+package dcchannel
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/internal/dctest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannel(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	const maxMessageSize = 256 * 1024
+
+	s := webrtc.SettingEngine{}
+	s.SetSCTPMaxMessageSize(maxMessageSize)
+	dctest.Run(t, s, func(t *testing.T, api *webrtc.API) {
+		pcOffer, pcAnswer := dctest.NewPair(t, api)
+		defer dctest.ClosePair(t, pcOffer, pcAnswer)
+
+		accepted := make(chan *Channel, 1)
+		pcAnswer.OnDataChannel(func(dc *webrtc.DataChannel) {
+			accepted <- New(dc)
+		})
+
+		dc, err := pcOffer.CreateDataChannel("channel", nil)
+		assert.NoError(t, err)
+		c := New(dc)
+
+		dctest.Signal(t, pcOffer, pcAnswer)
+		remote := <-accepted
+
+		// Messages larger than 64 KiB are read, and messages are read in order
+		large := make([]byte, maxMessageSize)
+		large[maxMessageSize-1] = 1
+		for _, msg := range [][]byte{large, []byte("small"), {}} {
+			assert.NoError(t, c.Write(context.Background(), msg))
+		}
+		for _, msg := range [][]byte{large, []byte("small"), {}} {
+			received, readErr := remote.Read(context.Background())
+			assert.NoError(t, readErr)
+			assert.Equal(t, msg, received)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = remote.Read(ctx)
+		assert.Equal(t, context.Canceled, err)
+
+		assert.NoError(t, c.Close())
+		assert.Equal(t, io.ErrClosedPipe, c.Write(context.Background(), []byte("closed")))
+		_, err = remote.Read(context.Background())
+		assert.Equal(t, io.EOF, err)
+	})
+}
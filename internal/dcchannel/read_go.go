@@ -0,0 +1,97 @@
+// +build !js
+
+package dcchannel
+
+import (
+	"context"
+	"io"
+)
+
+// startReading calls ReadMessage right away to find out whether the data channel
+// is detached, as ReadMessage fails with detached data channels, which are read
+// once open instead.
+func (c *Channel) startReading() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	msg, err := c.dc.ReadMessage(ctx)
+	switch err {
+	case nil:
+		c.pending = &msg
+	case context.Canceled, io.EOF:
+	default:
+		c.detached = true
+	}
+}
+
+func (c *Channel) handleOpen() {
+	if !c.detached {
+		return
+	}
+
+	rwc, err := c.dc.Detach()
+	if err != nil {
+		close(c.readDone)
+		return
+	}
+	c.rwc = rwc
+	go c.readDetached(rwc)
+}
+
+// readDetached reads the messages of a detached data channel, into a buffer of the
+// max-message-size advertised to the remote peer, as shorter buffers fail to read
+// larger messages
+func (c *Channel) readDetached(rwc io.Reader) {
+	defer close(c.readDone)
+
+	buf := make([]byte, c.dc.Transport().GetCapabilities().MaxMessageSize)
+	for {
+		n, err := rwc.Read(buf)
+		if err != nil {
+			return
+		}
+
+		select {
+		case c.messages <- append([]byte{}, buf[:n]...):
+		case <-c.released:
+			return
+		}
+	}
+}
+
+// Read returns the next message received. The messages received before the data
+// channel closed are read before io.EOF is returned. It returns ctx.Err() once
+// ctx is done.
+func (c *Channel) Read(ctx context.Context) ([]byte, error) {
+	c.mu.Lock()
+	detached, pending := c.detached, c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	switch {
+	case pending != nil:
+		return pending.Data, nil
+	case detached:
+		// A message handed over already is read even if ctx is done
+		select {
+		case msg := <-c.messages:
+			return msg, nil
+		default:
+		}
+
+		select {
+		case msg := <-c.messages:
+			return msg, nil
+		case <-c.readDone:
+			return nil, io.EOF
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	msg, err := c.dc.ReadMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Data, nil
+}
@@ -0,0 +1,42 @@
+// +build js,wasm
+
+package dcchannel
+
+import (
+	"context"
+	"io"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// startReading hands the messages received over to Read, as the Wasm bindings
+// don't provide ReadMessage
+func (c *Channel) startReading() {
+	c.dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+		select {
+		case c.messages <- msg.Data:
+		case <-c.released:
+		}
+	})
+}
+
+func (c *Channel) handleOpen() {}
+
+// Read returns the next message received, or io.EOF once the data channel is
+// closed. It returns ctx.Err() once ctx is done.
+func (c *Channel) Read(ctx context.Context) ([]byte, error) {
+	select {
+	case msg := <-c.messages:
+		return msg, nil
+	default:
+	}
+
+	select {
+	case msg := <-c.messages:
+		return msg, nil
+	case <-c.closed:
+		return nil, io.EOF
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
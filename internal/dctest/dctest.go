@@ -0,0 +1,58 @@
+// +build !js
+
+// Package dctest provides the helpers shared by the tests of the packages built
+// on data channels
+package dctest
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// Run runs f with an API of s using data channels as is, and with an API of s
+// detaching them
+func Run(t *testing.T, s webrtc.SettingEngine, f func(t *testing.T, api *webrtc.API)) {
+	t.Run("DataChannels", func(t *testing.T) {
+		f(t, webrtc.NewAPI(webrtc.WithSettingEngine(s)))
+	})
+
+	t.Run("Detached", func(t *testing.T) {
+		s.DetachDataChannels()
+		f(t, webrtc.NewAPI(webrtc.WithSettingEngine(s)))
+	})
+}
+
+// NewPair returns two PeerConnections of api, which are connected by Signal
+func NewPair(t *testing.T, api *webrtc.API) (*webrtc.PeerConnection, *webrtc.PeerConnection) {
+	pcOffer, err := api.NewPeerConnection(webrtc.Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := api.NewPeerConnection(webrtc.Configuration{})
+	assert.NoError(t, err)
+	return pcOffer, pcAnswer
+}
+
+// Signal exchanges the offer and the answer of two PeerConnections, once their
+// candidates are gathered
+func Signal(t *testing.T, pcOffer, pcAnswer *webrtc.PeerConnection) {
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	offerGatheringComplete := webrtc.GatheringCompletePromise(pcOffer)
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	<-offerGatheringComplete
+	assert.NoError(t, pcAnswer.SetRemoteDescription(*pcOffer.LocalDescription()))
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	answerGatheringComplete := webrtc.GatheringCompletePromise(pcAnswer)
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	<-answerGatheringComplete
+	assert.NoError(t, pcOffer.SetRemoteDescription(*pcAnswer.LocalDescription()))
+}
+
+// ClosePair closes two PeerConnections
+func ClosePair(t *testing.T, pcOffer, pcAnswer *webrtc.PeerConnection) {
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
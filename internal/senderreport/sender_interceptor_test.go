@@ -0,0 +1,124 @@
+package senderreport
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTime struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (m *mockTime) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *mockTime) SetNow(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+// bindMockStream binds a local stream to i and returns its writer together with
+// a channel of the Sender Reports written for it
+func bindMockStream(t *testing.T, i *SenderInterceptor, info *interceptor.StreamInfo) (interceptor.RTPWriter, chan *rtcp.SenderReport) {
+	reports := make(chan *rtcp.SenderReport, 10)
+	i.BindRTCPWriter(interceptor.RTCPWriterFunc(func(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+		for _, pkt := range pkts {
+			sr, ok := pkt.(*rtcp.SenderReport)
+			assert.True(t, ok)
+			select {
+			case reports <- sr:
+			default:
+			}
+		}
+		return 0, nil
+	}))
+
+	writer := i.BindLocalStream(info, interceptor.RTPWriterFunc(func(_ *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+		return len(payload), nil
+	}))
+	return writer, reports
+}
+
+// nextReportAt returns the first Sender Report generated at now, as reports may
+// have been sent before the mocked time was updated
+func nextReportAt(reports chan *rtcp.SenderReport, now time.Time) *rtcp.SenderReport {
+	for {
+		if sr := <-reports; sr.NTPTime == ntpTime(now) {
+			return sr
+		}
+	}
+}
+
+func TestSenderInterceptor(t *testing.T) {
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	info := &interceptor.StreamInfo{SSRC: 123456, ClockRate: 90000}
+
+	t.Run("send time", func(t *testing.T) {
+		mt := &mockTime{now: start}
+		i, err := NewSenderInterceptor(SenderInterval(time.Millisecond*10), SenderNow(mt.Now))
+		assert.NoError(t, err)
+
+		writer, reports := bindMockStream(t, i, info)
+		for seq := 0; seq < 10; seq++ {
+			_, err = writer.Write(&rtp.Header{SequenceNumber: uint16(seq), Timestamp: 1000}, []byte{0x00, 0x00}, interceptor.Attributes{})
+			assert.NoError(t, err)
+		}
+		mt.SetNow(start.Add(time.Second))
+
+		sr := nextReportAt(reports, start.Add(time.Second))
+		assert.NoError(t, i.Close())
+		assert.Equal(t, &rtcp.SenderReport{
+			SSRC:        123456,
+			NTPTime:     ntpTime(start.Add(time.Second)),
+			RTPTime:     1000 + 90000,
+			PacketCount: 10,
+			OctetCount:  20,
+		}, sr)
+	})
+
+	t.Run("capture time", func(t *testing.T) {
+		mt := &mockTime{now: start}
+		i, err := NewSenderInterceptor(SenderInterval(time.Millisecond*10), SenderNow(mt.Now))
+		assert.NoError(t, err)
+
+		// The media was captured 500ms before it was sent
+		attributes := interceptor.Attributes{}
+		SetCaptureTime(attributes, start.Add(-500*time.Millisecond))
+
+		writer, reports := bindMockStream(t, i, info)
+		_, err = writer.Write(&rtp.Header{Timestamp: 1000}, []byte{0x00}, attributes)
+		assert.NoError(t, err)
+		mt.SetNow(start.Add(time.Second))
+
+		sr := nextReportAt(reports, start.Add(time.Second))
+		assert.NoError(t, i.Close())
+		assert.Equal(t, ntpTime(start.Add(time.Second)), sr.NTPTime)
+		assert.Equal(t, uint32(1000+135000), sr.RTPTime)
+	})
+}
+
+func TestCaptureTime(t *testing.T) {
+	_, ok := CaptureTime(nil)
+	assert.False(t, ok)
+
+	attributes := interceptor.Attributes{}
+	_, ok = CaptureTime(attributes)
+	assert.False(t, ok)
+
+	captureTime := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	SetCaptureTime(attributes, captureTime)
+	value, ok := CaptureTime(attributes)
+	assert.True(t, ok)
+	assert.Equal(t, captureTime, value)
+}
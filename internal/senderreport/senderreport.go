@@ -0,0 +1,34 @@
+// Package senderreport provides the interceptor that generates RTCP Sender Reports for
+// ConfigureRTCPReports. It is the SenderInterceptor of github.com/pion/interceptor/pkg/report
+// at v0.0.12, which also maps RTP timestamps to the capture time of the media when it is
+// known, so receivers are able to synchronize the streams of a sender. Only the handling of
+// the capture time differs from upstream, fixes of upstream have to be ported over until
+// upstream supports it.
+package senderreport
+
+import (
+	"time"
+
+	"github.com/pion/interceptor"
+)
+
+type attributeKey int
+
+// CaptureTimeKey is the interceptor.Attributes key of the capture time of the media in
+// a RTP packet. The value is a time.Time, taken from the same clock as time.Now.
+const CaptureTimeKey attributeKey = iota
+
+// SetCaptureTime stores the capture time of the media in a RTP packet in attributes
+func SetCaptureTime(attributes interceptor.Attributes, captureTime time.Time) {
+	attributes.Set(CaptureTimeKey, captureTime)
+}
+
+// CaptureTime returns the capture time stored in attributes, if any
+func CaptureTime(attributes interceptor.Attributes) (time.Time, bool) {
+	if attributes == nil {
+		return time.Time{}, false
+	}
+
+	captureTime, ok := attributes.Get(CaptureTimeKey).(time.Time)
+	return captureTime, ok && !captureTime.IsZero()
+}
@@ -121,6 +121,13 @@ func stringPointerToValue(val *string) js.Value {
 	return js.ValueOf(*val)
 }
 
+func priorityPointerToValue(val *PriorityType) js.Value {
+	if val == nil {
+		return js.Undefined()
+	}
+	return js.ValueOf(val.String())
+}
+
 func uint16PointerToValue(val *uint16) js.Value {
 	if val == nil {
 		return js.Undefined()
@@ -0,0 +1,188 @@
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+const (
+	h264NALUTypeMask    = 0x1F
+	h264NALUTypeIDR     = 5
+	h264NALUTypeSPS     = 7
+	h264NALUTypeSTAPA   = 24
+	h264NALUTypeFUA     = 28
+	h264FUAStartBitmask = 0x80
+
+	h265NALUTypeMask     = 0x7E
+	h265NALUTypeShift    = 1
+	h265NALUTypeIRAPMin  = 16
+	h265NALUTypeIRAPMax  = 23
+	h265NALUTypeVPS      = 32
+	h265NALUTypeSPS      = 33
+	h265NALUTypeAP       = 48
+	h265NALUTypeFU       = 49
+	h265FUStartBitmask   = 0x80
+	h265FUTypeMask       = 0x3F
+	h265NALUHeaderSize   = 2
+	h265APNALUSizeLength = 2
+)
+
+// canDetectKeyframes reports if isKeyframeStart is able to inspect payloads of the given codec
+func canDetectKeyframes(mimeType string) bool {
+	return strings.EqualFold(mimeType, MimeTypeVP8) ||
+		strings.EqualFold(mimeType, MimeTypeVP9) ||
+		strings.EqualFold(mimeType, MimeTypeH264) ||
+		strings.EqualFold(mimeType, MimeTypeH265) ||
+		strings.EqualFold(mimeType, MimeTypeAV1)
+}
+
+// isKeyframeStart reports if the given RTP payload is the first packet of a keyframe
+// for the codec identified by mimeType. Codecs that we can't inspect always return false
+func isKeyframeStart(mimeType string, payload []byte) bool {
+	switch {
+	case strings.EqualFold(mimeType, MimeTypeVP8):
+		return isVP8KeyframeStart(payload)
+	case strings.EqualFold(mimeType, MimeTypeVP9):
+		return isVP9KeyframeStart(payload)
+	case strings.EqualFold(mimeType, MimeTypeH264):
+		return isH264KeyframeStart(payload)
+	case strings.EqualFold(mimeType, MimeTypeH265):
+		return isH265KeyframeStart(payload)
+	case strings.EqualFold(mimeType, MimeTypeAV1):
+		return isAV1KeyframeStart(payload)
+	default:
+		return false
+	}
+}
+
+// https://tools.ietf.org/html/rfc7741#section-4.2
+func isVP8KeyframeStart(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+
+	// S bit must be set and partition index must be zero
+	if payload[0]&0x10 == 0 || payload[0]&0x07 != 0 {
+		return false
+	}
+
+	offset := 1
+	if payload[0]&0x80 != 0 {
+		if len(payload) < 2 {
+			return false
+		}
+		ext := payload[1]
+		offset++
+
+		if ext&0x80 != 0 { // I
+			if len(payload) <= offset {
+				return false
+			}
+			if payload[offset]&0x80 != 0 { // M
+				offset++
+			}
+			offset++
+		}
+		if ext&0x40 != 0 { // L
+			offset++
+		}
+		if ext&0x30 != 0 { // T or K
+			offset++
+		}
+	}
+
+	if len(payload) <= offset {
+		return false
+	}
+
+	// P bit of the VP8 payload header is zero for keyframes
+	return payload[offset]&0x01 == 0
+}
+
+// https://tools.ietf.org/html/draft-ietf-payload-vp9-16#section-4.2
+func isVP9KeyframeStart(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+
+	// P bit unset (not inter-picture predicted) and B bit set (start of frame)
+	return payload[0]&0x40 == 0 && payload[0]&0x08 != 0
+}
+
+// https://tools.ietf.org/html/rfc6184#section-5.2
+func isH264KeyframeStart(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+
+	switch naluType := payload[0] & h264NALUTypeMask; naluType {
+	case h264NALUTypeIDR, h264NALUTypeSPS:
+		return true
+	case h264NALUTypeSTAPA:
+		for offset := 1; offset+2 < len(payload); {
+			naluSize := int(binary.BigEndian.Uint16(payload[offset:]))
+			offset += 2
+			if offset >= len(payload) {
+				return false
+			}
+
+			if t := payload[offset] & h264NALUTypeMask; t == h264NALUTypeIDR || t == h264NALUTypeSPS {
+				return true
+			}
+			offset += naluSize
+		}
+	case h264NALUTypeFUA:
+		if len(payload) < 2 {
+			return false
+		}
+		return payload[1]&h264FUAStartBitmask != 0 && payload[1]&h264NALUTypeMask == h264NALUTypeIDR
+	}
+
+	return false
+}
+
+// https://tools.ietf.org/html/rfc7798#section-4.4
+func isH265KeyframeStart(payload []byte) bool {
+	if len(payload) < h265NALUHeaderSize {
+		return false
+	}
+
+	isKeyframeNALU := func(naluType byte) bool {
+		return (naluType >= h265NALUTypeIRAPMin && naluType <= h265NALUTypeIRAPMax) ||
+			naluType == h265NALUTypeVPS || naluType == h265NALUTypeSPS
+	}
+
+	switch naluType := (payload[0] & h265NALUTypeMask) >> h265NALUTypeShift; naluType {
+	case h265NALUTypeAP:
+		for offset := h265NALUHeaderSize; offset+h265APNALUSizeLength < len(payload); {
+			naluSize := int(binary.BigEndian.Uint16(payload[offset:]))
+			offset += h265APNALUSizeLength
+
+			if isKeyframeNALU((payload[offset] & h265NALUTypeMask) >> h265NALUTypeShift) {
+				return true
+			}
+			offset += naluSize
+		}
+		return false
+	case h265NALUTypeFU:
+		if len(payload) <= h265NALUHeaderSize {
+			return false
+		}
+		fuHeader := payload[h265NALUHeaderSize]
+		return fuHeader&h265FUStartBitmask != 0 && isKeyframeNALU(fuHeader&h265FUTypeMask)
+	default:
+		return isKeyframeNALU(naluType)
+	}
+}
+
+// https://aomediacodec.github.io/av1-rtp-spec/#44-av1-aggregation-header
+func isAV1KeyframeStart(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+
+	// Z bit unset (not a continuation) and N bit set (start of a coded video sequence)
+	return payload[0]&0x80 == 0 && payload[0]&0x08 != 0
+}
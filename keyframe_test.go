@@ -0,0 +1,45 @@
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsKeyframeStart(t *testing.T) {
+	for _, test := range []struct {
+		Name     string
+		MimeType string
+		Payload  []byte
+		Keyframe bool
+	}{
+		{"VP8 Keyframe", MimeTypeVP8, []byte{0x10, 0x00}, true},
+		{"VP8 Interframe", MimeTypeVP8, []byte{0x10, 0x01}, false},
+		{"VP8 Continuation", MimeTypeVP8, []byte{0x00, 0x00}, false},
+		{"VP8 Keyframe with PictureID", MimeTypeVP8, []byte{0x90, 0x80, 0x81, 0x02, 0x00}, true},
+		{"VP8 Empty", MimeTypeVP8, []byte{}, false},
+		{"VP9 Keyframe", MimeTypeVP9, []byte{0x08}, true},
+		{"VP9 Interframe", MimeTypeVP9, []byte{0x48}, false},
+		{"H264 IDR", MimeTypeH264, []byte{0x65}, true},
+		{"H264 Non-IDR", MimeTypeH264, []byte{0x41}, false},
+		{"H264 STAP-A with SPS", MimeTypeH264, []byte{0x78, 0x00, 0x01, 0x67, 0x00, 0x01, 0x68}, true},
+		{"H264 STAP-A without SPS", MimeTypeH264, []byte{0x78, 0x00, 0x01, 0x41}, false},
+		{"H264 FU-A IDR Start", MimeTypeH264, []byte{0x7c, 0x85}, true},
+		{"H264 FU-A IDR Middle", MimeTypeH264, []byte{0x7c, 0x05}, false},
+		{"H265 IDR", MimeTypeH265, []byte{0x26, 0x01}, true},
+		{"H265 CRA", MimeTypeH265, []byte{0x2A, 0x01}, true},
+		{"H265 Trail", MimeTypeH265, []byte{0x02, 0x01}, false},
+		{"H265 AP with VPS", MimeTypeH265, []byte{0x60, 0x01, 0x00, 0x02, 0x40, 0x01, 0x00, 0x02, 0x42, 0x01}, true},
+		{"H265 AP without VPS", MimeTypeH265, []byte{0x60, 0x01, 0x00, 0x02, 0x02, 0x01}, false},
+		{"H265 FU IDR Start", MimeTypeH265, []byte{0x62, 0x01, 0x93}, true},
+		{"H265 FU IDR Middle", MimeTypeH265, []byte{0x62, 0x01, 0x13}, false},
+		{"AV1 New Sequence", MimeTypeAV1, []byte{0x08, 0x01, 0x08}, true},
+		{"AV1 Interframe", MimeTypeAV1, []byte{0x00, 0x01, 0x30}, false},
+		{"AV1 Continuation", MimeTypeAV1, []byte{0x88, 0x01, 0x30}, false},
+		{"Opus", MimeTypeOpus, []byte{0x00}, false},
+	} {
+		assert.Equal(t, test.Keyframe, isKeyframeStart(test.MimeType, test.Payload), test.Name)
+	}
+}
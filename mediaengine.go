@@ -11,30 +11,7 @@ import (
 	"github.com/pion/rtp"
 	"github.com/pion/rtp/codecs"
 	"github.com/pion/sdp/v3"
-)
-
-const (
-	// MimeTypeH264 H264 MIME type.
-	// Note: Matching should be case insensitive.
-	MimeTypeH264 = "video/H264"
-	// MimeTypeOpus Opus MIME type
-	// Note: Matching should be case insensitive.
-	MimeTypeOpus = "audio/opus"
-	// MimeTypeVP8 VP8 MIME type
-	// Note: Matching should be case insensitive.
-	MimeTypeVP8 = "video/VP8"
-	// MimeTypeVP9 VP9 MIME type
-	// Note: Matching should be case insensitive.
-	MimeTypeVP9 = "video/VP9"
-	// MimeTypeG722 G722 MIME type
-	// Note: Matching should be case insensitive.
-	MimeTypeG722 = "audio/G722"
-	// MimeTypePCMU PCMU MIME type
-	// Note: Matching should be case insensitive.
-	MimeTypePCMU = "audio/PCMU"
-	// MimeTypePCMA PCMA MIME type
-	// Note: Matching should be case insensitive.
-	MimeTypePCMA = "audio/PCMA"
+	"github.com/pion/webrtc/v3/pkg/rtpcodecs"
 )
 
 type mediaEngineHeaderExtension struct {
@@ -80,12 +57,35 @@ func (m *MediaEngine) RegisterDefaultCodecs() error {
 			RTPCodecCapability: RTPCodecCapability{MimeTypePCMA, 8000, 0, "", nil},
 			PayloadType:        8,
 		},
+		{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeTelephoneEvent, 48000, 0, "0-15", nil},
+			PayloadType:        110,
+		},
+		{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeTelephoneEvent, 8000, 0, "0-15", nil},
+			PayloadType:        126,
+		},
 	} {
 		if err := m.RegisterCodec(codec, RTPCodecTypeAudio); err != nil {
 			return err
 		}
 	}
 
+	// RED carries the registered Opus codec in every block, like browsers send it
+	for _, codec := range m.audioCodecs {
+		if !strings.EqualFold(codec.MimeType, MimeTypeOpus) {
+			continue
+		}
+
+		if err := m.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeRED, 48000, 2, fmt.Sprintf("%d/%d", codec.PayloadType, codec.PayloadType), nil},
+			PayloadType:        63,
+		}, RTPCodecTypeAudio); err != nil {
+			return err
+		}
+		break
+	}
+
 	videoRTCPFeedback := []RTCPFeedback{{"goog-remb", ""}, {"ccm", "fir"}, {"nack", ""}, {"nack", "pli"}}
 	for _, codec := range []RTPCodecParameters{
 		{
@@ -169,6 +169,24 @@ func (m *MediaEngine) RegisterDefaultCodecs() error {
 			PayloadType:        118,
 		},
 
+		{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeAV1, 90000, 0, "", videoRTCPFeedback},
+			PayloadType:        45,
+		},
+		{
+			RTPCodecCapability: RTPCodecCapability{"video/rtx", 90000, 0, "apt=45", nil},
+			PayloadType:        46,
+		},
+
+		{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeH265, 90000, 0, "profile-id=1", videoRTCPFeedback},
+			PayloadType:        47,
+		},
+		{
+			RTPCodecCapability: RTPCodecCapability{"video/rtx", 90000, 0, "apt=47", nil},
+			PayloadType:        48,
+		},
+
 		{
 			RTPCodecCapability: RTPCodecCapability{"video/ulpfec", 90000, 0, "", nil},
 			PayloadType:        116,
@@ -333,8 +351,9 @@ func (m *MediaEngine) collectStats(collector *statsReportCollector) {
 	statsLoop(m.audioCodecs)
 }
 
-// Look up a codec and enable if it exists
-func (m *MediaEngine) matchRemoteCodec(remoteCodec RTPCodecParameters, typ RTPCodecType, exactMatches, partialMatches []RTPCodecParameters) (codecMatchType, error) {
+// Look up a codec and enable if it exists. The returned codec is the remote codec
+// with its parameters updated to the ones negotiated with the local codec.
+func (m *MediaEngine) matchRemoteCodec(remoteCodec RTPCodecParameters, typ RTPCodecType, exactMatches, partialMatches []RTPCodecParameters) (RTPCodecParameters, codecMatchType, error) {
 	codecs := m.videoCodecs
 	if typ == RTPCodecTypeAudio {
 		codecs = m.audioCodecs
@@ -344,7 +363,7 @@ func (m *MediaEngine) matchRemoteCodec(remoteCodec RTPCodecParameters, typ RTPCo
 	if apt, hasApt := remoteFmtp["apt"]; hasApt {
 		payloadType, err := strconv.Atoi(apt)
 		if err != nil {
-			return codecMatchNone, err
+			return remoteCodec, codecMatchNone, err
 		}
 
 		aptMatch := codecMatchNone
@@ -365,7 +384,7 @@ func (m *MediaEngine) matchRemoteCodec(remoteCodec RTPCodecParameters, typ RTPCo
 		}
 
 		if aptMatch == codecMatchNone {
-			return codecMatchNone, nil // not an error, we just ignore this codec we don't support
+			return remoteCodec, codecMatchNone, nil // not an error, we just ignore this codec we don't support
 		}
 
 		// if apt's media codec is partial match, then apt codec must be partial match too
@@ -373,11 +392,14 @@ func (m *MediaEngine) matchRemoteCodec(remoteCodec RTPCodecParameters, typ RTPCo
 		if matchType == codecMatchExact && aptMatch == codecMatchPartial {
 			matchType = codecMatchPartial
 		}
-		return matchType, nil
+		return remoteCodec, matchType, nil
 	}
 
-	_, matchType := codecParametersFuzzySearch(remoteCodec, codecs)
-	return matchType, nil
+	localCodec, matchType := codecParametersFuzzySearch(remoteCodec, codecs)
+	if matchType == codecMatchExact && strings.EqualFold(remoteCodec.MimeType, MimeTypeH264) {
+		remoteCodec.SDPFmtpLine = h264NegotiatedFmtpLine(localCodec.SDPFmtpLine, remoteCodec.SDPFmtpLine)
+	}
+	return remoteCodec, matchType, nil
 }
 
 // Look up a header extension and enable if it exists
@@ -439,8 +461,8 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 		exactMatches := make([]RTPCodecParameters, 0, len(codecs))
 		partialMatches := make([]RTPCodecParameters, 0, len(codecs))
 
-		for _, codec := range codecs {
-			matchType, mErr := m.matchRemoteCodec(codec, typ, exactMatches, partialMatches)
+		for _, remoteCodec := range codecs {
+			codec, matchType, mErr := m.matchRemoteCodec(remoteCodec, typ, exactMatches, partialMatches)
 			if mErr != nil {
 				return mErr
 			}
@@ -542,12 +564,16 @@ func payloaderForCodec(codec RTPCodecCapability) (rtp.Payloader, error) {
 	switch strings.ToLower(codec.MimeType) {
 	case strings.ToLower(MimeTypeH264):
 		return &codecs.H264Payloader{}, nil
+	case strings.ToLower(MimeTypeH265):
+		return &rtpcodecs.H265Payloader{}, nil
 	case strings.ToLower(MimeTypeOpus):
 		return &codecs.OpusPayloader{}, nil
 	case strings.ToLower(MimeTypeVP8):
 		return &codecs.VP8Payloader{}, nil
 	case strings.ToLower(MimeTypeVP9):
 		return &codecs.VP9Payloader{}, nil
+	case strings.ToLower(MimeTypeAV1):
+		return &rtpcodecs.AV1Payloader{}, nil
 	case strings.ToLower(MimeTypeG722):
 		return &codecs.G722Payloader{}, nil
 	case strings.ToLower(MimeTypePCMU), strings.ToLower(MimeTypePCMA):
@@ -556,3 +582,13 @@ func payloaderForCodec(codec RTPCodecCapability) (rtp.Payloader, error) {
 		return nil, ErrNoPayloaderForCodec
 	}
 }
+
+// findFlexFECCodec returns the FlexFEC codec among codecs
+func findFlexFECCodec(codecs []RTPCodecParameters) (RTPCodecParameters, bool) {
+	for _, c := range codecs {
+		if strings.EqualFold(c.MimeType, MimeTypeFlexFEC03) {
+			return c, true
+		}
+	}
+	return RTPCodecParameters{}, false
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3/pkg/rtpcodecs"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -443,3 +444,79 @@ func TestUpdateHeaderExtenstionToClonedMediaEngine(t *testing.T) {
 	validate(&src)
 	validate(src.copy())
 }
+
+func TestMediaEngineAV1(t *testing.T) {
+	m := MediaEngine{}
+	assert.NoError(t, m.RegisterDefaultCodecs())
+
+	codec, matchType := codecParametersFuzzySearch(RTPCodecParameters{RTPCodecCapability: RTPCodecCapability{MimeType: "video/av1"}}, m.videoCodecs)
+	assert.Equal(t, codecMatchExact, matchType)
+	assert.Equal(t, PayloadType(45), codec.PayloadType)
+
+	payloader, err := payloaderForCodec(codec.RTPCodecCapability)
+	assert.NoError(t, err)
+	assert.IsType(t, &rtpcodecs.AV1Payloader{}, payloader)
+}
+
+func TestMediaEngineFmtpNegotiation(t *testing.T) {
+	mustParse := func(raw string) sdp.SessionDescription {
+		s := sdp.SessionDescription{}
+		assert.NoError(t, s.Unmarshal([]byte(raw)))
+		return s
+	}
+
+	t.Run("H264 answer reflects the negotiated level", func(t *testing.T) {
+		const offer = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=video 60323 UDP/TLS/RTP/SAVPF 96 97
+a=rtpmap:96 H264/90000
+a=fmtp:96 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e034
+a=rtpmap:97 H264/90000
+a=fmtp:97 packetization-mode=1;profile-level-id=4d0034
+`
+		m := MediaEngine{}
+		assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeH264, 90000, 0, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f", nil},
+			PayloadType:        102,
+		}, RTPCodecTypeVideo))
+		assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeH264, 90000, 0, "packetization-mode=1;profile-level-id=4d001f", nil},
+			PayloadType:        103,
+		}, RTPCodecTypeVideo))
+		assert.NoError(t, m.updateFromRemoteDescription(mustParse(offer)))
+
+		baseline, _, err := m.getCodecByPayload(96)
+		assert.NoError(t, err)
+		assert.Equal(t, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f", baseline.SDPFmtpLine)
+
+		main, _, err := m.getCodecByPayload(97)
+		assert.NoError(t, err)
+		assert.Equal(t, "packetization-mode=1;profile-level-id=4d001f", main.SDPFmtpLine)
+	})
+
+	t.Run("VP9 profile-id defaults to 0", func(t *testing.T) {
+		const offer = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=video 60323 UDP/TLS/RTP/SAVPF 96 98
+a=rtpmap:96 VP9/90000
+a=rtpmap:98 VP9/90000
+a=fmtp:98 profile-id=2
+`
+		m := MediaEngine{}
+		assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeVP9, 90000, 0, "profile-id=0", nil},
+			PayloadType:        98,
+		}, RTPCodecTypeVideo))
+		assert.NoError(t, m.updateFromRemoteDescription(mustParse(offer)))
+
+		_, _, err := m.getCodecByPayload(96)
+		assert.NoError(t, err)
+
+		_, _, err = m.getCodecByPayload(98)
+		assert.Error(t, err)
+	})
+}
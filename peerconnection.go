@@ -135,7 +135,15 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		log: api.settingEngine.LoggerFactory.NewLogger("pc"),
 	}
 
-	if !api.settingEngine.disableMediaEngineCopy {
+	switch {
+	case api.settingEngine.disableMedia:
+		// An empty MediaEngine rejects the audio and video media sections
+		pc.api = &API{
+			settingEngine: api.settingEngine,
+			mediaEngine:   &MediaEngine{},
+			interceptor:   &interceptor.NoOp{},
+		}
+	case !api.settingEngine.disableMediaEngineCopy:
 		pc.api = &API{
 			settingEngine: api.settingEngine,
 			mediaEngine:   api.mediaEngine.copy(),
@@ -177,7 +185,7 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		}
 	})
 
-	pc.interceptorRTCPWriter = api.interceptor.BindRTCPWriter(interceptor.RTCPWriterFunc(pc.writeRTCP))
+	pc.interceptorRTCPWriter = pc.api.interceptor.BindRTCPWriter(interceptor.RTCPWriterFunc(pc.writeRTCP))
 
 	return pc, nil
 }
@@ -1017,8 +1025,10 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		return err
 	}
 
-	if err := pc.api.mediaEngine.updateFromRemoteDescription(*desc.parsed); err != nil {
-		return err
+	if !pc.api.settingEngine.disableMedia {
+		if err := pc.api.mediaEngine.updateFromRemoteDescription(*desc.parsed); err != nil {
+			return err
+		}
 	}
 
 	var t *RTPTransceiver
@@ -1053,6 +1063,12 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 			}
 
 			switch {
+			case t == nil && pc.api.settingEngine.disableMedia:
+				// The transceiver only answers the media section, which is rejected
+				t = newRTPTransceiver(nil, nil, RTPTransceiverDirectionInactive, kind)
+				pc.mu.Lock()
+				pc.addRTPTransceiver(t)
+				pc.mu.Unlock()
 			case t == nil:
 				receiver, err := pc.api.NewRTPReceiver(kind, pc.dtlsTransport)
 				if err != nil {
@@ -1164,7 +1180,7 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 func (pc *PeerConnection) startReceiver(incoming trackDetails, receiver *RTPReceiver) {
 	encodings := []RTPDecodingParameters{}
 	if incoming.ssrc != 0 {
-		encodings = append(encodings, RTPDecodingParameters{RTPCodingParameters{SSRC: incoming.ssrc}})
+		encodings = append(encodings, RTPDecodingParameters{RTPCodingParameters{SSRC: incoming.ssrc, FEC: RTPFecParameters{SSRC: incoming.fecSSRC}}})
 	}
 	for _, rid := range incoming.rids {
 		encodings = append(encodings, RTPDecodingParameters{RTPCodingParameters{RID: rid}})
@@ -1293,6 +1309,7 @@ func (pc *PeerConnection) startRTPSenders(currentTransceivers []*RTPTransceiver)
 						RTPCodingParameters{
 							SSRC:        transceiver.Sender().ssrc,
 							PayloadType: transceiver.Sender().payloadType,
+							FEC:         RTPFecParameters{SSRC: transceiver.Sender().fecSSRC},
 						},
 					},
 				},
@@ -1307,10 +1324,10 @@ func (pc *PeerConnection) startRTPSenders(currentTransceivers []*RTPTransceiver)
 }
 
 // Start SCTP subsystem
-func (pc *PeerConnection) startSCTP() {
+func (pc *PeerConnection) startSCTP(remoteMaxMessageSize uint32) {
 	// Start sctp
 	if err := pc.sctpTransport.Start(SCTPCapabilities{
-		MaxMessageSize: 0,
+		MaxMessageSize: remoteMaxMessageSize,
 	}); err != nil {
 		pc.log.Warnf("Failed to start SCTP: %s", err)
 		if err = pc.sctpTransport.Stop(); err != nil {
@@ -1579,6 +1596,9 @@ func (pc *PeerConnection) AddTrack(track TrackLocal) (*RTPSender, error) {
 	if pc.isClosed.get() {
 		return nil, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
+	if pc.api.settingEngine.disableMedia {
+		return nil, ErrMediaDisabled
+	}
 
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
@@ -1662,6 +1682,9 @@ func (pc *PeerConnection) AddTransceiverFromKind(kind RTPCodecType, init ...RTPT
 	if pc.isClosed.get() {
 		return nil, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
+	if pc.api.settingEngine.disableMedia {
+		return nil, ErrMediaDisabled
+	}
 
 	direction := RTPTransceiverDirectionSendrecv
 	if len(init) > 1 {
@@ -1703,6 +1726,9 @@ func (pc *PeerConnection) AddTransceiverFromTrack(track TrackLocal, init ...RTPT
 	if pc.isClosed.get() {
 		return nil, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
+	if pc.api.settingEngine.disableMedia {
+		return nil, ErrMediaDisabled
+	}
 
 	direction := RTPTransceiverDirectionSendrecv
 	if len(init) > 1 {
@@ -1771,6 +1797,14 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 		if options.Negotiated != nil {
 			params.Negotiated = *options.Negotiated
 		}
+
+		if options.Priority != nil {
+			params.Priority = *options.Priority
+		}
+
+		if options.Fragmented != nil {
+			params.Fragmented = *options.Fragmented
+		}
 	}
 
 	d, err := pc.api.newDataChannel(params, pc.log)
@@ -1778,6 +1812,11 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 		return nil, err
 	}
 
+	// The sub-protocol sent to the remote peer is longer for fragmented data channels
+	if len(d.wireProtocol()) > 65535 {
+		return nil, &rtcerr.TypeError{Err: ErrProtocolTooLarge}
+	}
+
 	// https://w3c.github.io/webrtc-pc/#peer-to-peer-data-api (Step #16)
 	if d.maxPacketLifeTime != nil && d.maxRetransmits != nil {
 		return nil, &rtcerr.TypeError{Err: ErrRetransmitsOrPacketLifeTime}
@@ -2021,6 +2060,10 @@ func (pc *PeerConnection) GetStats() StatsReport {
 	}
 	pc.mu.Unlock()
 
+	for _, receiver := range pc.GetReceivers() {
+		receiver.collectStats(statsCollector)
+	}
+
 	pc.api.mediaEngine.collectStats(statsCollector)
 
 	return statsCollector.Ready()
@@ -2056,6 +2099,13 @@ func (pc *PeerConnection) startTransports(iceRole ICERole, dtlsRole DTLSRole, re
 }
 
 func (pc *PeerConnection) startRTP(isRenegotiation bool, remoteDesc *SessionDescription, currentTransceivers []*RTPTransceiver) {
+	if pc.api.settingEngine.disableMedia {
+		if haveApplicationMediaSection(remoteDesc.parsed) {
+			pc.startSCTP(getMaxMessageSize(remoteDesc.parsed))
+		}
+		return
+	}
+
 	trackDetails := trackDetailsFromSDP(pc.log, remoteDesc.parsed)
 	if isRenegotiation {
 		for _, t := range currentTransceivers {
@@ -2091,7 +2141,7 @@ func (pc *PeerConnection) startRTP(isRenegotiation bool, remoteDesc *SessionDesc
 
 	pc.startRTPReceivers(trackDetails, currentTransceivers)
 	if haveApplicationMediaSection(remoteDesc.parsed) {
-		pc.startSCTP()
+		pc.startSCTP(getMaxMessageSize(remoteDesc.parsed))
 	}
 
 	if !isRenegotiation {
@@ -2167,7 +2217,7 @@ func (pc *PeerConnection) generateUnmatchedSDP(transceivers []*RTPTransceiver, u
 		return nil, err
 	}
 
-	return populateSDP(d, isPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, pc.api.mediaEngine, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), candidates, iceParams, mediaSections, pc.ICEGatheringState())
+	return populateSDP(d, isPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, pc.api.mediaEngine, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), candidates, iceParams, mediaSections, pc.ICEGatheringState(), pc.sctpTransport.localMaxMessageSize())
 }
 
 // generateMatchedSDP generates a SDP and takes the remote state into account
@@ -2288,7 +2338,7 @@ func (pc *PeerConnection) generateMatchedSDP(transceivers []*RTPTransceiver, use
 		return nil, err
 	}
 
-	return populateSDP(d, detectedPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, pc.api.mediaEngine, connectionRole, candidates, iceParams, mediaSections, pc.ICEGatheringState())
+	return populateSDP(d, detectedPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, pc.api.mediaEngine, connectionRole, candidates, iceParams, mediaSections, pc.ICEGatheringState(), pc.sctpTransport.localMaxMessageSize())
 }
 
 func (pc *PeerConnection) setGatherCompleteHandler(handler func()) {
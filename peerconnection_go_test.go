@@ -1397,3 +1397,76 @@ func TestPeerConnection_SessionID(t *testing.T) {
 	}
 	closePairNow(t, pcOffer, pcAnswer)
 }
+
+func TestPeerConnection_DisableMedia(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.DisableMedia(true)
+	dataOnlyAPI := NewAPI(WithSettingEngine(s))
+
+	// connect signals the pair, and waits for a data channel created by the offerer to open
+	connect := func(t *testing.T, pcOffer, pcAnswer *PeerConnection) {
+		opened := make(chan struct{})
+		pcAnswer.OnDataChannel(func(d *DataChannel) {
+			d.OnOpen(func() {
+				close(opened)
+			})
+		})
+		assert.NoError(t, signalPair(pcOffer, pcAnswer))
+		<-opened
+	}
+
+	t.Run("Data only", func(t *testing.T) {
+		pcOffer, pcAnswer, err := dataOnlyAPI.newPair(Configuration{})
+		assert.NoError(t, err)
+
+		track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		assert.NoError(t, err)
+		_, err = pcOffer.AddTrack(track)
+		assert.Equal(t, ErrMediaDisabled, err)
+		_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+		assert.Equal(t, ErrMediaDisabled, err)
+		_, err = pcOffer.AddTransceiverFromTrack(track)
+		assert.Equal(t, ErrMediaDisabled, err)
+
+		connect(t, pcOffer, pcAnswer)
+
+		for _, pc := range []*PeerConnection{pcOffer, pcAnswer} {
+			media := pc.LocalDescription().parsed.MediaDescriptions
+			assert.Len(t, media, 1)
+			assert.Equal(t, mediaSectionApplication, media[0].MediaName.Media)
+
+			assert.Nil(t, pc.dtlsTransport.srtpSession.Load())
+			assert.Nil(t, pc.dtlsTransport.srtcpSession.Load())
+			assert.Nil(t, pc.dtlsTransport.srtpEndpoint)
+		}
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("Media offered", func(t *testing.T) {
+		pcOffer, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		pcAnswer, err := dataOnlyAPI.NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{Direction: RTPTransceiverDirectionRecvonly})
+		assert.NoError(t, err)
+
+		connect(t, pcOffer, pcAnswer)
+
+		// The video media section is rejected
+		media := pcAnswer.LocalDescription().parsed.MediaDescriptions
+		assert.Len(t, media, 2)
+		assert.Equal(t, "video", media[0].MediaName.Media)
+		assert.Equal(t, 0, media[0].MediaName.Port.Value)
+		assert.Equal(t, mediaSectionApplication, media[1].MediaName.Media)
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+}
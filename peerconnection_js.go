@@ -367,6 +367,9 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 			err = recoveryToError(e)
 		}
 	}()
+	if options != nil && options.Fragmented != nil && *options.Fragmented {
+		return nil, errFragmentedNotSupported
+	}
 	channel := pc.underlying.Call("createDataChannel", label, dataChannelInitToValue(options))
 	return &DataChannel{
 		underlying: channel,
@@ -706,6 +709,7 @@ func dataChannelInitToValue(options *DataChannelInit) js.Value {
 		"protocol":          stringPointerToValue(options.Protocol),
 		"negotiated":        boolPointerToValue(options.Negotiated),
 		"id":                uint16PointerToValue(options.ID),
+		"priority":          priorityPointerToValue(options.Priority),
 	})
 }
 
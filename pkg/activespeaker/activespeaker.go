@@ -0,0 +1,185 @@
+// Package activespeaker detects the dominant speaker of a conference from the
+// RFC 6464 ssrc-audio-level header extension, without decoding any audio.
+package activespeaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	defaultInterval   = 20 * time.Millisecond
+	defaultWindow     = time.Second
+	defaultThreshold  = 40
+	defaultHysteresis = 6
+
+	// maxActivity is the activity of a source with a level of 0 -dBov
+	maxActivity = 127
+)
+
+// Source is an audio track the Detector observes, usually a *webrtc.TrackRemote.
+// The latest level is only updated while the track is read.
+type Source interface {
+	SynchronizationSource() (webrtc.RTPSynchronizationSource, bool)
+}
+
+type source struct {
+	activity float64
+}
+
+// Detector samples the audio level of every source once per interval and averages it.
+// The source with the highest average becomes the dominant speaker once it exceeds
+// the threshold, and stays dominant until another source exceeds it by the hysteresis,
+// even if everybody is silent.
+type Detector struct {
+	mu       sync.Mutex
+	sources  map[Source]*source
+	dominant Source
+	onChange func(Source)
+
+	interval   time.Duration
+	window     time.Duration
+	threshold  float64
+	hysteresis float64
+
+	wg    sync.WaitGroup
+	close chan struct{}
+}
+
+// NewDetector returns a new Detector, which samples the audio levels until it is closed.
+func NewDetector(opts ...Option) (*Detector, error) {
+	d := &Detector{
+		sources:    map[Source]*source{},
+		interval:   defaultInterval,
+		window:     defaultWindow,
+		threshold:  defaultThreshold,
+		hysteresis: defaultHysteresis,
+		close:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return nil, err
+		}
+	}
+
+	d.wg.Add(1)
+	go d.loop()
+
+	return d, nil
+}
+
+// AddSource starts observing the audio level of s
+func (d *Detector) AddSource(s Source) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.sources[s]; !ok {
+		d.sources[s] = &source{}
+	}
+}
+
+// RemoveSource stops observing the audio level of s. If s was the dominant speaker
+// there is none until another source exceeds the threshold.
+func (d *Detector) RemoveSource(s Source) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.sources, s)
+	if d.dominant == s {
+		d.dominant = nil
+	}
+}
+
+// DominantSpeaker returns the current dominant speaker, or nil if there is none yet
+func (d *Detector) DominantSpeaker() Source {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.dominant
+}
+
+// OnDominantSpeakerChange sets an event handler which is invoked when the dominant
+// speaker changes. It is called from the goroutine of the Detector.
+func (d *Detector) OnDominantSpeakerChange(f func(Source)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.onChange = f
+}
+
+// Close stops the Detector
+func (d *Detector) Close() error {
+	defer d.wg.Wait()
+
+	select {
+	case <-d.close:
+	default:
+		close(d.close)
+	}
+
+	return nil
+}
+
+func (d *Detector) loop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			d.detect(now)
+		case <-d.close:
+			return
+		}
+	}
+}
+
+// detect samples the audio levels at now and updates the dominant speaker
+func (d *Detector) detect(now time.Time) {
+	d.mu.Lock()
+
+	// Samples decay with the time constant of the window
+	weight := float64(d.interval) / float64(d.window)
+	if weight > 1 {
+		weight = 1
+	}
+
+	var loudest Source
+	for s, state := range d.sources {
+		state.activity += weight * (d.activity(s, now) - state.activity)
+		if loudest == nil || state.activity > d.sources[loudest].activity {
+			loudest = s
+		}
+	}
+
+	changed := false
+	if loudest != nil && loudest != d.dominant && d.sources[loudest].activity >= d.threshold {
+		if d.dominant == nil || d.sources[loudest].activity > d.sources[d.dominant].activity+d.hysteresis {
+			d.dominant = loudest
+			changed = true
+		}
+	}
+
+	dominant, onChange := d.dominant, d.onChange
+	d.mu.Unlock()
+
+	if changed && onChange != nil {
+		onChange(dominant)
+	}
+}
+
+// activity returns the activity of the latest packet of s. Sources that didn't
+// receive a packet within the window, or whose packets don't carry an audio
+// level, are silent.
+func (d *Detector) activity(s Source, now time.Time) float64 {
+	latest, ok := s.SynchronizationSource()
+	if !ok || latest.AudioLevel == nil || now.Sub(latest.Timestamp) > d.window {
+		return 0
+	}
+
+	return float64(maxActivity - latest.AudioLevel.Level)
+}
@@ -0,0 +1,147 @@
+package activespeaker
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSource struct {
+	mu     sync.Mutex
+	source webrtc.RTPSynchronizationSource
+	ok     bool
+}
+
+func (m *mockSource) SynchronizationSource() (webrtc.RTPSynchronizationSource, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.source, m.ok
+}
+
+func (m *mockSource) setLevel(level uint8, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.source = webrtc.RTPSynchronizationSource{Timestamp: now, AudioLevel: &media.AudioLevel{Level: level, Voice: true}}
+	m.ok = true
+}
+
+func TestDetector(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// Sampling is driven by calling detect directly, the loop never ticks
+	d, err := NewDetector(Interval(time.Hour), Window(2*time.Hour))
+	assert.NoError(t, err)
+
+	changes := []Source{}
+	d.OnDominantSpeakerChange(func(s Source) {
+		changes = append(changes, s)
+	})
+
+	alice, bob, muted := &mockSource{}, &mockSource{}, &mockSource{}
+	d.AddSource(alice)
+	d.AddSource(bob)
+	d.AddSource(muted)
+
+	now := time.Now()
+	step := func(aliceLevel, bobLevel uint8) {
+		now = now.Add(d.interval)
+		alice.setLevel(aliceLevel, now)
+		bob.setLevel(bobLevel, now)
+		d.detect(now)
+	}
+
+	t.Run("Silence", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			step(127, 100)
+		}
+		assert.Nil(t, d.DominantSpeaker())
+	})
+
+	t.Run("Speaking", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			step(30, 100)
+		}
+		assert.Equal(t, alice, d.DominantSpeaker())
+	})
+
+	t.Run("Hysteresis", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			step(30, 28)
+		}
+		assert.Equal(t, alice, d.DominantSpeaker())
+
+		for i := 0; i < 10; i++ {
+			step(30, 10)
+		}
+		assert.Equal(t, bob, d.DominantSpeaker())
+	})
+
+	t.Run("Stays dominant while silent", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			step(127, 127)
+		}
+		assert.Equal(t, bob, d.DominantSpeaker())
+	})
+
+	t.Run("Stale levels are silent", func(t *testing.T) {
+		alice.setLevel(0, now.Add(-d.window))
+		for i := 0; i < 10; i++ {
+			now = now.Add(d.interval)
+			d.detect(now)
+		}
+		assert.Equal(t, bob, d.DominantSpeaker())
+	})
+
+	t.Run("RemoveSource", func(t *testing.T) {
+		d.RemoveSource(bob)
+		assert.Nil(t, d.DominantSpeaker())
+	})
+
+	assert.Equal(t, []Source{alice, bob}, changes)
+	assert.NoError(t, d.Close())
+}
+
+func TestDetectorLoop(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	d, err := NewDetector(Interval(10*time.Millisecond), Window(50*time.Millisecond))
+	assert.NoError(t, err)
+
+	speaker := &mockSource{}
+	speaker.setLevel(20, time.Now())
+	dominant := make(chan Source, 1)
+	d.OnDominantSpeakerChange(func(s Source) {
+		dominant <- s
+	})
+	d.AddSource(speaker)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-time.After(10 * time.Millisecond):
+				speaker.setLevel(20, time.Now())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	assert.Equal(t, speaker, <-dominant)
+	close(done)
+	assert.NoError(t, d.Close())
+	assert.NoError(t, d.Close())
+}
@@ -0,0 +1,43 @@
+package activespeaker
+
+import (
+	"time"
+)
+
+// Option can be used to configure the Detector.
+type Option func(d *Detector) error
+
+// Interval sets how often the audio levels of the sources are sampled.
+func Interval(interval time.Duration) Option {
+	return func(d *Detector) error {
+		d.interval = interval
+		return nil
+	}
+}
+
+// Window sets the time constant the audio levels are averaged over. Shorter windows
+// react faster to a new speaker, longer windows ignore short noises.
+func Window(window time.Duration) Option {
+	return func(d *Detector) error {
+		d.window = window
+		return nil
+	}
+}
+
+// Threshold sets the averaged activity a source needs to become the dominant speaker.
+// Activity is 127 minus the level in -dBov, so 0 is silence and 127 the loudest audio.
+func Threshold(threshold uint8) Option {
+	return func(d *Detector) error {
+		d.threshold = float64(threshold)
+		return nil
+	}
+}
+
+// Hysteresis sets by how much the averaged activity of a source has to exceed the
+// activity of the dominant speaker to replace it.
+func Hysteresis(hysteresis uint8) Option {
+	return func(d *Detector) error {
+		d.hysteresis = float64(hysteresis)
+		return nil
+	}
+}
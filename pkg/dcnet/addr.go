@@ -0,0 +1,28 @@
+package dcnet
+
+import (
+	"net"
+	"strconv"
+)
+
+// Addr is the address of a Conn or Listener: a candidate of the selected ICE candidate pair,
+// and the label of the data channel
+type Addr struct {
+	// Protocol is the transport protocol of the candidate, "udp" or "tcp". It is
+	// empty if no candidate pair has been selected yet.
+	Protocol string
+	Address  string
+	Port     uint16
+	// Label is the label of the data channel, it is empty for a Listener
+	Label string
+}
+
+// Network returns "webrtc", as data channels are sent over SCTP on top of DTLS and ICE
+func (a *Addr) Network() string {
+	return "webrtc"
+}
+
+// String returns the address and port of the candidate, like "192.0.2.1:50000"
+func (a *Addr) String() string {
+	return net.JoinHostPort(a.Address, strconv.Itoa(int(a.Port)))
+}
@@ -0,0 +1,51 @@
+// +build !js
+
+package dcnet
+
+import (
+	"github.com/pion/webrtc/v3"
+)
+
+// candidateAddr returns the address of a candidate of the selected candidate pair of an ICE transport
+func candidateAddr(ice *webrtc.ICETransport, label string, local bool) *Addr {
+	addr := &Addr{Label: label}
+	if ice == nil {
+		return addr
+	}
+
+	pair, err := ice.GetSelectedCandidatePair()
+	if err != nil || pair == nil {
+		return addr
+	}
+
+	candidate := pair.Remote
+	if local {
+		candidate = pair.Local
+	}
+	addr.Protocol = candidate.Protocol.String()
+	addr.Address = candidate.Address
+	addr.Port = candidate.Port
+	return addr
+}
+
+func dataChannelICETransport(dc *webrtc.DataChannel) *webrtc.ICETransport {
+	if sctp := dc.Transport(); sctp != nil && sctp.Transport() != nil {
+		return sctp.Transport().ICETransport()
+	}
+	return nil
+}
+
+func localAddr(dc *webrtc.DataChannel) *Addr {
+	return candidateAddr(dataChannelICETransport(dc), dc.Label(), true)
+}
+
+func remoteAddr(dc *webrtc.DataChannel) *Addr {
+	return candidateAddr(dataChannelICETransport(dc), dc.Label(), false)
+}
+
+func listenerAddr(pc *webrtc.PeerConnection) *Addr {
+	if sctp := pc.SCTP(); sctp != nil && sctp.Transport() != nil {
+		return candidateAddr(sctp.Transport().ICETransport(), "", true)
+	}
+	return &Addr{}
+}
@@ -0,0 +1,21 @@
+// +build js,wasm
+
+package dcnet
+
+import (
+	"github.com/pion/webrtc/v3"
+)
+
+// The selected ICE candidate pair isn't available in the browser, so only the label is known
+
+func localAddr(dc *webrtc.DataChannel) *Addr {
+	return &Addr{Label: dc.Label()}
+}
+
+func remoteAddr(dc *webrtc.DataChannel) *Addr {
+	return &Addr{Label: dc.Label()}
+}
+
+func listenerAddr(*webrtc.PeerConnection) *Addr {
+	return &Addr{}
+}
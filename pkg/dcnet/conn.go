@@ -0,0 +1,214 @@
+// Package dcnet provides net.Conn and net.Listener adapters over WebRTC data channels,
+// so protocols like HTTP, TLS or gRPC can run over a PeerConnection.
+package dcnet
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/transport/deadline"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/internal/dcchannel"
+)
+
+// maxMessageSize is the largest message written, which every browser can receive
+const maxMessageSize = 16384
+
+// Conn is a net.Conn over a DataChannel. The data channel is used as a byte stream, and
+// message boundaries aren't kept.
+//
+// An empty message marks the end of the stream written by the remote Conn, see CloseWrite.
+// Both peers have to use a Conn for the data channel, as plain empty messages end the stream too.
+type Conn struct {
+	c *dcchannel.Channel
+
+	pending []byte
+	eof     bool
+	readMu  sync.Mutex
+
+	writeMu     sync.Mutex
+	writeClosed bool
+
+	readDeadline  *deadline.Deadline
+	writeDeadline *deadline.Deadline
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewConn returns a Conn over dc. The Conn takes over the OnOpen, OnClose and
+// OnBufferedAmountLow handlers of dc, and reads its messages with ReadMessage, so dc
+// must not have an OnMessage handler. It should be created before dc opens, for example
+// in the OnDataChannel handler. Detached data channels are supported, the Conn detaches
+// them itself once they are open.
+//
+// Write blocks until dc is open.
+func NewConn(dc *webrtc.DataChannel) *Conn {
+	return &Conn{
+		c:             dcchannel.New(dc),
+		readDeadline:  deadline.New(),
+		writeDeadline: deadline.New(),
+		closed:        make(chan struct{}),
+	}
+}
+
+// Dial opens a new data channel with the given label on pc and returns a Conn over it.
+// It doesn't wait for the data channel to open.
+func Dial(pc *webrtc.PeerConnection, label string) (*Conn, error) {
+	dc, err := pc.CreateDataChannel(label, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(dc), nil
+}
+
+// DataChannel returns the data channel of the Conn
+func (c *Conn) DataChannel() *webrtc.DataChannel {
+	return c.c.DataChannel()
+}
+
+// Read reads data from the stream. It returns io.EOF once the remote Conn called
+// CloseWrite, or the data channel was closed and every message has been read.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	if c.isClosed() {
+		return 0, io.ErrClosedPipe
+	}
+
+	for len(c.pending) == 0 {
+		if c.eof {
+			return 0, io.EOF
+		}
+
+		msg, err := c.c.Read(c.readDeadline)
+		if err != nil {
+			return 0, c.mapError(err)
+		}
+
+		if len(msg) == 0 {
+			c.eof = true
+		}
+		c.pending = msg
+	}
+
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write writes data to the stream, split into messages of at most 16 KiB. It blocks until
+// the data channel is open, and while more than 1 MiB is buffered by the data channel.
+func (c *Conn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.writeClosed {
+		return 0, io.ErrClosedPipe
+	}
+
+	n := 0
+	for n < len(b) {
+		size := len(b) - n
+		if size > maxMessageSize {
+			size = maxMessageSize
+		}
+		if err := c.write(b[n : n+size]); err != nil {
+			return n, err
+		}
+		n += size
+	}
+	return n, nil
+}
+
+func (c *Conn) write(b []byte) error {
+	if c.isClosed() {
+		return io.ErrClosedPipe
+	}
+	return c.mapError(c.c.Write(c.writeDeadline, b))
+}
+
+// CloseWrite ends the stream written by this Conn, the remote Conn reads io.EOF once it
+// read everything written before. Data can still be read until the remote Conn closes
+// its side too.
+func (c *Conn) CloseWrite() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.writeClosed {
+		return nil
+	}
+
+	if err := c.write([]byte{}); err != nil {
+		return err
+	}
+	c.writeClosed = true
+	return nil
+}
+
+// Close closes the data channel, which ends both directions. Blocked Read and Write
+// calls return io.ErrClosedPipe.
+func (c *Conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		err = c.c.Close()
+	})
+	return err
+}
+
+func (c *Conn) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// mapError returns the errors of a net.Conn
+func (c *Conn) mapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case c.isClosed():
+		return io.ErrClosedPipe
+	case err == context.DeadlineExceeded:
+		return errTimeout
+	}
+	return err
+}
+
+// LocalAddr returns the local address of the selected ICE candidate pair
+func (c *Conn) LocalAddr() net.Addr {
+	return localAddr(c.c.DataChannel())
+}
+
+// RemoteAddr returns the remote address of the selected ICE candidate pair
+func (c *Conn) RemoteAddr() net.Addr {
+	return remoteAddr(c.c.DataChannel())
+}
+
+// SetDeadline sets the read and write deadlines
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.readDeadline.Set(t)
+	c.writeDeadline.Set(t)
+	return nil
+}
+
+// SetReadDeadline sets the deadline for future and pending Read calls. Zero means no deadline.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.Set(t)
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for future and pending Write calls. Zero means no deadline.
+// Data that was handed to the data channel before the deadline is still sent.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.Set(t)
+	return nil
+}
@@ -0,0 +1,156 @@
+// +build !js
+
+package dcnet
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/internal/dctest"
+	"github.com/stretchr/testify/assert"
+)
+
+// connectedPair returns two connected PeerConnections, with a Conn dialed by the offerer
+// and the Listener of the answerer
+func connectedPair(t *testing.T, api *webrtc.API) (*webrtc.PeerConnection, *webrtc.PeerConnection, *Conn, *Listener) {
+	pcOffer, pcAnswer := dctest.NewPair(t, api)
+
+	listener := Listen(pcAnswer)
+	conn, err := Dial(pcOffer, "conn")
+	assert.NoError(t, err)
+
+	dctest.Signal(t, pcOffer, pcAnswer)
+	return pcOffer, pcAnswer, conn, listener
+}
+
+func TestConn(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	dctest.Run(t, webrtc.SettingEngine{}, func(t *testing.T, api *webrtc.API) {
+		pcOffer, pcAnswer, conn, listener := connectedPair(t, api)
+		defer dctest.ClosePair(t, pcOffer, pcAnswer)
+
+		accepted, err := listener.Accept()
+		assert.NoError(t, err)
+
+		t.Run("Stream", func(t *testing.T) {
+			// Writes larger than a message are split, and reads don't keep message boundaries
+			data := make([]byte, 3*maxMessageSize+10)
+			for i := range data {
+				data[i] = byte(i)
+			}
+
+			go func() {
+				_, writeErr := conn.Write(data)
+				assert.NoError(t, writeErr)
+			}()
+
+			received := make([]byte, len(data))
+			_, err = io.ReadFull(accepted, received)
+			assert.NoError(t, err)
+			assert.Equal(t, data, received)
+		})
+
+		t.Run("Addr", func(t *testing.T) {
+			remote, ok := accepted.RemoteAddr().(*Addr)
+			assert.True(t, ok)
+			assert.Equal(t, "conn", remote.Label)
+			assert.Equal(t, "udp", remote.Protocol)
+			assert.NotZero(t, remote.Port)
+
+			local, ok := conn.LocalAddr().(*Addr)
+			assert.True(t, ok)
+			assert.Equal(t, local.String(), remote.String())
+			assert.Equal(t, "webrtc", local.Network())
+			assert.Equal(t, "", listener.Addr().(*Addr).Label)
+		})
+
+		t.Run("Read deadline", func(t *testing.T) {
+			assert.NoError(t, conn.SetReadDeadline(time.Now().Add(-time.Second)))
+			_, readErr := conn.Read(make([]byte, 10))
+			netErr, ok := readErr.(net.Error)
+			assert.True(t, ok)
+			assert.True(t, netErr.Timeout())
+			assert.NoError(t, conn.SetReadDeadline(time.Time{}))
+		})
+
+		t.Run("Half close", func(t *testing.T) {
+			_, err = conn.Write([]byte("request"))
+			assert.NoError(t, err)
+			assert.NoError(t, conn.CloseWrite())
+
+			_, err = conn.Write([]byte("more"))
+			assert.Equal(t, io.ErrClosedPipe, err)
+
+			request, readErr := ioutil.ReadAll(accepted)
+			assert.NoError(t, readErr)
+			assert.Equal(t, []byte("request"), request)
+
+			_, err = accepted.Write([]byte("response"))
+			assert.NoError(t, err)
+			response := make([]byte, 8)
+			_, err = io.ReadFull(conn, response)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("response"), response)
+		})
+
+		t.Run("Close", func(t *testing.T) {
+			assert.NoError(t, accepted.Close())
+
+			_, readErr := accepted.Read(make([]byte, 10))
+			assert.Equal(t, io.ErrClosedPipe, readErr)
+
+			// The remote Conn reads the end of the stream once the data channel is closed
+			_, readErr = conn.Read(make([]byte, 10))
+			assert.Equal(t, io.EOF, readErr)
+		})
+
+		assert.NoError(t, listener.Close())
+		_, err = listener.Accept()
+		assert.Equal(t, io.ErrClosedPipe, err)
+	})
+}
+
+func TestHTTP(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	pcOffer, pcAnswer, _, listener := connectedPair(t, webrtc.NewAPI())
+	defer dctest.ClosePair(t, pcOffer, pcAnswer)
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello " + r.URL.Path))
+		assert.NoError(t, err)
+	})}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	transport := &http.Transport{
+		DialContext: func(context.Context, string, string) (net.Conn, error) {
+			return Dial(pcOffer, "http")
+		},
+	}
+	client := &http.Client{Transport: transport}
+
+	for _, path := range []string{"/a", "/b"} {
+		resp, err := client.Get("http://webrtc" + path)
+		assert.NoError(t, err)
+
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+		assert.Equal(t, "hello "+path, string(body))
+	}
+
+	transport.CloseIdleConnections()
+	assert.NoError(t, server.Close())
+}
@@ -0,0 +1,14 @@
+package dcnet
+
+import (
+	"net"
+)
+
+// errTimeout is returned by Read and Write once their deadline is exceeded
+var errTimeout net.Error = timeoutError{}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,100 @@
+package dcnet
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// Listener is a net.Listener that yields a Conn for every data channel the remote peer
+// opens on a PeerConnection
+type Listener struct {
+	pc *webrtc.PeerConnection
+
+	mu       sync.Mutex
+	pending  []*Conn
+	isClosed bool
+
+	notify    chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Listen returns a Listener for the data channels opened by the remote peer of pc.
+// It takes over the OnDataChannel handler of pc.
+func Listen(pc *webrtc.PeerConnection) *Listener {
+	l := &Listener{
+		pc:     pc,
+		notify: make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+
+	// The handler must not block, as no other data channel is accepted until it returns
+	pc.OnDataChannel(func(dc *webrtc.DataChannel) {
+		c := NewConn(dc)
+
+		l.mu.Lock()
+		if l.isClosed {
+			l.mu.Unlock()
+			_ = c.Close()
+			return
+		}
+		l.pending = append(l.pending, c)
+		l.mu.Unlock()
+
+		select {
+		case l.notify <- struct{}{}:
+		default:
+		}
+	})
+
+	return l
+}
+
+// Accept waits for and returns the next data channel opened by the remote peer
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		l.mu.Lock()
+		if l.isClosed {
+			l.mu.Unlock()
+			return nil, io.ErrClosedPipe
+		}
+		if len(l.pending) != 0 {
+			c := l.pending[0]
+			l.pending = l.pending[1:]
+			l.mu.Unlock()
+			return c, nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-l.notify:
+		case <-l.closed:
+		}
+	}
+}
+
+// Close stops accepting data channels. The ones that weren't accepted yet, or are opened
+// afterwards, are closed. Conns that were accepted already and the PeerConnection stay open.
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() {
+		l.mu.Lock()
+		l.isClosed = true
+		pending := l.pending
+		l.pending = nil
+		l.mu.Unlock()
+
+		close(l.closed)
+		for _, c := range pending {
+			_ = c.Close()
+		}
+	})
+	return nil
+}
+
+// Addr returns the local address of the selected ICE candidate pair
+func (l *Listener) Addr() net.Addr {
+	return listenerAddr(l.pc)
+}
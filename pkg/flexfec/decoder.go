@@ -0,0 +1,174 @@
+package flexfec
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// maxMediaPackets is the number of recent media packets kept to recover others
+	maxMediaPackets = 512
+	// maxFECPackets is the number of FEC packets kept until they can be used
+	maxFECPackets = 64
+)
+
+// fecPacket is a received FEC packet, the protected payload points into a copy of the packet
+type fecPacket struct {
+	header  header
+	payload []byte
+}
+
+// Decoder recovers lost packets of a single media stream from the FlexFEC-03 packets
+// protecting it. Media and FEC packets are pushed as they are received, and a lost
+// packet is recovered as soon as it is the only packet a FEC packet protects that is
+// missing. It is safe for concurrent use.
+type Decoder struct {
+	mu   sync.Mutex
+	ssrc uint32
+
+	media      map[uint16][]byte
+	mediaOrder []uint16
+	fec        []*fecPacket
+}
+
+// NewDecoder returns a Decoder for the media stream with the given SSRC
+func NewDecoder(ssrc uint32) *Decoder {
+	return &Decoder{
+		ssrc:  ssrc,
+		media: map[uint16][]byte{},
+	}
+}
+
+// PushMedia adds a received media packet. It returns the packets that could be recovered
+// with it, and false if the packet was received or recovered before, in which case the
+// packet should be dropped.
+func (d *Decoder) PushMedia(raw []byte) ([][]byte, bool) {
+	if len(raw) < rtpHeaderSize {
+		return nil, true
+	}
+	sequenceNumber := binary.BigEndian.Uint16(raw[2:])
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.media[sequenceNumber]; ok {
+		return nil, false
+	}
+	d.addMedia(sequenceNumber, append([]byte{}, raw...))
+
+	return d.recover(), true
+}
+
+// PushFEC adds a received FEC packet and returns the packets that could be recovered with it
+func (d *Decoder) PushFEC(raw []byte) ([][]byte, error) {
+	packet := rtp.Packet{}
+	if err := packet.Unmarshal(append([]byte{}, raw...)); err != nil {
+		return nil, err
+	}
+
+	payload := packet.Payload
+	if packet.Padding && len(payload) != 0 && int(payload[len(payload)-1]) <= len(payload) {
+		payload = payload[:len(payload)-int(payload[len(payload)-1])]
+	}
+
+	f := &fecPacket{}
+	headerSize, err := f.header.unmarshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	f.payload = payload[headerSize:]
+
+	if f.header.protectedSSRC != d.ssrc || len(f.header.offsets) == 0 {
+		return nil, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.fec) == maxFECPackets {
+		d.fec = d.fec[1:]
+	}
+	d.fec = append(d.fec, f)
+
+	return d.recover(), nil
+}
+
+// addMedia keeps a media packet, dropping the oldest one once maxMediaPackets are kept.
+// Caller must hold the lock.
+func (d *Decoder) addMedia(sequenceNumber uint16, raw []byte) {
+	if len(d.mediaOrder) == maxMediaPackets {
+		delete(d.media, d.mediaOrder[0])
+		d.mediaOrder = d.mediaOrder[1:]
+	}
+	d.media[sequenceNumber] = raw
+	d.mediaOrder = append(d.mediaOrder, sequenceNumber)
+}
+
+// recover recovers every packet that is the only missing packet of a FEC packet, until no
+// more packets can be recovered. FEC packets are dropped once they have no missing packets
+// left. Caller must hold the lock.
+func (d *Decoder) recover() [][]byte {
+	var recovered [][]byte
+	for progress := true; progress; {
+		progress = false
+
+		remaining := d.fec[:0]
+		for _, f := range d.fec {
+			missing, missingCount := uint16(0), 0
+			for _, offset := range f.header.offsets {
+				if sequenceNumber := f.header.sequenceNumberBase + offset; d.media[sequenceNumber] == nil {
+					missing = sequenceNumber
+					missingCount++
+				}
+			}
+
+			switch missingCount {
+			case 0:
+				continue
+			case 1:
+				if raw := d.recoverPacket(f, missing); raw != nil {
+					d.addMedia(missing, raw)
+					recovered = append(recovered, raw)
+					progress = true
+				}
+				continue
+			}
+			remaining = append(remaining, f)
+		}
+		d.fec = remaining
+	}
+
+	return recovered
+}
+
+// recoverPacket XORs the protected packets that were received with a FEC packet to
+// recover the missing one. Caller must hold the lock.
+func (d *Decoder) recoverPacket(f *fecPacket, sequenceNumber uint16) []byte {
+	h := f.header
+	payload := append([]byte{}, f.payload...)
+	for _, offset := range f.header.offsets {
+		raw := d.media[f.header.sequenceNumberBase+offset]
+		if raw == nil {
+			continue
+		}
+		if len(raw)-rtpHeaderSize > len(payload) {
+			return nil
+		}
+		payload = h.xorPacket(raw, payload)
+	}
+
+	if int(h.lengthRecovery) > len(payload) {
+		return nil
+	}
+
+	raw := make([]byte, rtpHeaderSize+int(h.lengthRecovery))
+	raw[0] = rtpVersion2 | h.firstByteRecovery&firstByteRecoverMask
+	raw[1] = h.secondByteRecovery
+	binary.BigEndian.PutUint16(raw[2:], sequenceNumber)
+	binary.BigEndian.PutUint32(raw[4:], h.timestampRecovery)
+	binary.BigEndian.PutUint32(raw[8:], d.ssrc)
+	copy(raw[rtpHeaderSize:], payload)
+	return raw
+}
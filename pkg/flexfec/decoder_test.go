@@ -0,0 +1,149 @@
+package flexfec
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func mediaPacket(sequenceNumber uint16) *rtp.Packet {
+	payload := make([]byte, 10+int(sequenceNumber)%7)
+	for i := range payload {
+		payload[i] = byte(sequenceNumber) + byte(i)
+	}
+
+	return &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         sequenceNumber%3 == 0,
+			PayloadType:    96,
+			SequenceNumber: sequenceNumber,
+			Timestamp:      uint32(sequenceNumber) * 3000,
+			SSRC:           5000,
+		},
+		Payload: payload,
+	}
+}
+
+func marshal(t *testing.T, p *rtp.Packet) []byte {
+	raw, err := p.Marshal()
+	assert.NoError(t, err)
+	return raw
+}
+
+// encode returns the raw media and FEC packets of a group starting at firstSequenceNumber
+func encode(t *testing.T, firstSequenceNumber uint16, numMediaPackets, numFECPackets int) ([][]byte, [][]byte) {
+	e := newEncoder(5000, 6000, 118, numMediaPackets, numFECPackets)
+
+	var media, fec [][]byte
+	for i := 0; i < numMediaPackets; i++ {
+		p := mediaPacket(firstSequenceNumber + uint16(i))
+		fecPackets, err := e.push(&p.Header, p.Payload)
+		assert.NoError(t, err)
+		media = append(media, marshal(t, p))
+
+		for _, f := range fecPackets {
+			assert.Equal(t, uint32(6000), f.SSRC)
+			assert.Equal(t, uint8(118), f.PayloadType)
+			fec = append(fec, marshal(t, f))
+		}
+	}
+	return media, fec
+}
+
+func TestEncoder(t *testing.T) {
+	_, fec := encode(t, 1, 10, 2)
+	assert.Equal(t, 2, len(fec))
+
+	_, fec = encode(t, 1, 10, 0)
+	assert.Empty(t, fec)
+
+	t.Run("Sequence number gap", func(t *testing.T) {
+		e := newEncoder(5000, 6000, 118, 2, 1)
+		p := mediaPacket(1)
+		fecPackets, err := e.push(&p.Header, p.Payload)
+		assert.NoError(t, err)
+		assert.Empty(t, fecPackets)
+
+		p = mediaPacket(1 + maxProtectedPackets)
+		fecPackets, err = e.push(&p.Header, p.Payload)
+		assert.NoError(t, err)
+		assert.Empty(t, fecPackets, "group with a gap must be dropped")
+	})
+}
+
+func TestDecoder(t *testing.T) {
+	for _, test := range []struct {
+		Name          string
+		FirstSequence uint16
+		NumMedia      int
+		NumFEC        int
+		Lost          []int
+		Recovered     []int
+	}{
+		{"Single loss", 1, 10, 1, []int{4}, []int{4}},
+		{"Burst loss", 1, 10, 2, []int{4, 5}, []int{4, 5}},
+		{"Too many losses", 1, 10, 1, []int{4, 5}, nil},
+		{"Sequence number wrap", 65530, 10, 2, []int{5, 6}, []int{5, 6}},
+		{"Large group", 1, 100, 1, []int{99}, []int{99}},
+	} {
+		test := test
+		t.Run(test.Name, func(t *testing.T) {
+			media, fec := encode(t, test.FirstSequence, test.NumMedia, test.NumFEC)
+			d := NewDecoder(5000)
+
+			lost := map[int]bool{}
+			for _, i := range test.Lost {
+				lost[i] = true
+			}
+
+			var recovered [][]byte
+			for i, raw := range media {
+				if lost[i] {
+					continue
+				}
+				r, isNew := d.PushMedia(raw)
+				assert.True(t, isNew)
+				recovered = append(recovered, r...)
+			}
+			for _, raw := range fec {
+				r, err := d.PushFEC(raw)
+				assert.NoError(t, err)
+				recovered = append(recovered, r...)
+			}
+
+			assert.Equal(t, len(test.Recovered), len(recovered))
+			for _, i := range test.Recovered {
+				assert.Contains(t, recovered, media[i])
+
+				_, isNew := d.PushMedia(media[i])
+				assert.False(t, isNew, "late packet must be reported as duplicate")
+			}
+		})
+	}
+
+	t.Run("FEC before media", func(t *testing.T) {
+		media, fec := encode(t, 1, 4, 1)
+		d := NewDecoder(5000)
+
+		r, err := d.PushFEC(fec[0])
+		assert.NoError(t, err)
+		assert.Empty(t, r)
+
+		for _, raw := range media[1:] {
+			r, _ = d.PushMedia(raw)
+		}
+		assert.Equal(t, [][]byte{media[0]}, r)
+	})
+
+	t.Run("Other SSRC", func(t *testing.T) {
+		media, fec := encode(t, 1, 2, 1)
+		d := NewDecoder(5001)
+
+		_, _ = d.PushMedia(media[0])
+		r, err := d.PushFEC(fec[0])
+		assert.NoError(t, err)
+		assert.Empty(t, r)
+	})
+}
@@ -0,0 +1,111 @@
+package flexfec
+
+import (
+	"sync"
+
+	"github.com/pion/randutil"
+	"github.com/pion/rtp"
+)
+
+// encoder groups the packets of a media stream and generates the FEC packets protecting
+// every group. The FEC packets are interleaved, so a burst of up to numFECPackets lost
+// packets in a group can be recovered.
+type encoder struct {
+	mu sync.Mutex
+
+	mediaSSRC      uint32
+	fecSSRC        uint32
+	fecPayloadType uint8
+	sequenceNumber uint16
+
+	numMediaPackets int
+	numFECPackets   int
+
+	// raw packets of the current group and their sequence numbers
+	packets         [][]byte
+	sequenceNumbers []uint16
+}
+
+func newEncoder(mediaSSRC, fecSSRC uint32, fecPayloadType uint8, numMediaPackets, numFECPackets int) *encoder {
+	return &encoder{
+		mediaSSRC:       mediaSSRC,
+		fecSSRC:         fecSSRC,
+		fecPayloadType:  fecPayloadType,
+		sequenceNumber:  uint16(randutil.NewMathRandomGenerator().Uint32()),
+		numMediaPackets: numMediaPackets,
+		numFECPackets:   numFECPackets,
+	}
+}
+
+func (e *encoder) setNumFECPackets(numFECPackets int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.numFECPackets = numFECPackets
+}
+
+// push adds a media packet to the current group, and returns the FEC packets protecting
+// the group once it is complete. A group that can't be described by a single mask, because
+// sequence numbers jumped, is dropped unprotected.
+func (e *encoder) push(header *rtp.Header, payload []byte) ([]*rtp.Packet, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.sequenceNumbers) != 0 {
+		if offset := header.SequenceNumber - e.sequenceNumbers[0]; offset == 0 || offset >= maxProtectedPackets {
+			e.reset()
+		}
+	}
+
+	headerRaw, err := header.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	e.packets = append(e.packets, append(headerRaw, payload...))
+	e.sequenceNumbers = append(e.sequenceNumbers, header.SequenceNumber)
+
+	if len(e.packets) < e.numMediaPackets {
+		return nil, nil
+	}
+	defer e.reset()
+
+	fecPackets := make([]*rtp.Packet, 0, e.numFECPackets)
+	for i := 0; i < e.numFECPackets; i++ {
+		fecPackets = append(fecPackets, e.fecPacket(i, header.Timestamp))
+	}
+	return fecPackets, nil
+}
+
+// fecPacket returns the i-th FEC packet of the current group, protecting every
+// numFECPackets-th packet of the group starting with the i-th
+func (e *encoder) fecPacket(i int, timestamp uint32) *rtp.Packet {
+	h := header{
+		protectedSSRC:      e.mediaSSRC,
+		sequenceNumberBase: e.sequenceNumbers[i],
+	}
+
+	var protected []byte
+	for j := i; j < len(e.packets); j += e.numFECPackets {
+		h.offsets = append(h.offsets, e.sequenceNumbers[j]-h.sequenceNumberBase)
+		protected = h.xorPacket(e.packets[j], protected)
+	}
+
+	payload := make([]byte, h.marshalSize()+len(protected))
+	copy(payload[h.marshalTo(payload):], protected)
+
+	e.sequenceNumber++
+	return &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    e.fecPayloadType,
+			SequenceNumber: e.sequenceNumber,
+			Timestamp:      timestamp,
+			SSRC:           e.fecSSRC,
+		},
+		Payload: payload,
+	}
+}
+
+func (e *encoder) reset() {
+	e.packets = e.packets[:0]
+	e.sequenceNumbers = e.sequenceNumbers[:0]
+}
@@ -0,0 +1,13 @@
+package flexfec
+
+import (
+	"errors"
+)
+
+var (
+	errPacketTooShort       = errors.New("packet too short")
+	errUnsupportedHeader    = errors.New("only flexible masks without retransmissions are supported")
+	errUnsupportedSSRCCount = errors.New("only FEC packets protecting a single SSRC are supported")
+	errInvalidMediaPackets  = errors.New("number of media packets must be between 1 and 109")
+	errInvalidFECPackets    = errors.New("number of FEC packets can't exceed the number of media packets")
+)
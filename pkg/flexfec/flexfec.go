@@ -0,0 +1,219 @@
+// Package flexfec implements FlexFEC-03 forward error correction for RTP, as negotiated
+// by browsers with the video/flexfec-03 codec. A FEC packet carries the XOR of a group of
+// media packets, so a single lost packet of the group can be recovered without a retransmission.
+// https://tools.ietf.org/html/draft-ietf-payload-flexible-fec-scheme-03
+package flexfec
+
+import (
+	"encoding/binary"
+
+	"github.com/pion/interceptor"
+)
+
+const (
+	rtpHeaderSize = 12
+
+	headerSizeMask0 = 20
+	headerSizeMask1 = 24
+	headerSizeMask2 = 32
+
+	// maskBits are the number of packets every chunk of the mask can protect, after the K bit
+	maskBits0 = 15
+	maskBits1 = 31
+	maskBits2 = 63
+
+	// maxProtectedPackets is the largest distance a protected packet can have from the
+	// sequence number base, plus one
+	maxProtectedPackets = maskBits0 + maskBits1 + maskBits2
+
+	kBit16 = 0x8000
+	kBit32 = 0x80000000
+	kBit64 = 0x8000000000000000
+
+	rtpVersion2          = 0x80
+	firstByteRecoverMask = 0x3F
+)
+
+type attributeKey int
+
+// ProtectedSSRCKey is the interceptor.StreamInfo attributes key that marks a local stream as the
+// FEC stream protecting the media stream with the SSRC in the value, an uint32.
+const ProtectedSSRCKey attributeKey = iota
+
+// SetProtectedSSRC marks a local stream as the FEC stream protecting the media stream with
+// the given SSRC. The FEC stream has to be bound before the media stream.
+func SetProtectedSSRC(info *interceptor.StreamInfo, ssrc uint32) {
+	if info.Attributes == nil {
+		info.Attributes = interceptor.Attributes{}
+	}
+	info.Attributes.Set(ProtectedSSRCKey, ssrc)
+}
+
+// ProtectedSSRC returns the SSRC of the media stream a FEC stream protects
+func ProtectedSSRC(info *interceptor.StreamInfo) (uint32, bool) {
+	if info.Attributes == nil {
+		return 0, false
+	}
+
+	ssrc, ok := info.Attributes.Get(ProtectedSSRCKey).(uint32)
+	return ssrc, ok
+}
+
+// header is the FlexFEC-03 header of a FEC packet protecting a single SSRC with a flexible mask.
+// The recovery fields are the XOR of the same fields of the protected packets.
+type header struct {
+	firstByteRecovery  uint8
+	secondByteRecovery uint8
+	lengthRecovery     uint16
+	timestampRecovery  uint32
+	protectedSSRC      uint32
+	sequenceNumberBase uint16
+	// offsets of the protected packets from the sequence number base, in increasing order
+	offsets []uint16
+}
+
+func (h *header) marshalSize() int {
+	last := h.offsets[len(h.offsets)-1]
+	switch {
+	case last < maskBits0:
+		return headerSizeMask0
+	case last < maskBits0+maskBits1:
+		return headerSizeMask1
+	default:
+		return headerSizeMask2
+	}
+}
+
+func (h *header) marshalTo(buf []byte) int {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |0|0|P|X|  CC   |M| PT recovery |         length recovery       |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                          TS recovery                          |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |   SSRCCount   |                    reserved                   |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                             SSRC_i                            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |           SN base_i           |k|          Mask [0-14]        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |k|                   Mask [15-45] (optional)                   |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |k|                                                             |
+	 * +-+                   Mask [46-108] (optional)                  |
+	 * |                                                               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	size := h.marshalSize()
+
+	buf[0] = h.firstByteRecovery & firstByteRecoverMask
+	buf[1] = h.secondByteRecovery
+	binary.BigEndian.PutUint16(buf[2:], h.lengthRecovery)
+	binary.BigEndian.PutUint32(buf[4:], h.timestampRecovery)
+	buf[8] = 1
+	buf[9], buf[10], buf[11] = 0, 0, 0
+	binary.BigEndian.PutUint32(buf[12:], h.protectedSSRC)
+	binary.BigEndian.PutUint16(buf[16:], h.sequenceNumberBase)
+
+	var mask0 uint16
+	var mask1 uint32
+	var mask2 uint64
+	for _, offset := range h.offsets {
+		switch {
+		case offset < maskBits0:
+			mask0 |= 1 << (maskBits0 - 1 - offset)
+		case offset < maskBits0+maskBits1:
+			mask1 |= 1 << (maskBits1 - 1 - (offset - maskBits0))
+		default:
+			mask2 |= 1 << (maskBits2 - 1 - (offset - maskBits0 - maskBits1))
+		}
+	}
+
+	switch size {
+	case headerSizeMask0:
+		binary.BigEndian.PutUint16(buf[18:], kBit16|mask0)
+	case headerSizeMask1:
+		binary.BigEndian.PutUint16(buf[18:], mask0)
+		binary.BigEndian.PutUint32(buf[20:], kBit32|mask1)
+	default:
+		binary.BigEndian.PutUint16(buf[18:], mask0)
+		binary.BigEndian.PutUint32(buf[20:], mask1)
+		binary.BigEndian.PutUint64(buf[24:], kBit64|mask2)
+	}
+
+	return size
+}
+
+// unmarshal parses the header at the start of a FEC payload and returns its size
+func (h *header) unmarshal(buf []byte) (int, error) {
+	if len(buf) < headerSizeMask0 {
+		return 0, errPacketTooShort
+	}
+	if buf[0]&^firstByteRecoverMask != 0 {
+		return 0, errUnsupportedHeader
+	}
+	if buf[8] != 1 {
+		return 0, errUnsupportedSSRCCount
+	}
+
+	h.firstByteRecovery = buf[0]
+	h.secondByteRecovery = buf[1]
+	h.lengthRecovery = binary.BigEndian.Uint16(buf[2:])
+	h.timestampRecovery = binary.BigEndian.Uint32(buf[4:])
+	h.protectedSSRC = binary.BigEndian.Uint32(buf[12:])
+	h.sequenceNumberBase = binary.BigEndian.Uint16(buf[16:])
+	h.offsets = h.offsets[:0]
+
+	mask0 := binary.BigEndian.Uint16(buf[18:])
+	for i := uint16(0); i < maskBits0; i++ {
+		if mask0&(1<<(maskBits0-1-i)) != 0 {
+			h.offsets = append(h.offsets, i)
+		}
+	}
+	if mask0&kBit16 != 0 {
+		return headerSizeMask0, nil
+	}
+
+	if len(buf) < headerSizeMask1 {
+		return 0, errPacketTooShort
+	}
+	mask1 := binary.BigEndian.Uint32(buf[20:])
+	for i := uint16(0); i < maskBits1; i++ {
+		if mask1&(1<<(maskBits1-1-i)) != 0 {
+			h.offsets = append(h.offsets, maskBits0+i)
+		}
+	}
+	if mask1&kBit32 != 0 {
+		return headerSizeMask1, nil
+	}
+
+	if len(buf) < headerSizeMask2 {
+		return 0, errPacketTooShort
+	}
+	mask2 := binary.BigEndian.Uint64(buf[24:])
+	for i := uint16(0); i < maskBits2; i++ {
+		if mask2&(1<<(maskBits2-1-i)) != 0 {
+			h.offsets = append(h.offsets, maskBits0+maskBits1+i)
+		}
+	}
+	return headerSizeMask2, nil
+}
+
+// xorPacket adds a protected media packet to the recovery fields of h and to the
+// protected payload, which is grown to the size of the media packet payload as needed
+func (h *header) xorPacket(raw []byte, payload []byte) []byte {
+	h.firstByteRecovery ^= raw[0]
+	h.secondByteRecovery ^= raw[1]
+	h.lengthRecovery ^= uint16(len(raw) - rtpHeaderSize)
+	h.timestampRecovery ^= binary.BigEndian.Uint32(raw[4:])
+
+	for len(payload) < len(raw)-rtpHeaderSize {
+		payload = append(payload, 0)
+	}
+	for i, b := range raw[rtpHeaderSize:] {
+		payload[i] ^= b
+	}
+	return payload
+}
@@ -0,0 +1,67 @@
+package flexfec
+
+import (
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeader(t *testing.T) {
+	for _, test := range []struct {
+		Name    string
+		Offsets []uint16
+		Size    int
+	}{
+		{"First mask", []uint16{0, 2, 14}, headerSizeMask0},
+		{"Second mask", []uint16{0, 15, 45}, headerSizeMask1},
+		{"Third mask", []uint16{0, 46, 108}, headerSizeMask2},
+	} {
+		test := test
+		t.Run(test.Name, func(t *testing.T) {
+			h := header{
+				firstByteRecovery:  0x12,
+				secondByteRecovery: 0xE0,
+				lengthRecovery:     0x1234,
+				timestampRecovery:  0x56789ABC,
+				protectedSSRC:      0xDEADBEEF,
+				sequenceNumberBase: 65530,
+				offsets:            test.Offsets,
+			}
+
+			buf := make([]byte, h.marshalSize()+1)
+			assert.Equal(t, test.Size, h.marshalTo(buf))
+
+			parsed := header{}
+			size, err := parsed.unmarshal(buf)
+			assert.NoError(t, err)
+			assert.Equal(t, test.Size, size)
+			assert.Equal(t, h, parsed)
+
+			_, err = parsed.unmarshal(buf[:test.Size-1])
+			assert.Equal(t, errPacketTooShort, err)
+		})
+	}
+
+	t.Run("First byte", func(t *testing.T) {
+		h := header{firstByteRecovery: 0xBF, offsets: []uint16{0}}
+		buf := make([]byte, h.marshalSize())
+		h.marshalTo(buf)
+		assert.Equal(t, byte(0x3F), buf[0], "R and F bits must be cleared")
+
+		buf[0] |= 0x40
+		_, err := h.unmarshal(buf)
+		assert.Equal(t, errUnsupportedHeader, err)
+	})
+}
+
+func TestProtectedSSRC(t *testing.T) {
+	info := &interceptor.StreamInfo{}
+	_, ok := ProtectedSSRC(info)
+	assert.False(t, ok)
+
+	SetProtectedSSRC(info, 1234)
+	ssrc, ok := ProtectedSSRC(info)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1234), ssrc)
+}
@@ -0,0 +1,168 @@
+package flexfec
+
+import (
+	"sync"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/logging"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// fractionLostDenominator is the denominator of the fraction lost of a RTCP reception report
+const fractionLostDenominator = 256
+
+// Interceptor generates FlexFEC-03 packets for the local media streams that have a FEC stream.
+//
+// The FEC stream is a local stream of its own, marked with SetProtectedSSRC and bound before
+// the media stream it protects. FEC packets are written to the FEC stream, so the interceptors
+// that run after this one see them with the SSRC and payload type of the FEC stream.
+type Interceptor struct {
+	interceptor.NoOp
+	numMediaPackets int
+	numFECPackets   int
+	adaptive        bool
+	log             logging.LeveledLogger
+
+	m          sync.Mutex
+	fecStreams map[uint32]fecStream
+	encoders   map[uint32]*encoder
+}
+
+// fecStream is a bound FEC stream, keyed by the SSRC it protects
+type fecStream struct {
+	ssrc        uint32
+	payloadType uint8
+	writer      interceptor.RTPWriter
+}
+
+// NewInterceptor returns a new Interceptor. By default every group of 10 media packets
+// is protected by 2 FEC packets.
+func NewInterceptor(opts ...Option) (*Interceptor, error) {
+	i := &Interceptor{
+		numMediaPackets: 10,
+		numFECPackets:   2,
+		log:             logging.NewDefaultLoggerFactory().NewLogger("flexfec_interceptor"),
+		fecStreams:      map[uint32]fecStream{},
+		encoders:        map[uint32]*encoder{},
+	}
+
+	for _, opt := range opts {
+		if err := opt(i); err != nil {
+			return nil, err
+		}
+	}
+
+	if i.numFECPackets < 0 || i.numFECPackets > i.numMediaPackets {
+		return nil, errInvalidFECPackets
+	}
+
+	return i, nil
+}
+
+// BindRTCPReader lets you modify any incoming RTCP packets. It is called once per sender/receiver, however this might
+// change in the future. The returned method will be called once per packet batch.
+func (i *Interceptor) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	if !i.adaptive {
+		return reader
+	}
+
+	return interceptor.RTCPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, attr, err := reader.Read(b, a)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		pkts, err := rtcp.Unmarshal(b[:n])
+		if err != nil {
+			return 0, nil, err
+		}
+
+		for _, pkt := range pkts {
+			switch report := pkt.(type) {
+			case *rtcp.ReceiverReport:
+				i.adapt(report.Reports)
+			case *rtcp.SenderReport:
+				i.adapt(report.Reports)
+			}
+		}
+
+		return n, attr, nil
+	})
+}
+
+// adapt sets the number of FEC packets of the reported streams to twice the number
+// of packets of a group that are expected to be lost
+func (i *Interceptor) adapt(reports []rtcp.ReceptionReport) {
+	i.m.Lock()
+	defer i.m.Unlock()
+
+	for _, report := range reports {
+		e, ok := i.encoders[report.SSRC]
+		if !ok {
+			continue
+		}
+
+		lost := 2 * int(report.FractionLost) * i.numMediaPackets
+		numFECPackets := (lost + fractionLostDenominator - 1) / fractionLostDenominator
+		if numFECPackets < i.numFECPackets {
+			numFECPackets = i.numFECPackets
+		}
+		if numFECPackets > i.numMediaPackets {
+			numFECPackets = i.numMediaPackets
+		}
+		e.setNumFECPackets(numFECPackets)
+	}
+}
+
+// BindLocalStream lets you modify any outgoing RTP packets. It is called once for per LocalStream. The returned method
+// will be called once per rtp packet.
+func (i *Interceptor) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	i.m.Lock()
+	defer i.m.Unlock()
+
+	if protectedSSRC, ok := ProtectedSSRC(info); ok {
+		i.fecStreams[protectedSSRC] = fecStream{ssrc: info.SSRC, payloadType: info.PayloadType, writer: writer}
+		return writer
+	}
+
+	fec, ok := i.fecStreams[info.SSRC]
+	if !ok {
+		return writer
+	}
+
+	e := newEncoder(info.SSRC, fec.ssrc, fec.payloadType, i.numMediaPackets, i.numFECPackets)
+	i.encoders[info.SSRC] = e
+
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, a interceptor.Attributes) (int, error) {
+		fecPackets, err := e.push(header, payload)
+		if err != nil {
+			i.log.Warnf("failed protecting packet: %+v", err)
+		}
+
+		n, err := writer.Write(header, payload, a)
+		if err != nil {
+			return n, err
+		}
+
+		for _, p := range fecPackets {
+			if _, err := fec.writer.Write(&p.Header, p.Payload, interceptor.Attributes{}); err != nil {
+				i.log.Warnf("failed sending FEC packet: %+v", err)
+			}
+		}
+
+		return n, nil
+	})
+}
+
+// UnbindLocalStream is called when the Stream is removed. It can be used to clean up any data related to that track.
+func (i *Interceptor) UnbindLocalStream(info *interceptor.StreamInfo) {
+	i.m.Lock()
+	defer i.m.Unlock()
+
+	if protectedSSRC, ok := ProtectedSSRC(info); ok {
+		delete(i.fecStreams, protectedSSRC)
+		return
+	}
+	delete(i.encoders, info.SSRC)
+}
@@ -0,0 +1,111 @@
+package flexfec
+
+import (
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+// bindMockStreams binds a FEC stream protecting SSRC 5000 and the media stream to i, and returns
+// the writer of the media stream together with the FEC packets written for it
+func bindMockStreams(i *Interceptor) (interceptor.RTPWriter, *[]rtp.Header) {
+	fecInfo := &interceptor.StreamInfo{SSRC: 6000, PayloadType: 118}
+	SetProtectedSSRC(fecInfo, 5000)
+
+	fecHeaders := &[]rtp.Header{}
+	i.BindLocalStream(fecInfo, interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+		*fecHeaders = append(*fecHeaders, *header)
+		return len(payload), nil
+	}))
+
+	writer := i.BindLocalStream(&interceptor.StreamInfo{SSRC: 5000, PayloadType: 96}, interceptor.RTPWriterFunc(func(_ *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+		return len(payload), nil
+	}))
+	return writer, fecHeaders
+}
+
+func writeGroup(t *testing.T, writer interceptor.RTPWriter, firstSequenceNumber uint16, numMediaPackets int) {
+	for j := 0; j < numMediaPackets; j++ {
+		p := mediaPacket(firstSequenceNumber + uint16(j))
+		_, err := writer.Write(&p.Header, p.Payload, interceptor.Attributes{})
+		assert.NoError(t, err)
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	_, err := NewInterceptor(NumMediaPackets(0))
+	assert.Equal(t, errInvalidMediaPackets, err)
+
+	_, err = NewInterceptor(NumMediaPackets(2), NumFECPackets(3))
+	assert.Equal(t, errInvalidFECPackets, err)
+
+	i, err := NewInterceptor(NumMediaPackets(4), NumFECPackets(1))
+	assert.NoError(t, err)
+
+	writer, fecHeaders := bindMockStreams(i)
+	writeGroup(t, writer, 1, 8)
+	assert.Equal(t, 2, len(*fecHeaders))
+	for _, h := range *fecHeaders {
+		assert.Equal(t, uint32(6000), h.SSRC)
+		assert.Equal(t, uint8(118), h.PayloadType)
+	}
+	assert.Equal(t, (*fecHeaders)[0].SequenceNumber+1, (*fecHeaders)[1].SequenceNumber)
+
+	t.Run("Unprotected stream", func(t *testing.T) {
+		p := mediaPacket(1)
+		written := false
+		writer := i.BindLocalStream(&interceptor.StreamInfo{SSRC: 7000}, interceptor.RTPWriterFunc(func(_ *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+			written = true
+			return len(payload), nil
+		}))
+		_, err := writer.Write(&p.Header, p.Payload, interceptor.Attributes{})
+		assert.NoError(t, err)
+		assert.True(t, written)
+	})
+
+	i.UnbindLocalStream(&interceptor.StreamInfo{SSRC: 5000})
+	assert.Empty(t, i.encoders)
+}
+
+func TestInterceptorAdaptToLoss(t *testing.T) {
+	i, err := NewInterceptor(NumMediaPackets(10), NumFECPackets(1), AdaptToLoss())
+	assert.NoError(t, err)
+
+	writer, fecHeaders := bindMockStreams(i)
+
+	var rtcpRaw []byte
+	reader := i.BindRTCPReader(interceptor.RTCPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		return copy(b, rtcpRaw), a, nil
+	}))
+	receiveReport := func(fractionLost uint8) {
+		rtcpRaw, err = (&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{SSRC: 5000, FractionLost: fractionLost}}}).Marshal()
+		assert.NoError(t, err)
+
+		_, _, err = reader.Read(make([]byte, 1500), interceptor.Attributes{})
+		assert.NoError(t, err)
+	}
+
+	writeGroup(t, writer, 1, 10)
+	assert.Equal(t, 1, len(*fecHeaders))
+
+	// 20% loss of 10 packets needs 4 FEC packets
+	receiveReport(51)
+	*fecHeaders = (*fecHeaders)[:0]
+	writeGroup(t, writer, 11, 10)
+	assert.Equal(t, 4, len(*fecHeaders))
+
+	// Never more FEC packets than media packets
+	receiveReport(255)
+	*fecHeaders = (*fecHeaders)[:0]
+	writeGroup(t, writer, 21, 10)
+	assert.Equal(t, 10, len(*fecHeaders))
+
+	// Never less than the configured number of FEC packets
+	receiveReport(0)
+	*fecHeaders = (*fecHeaders)[:0]
+	writeGroup(t, writer, 31, 10)
+	assert.Equal(t, 1, len(*fecHeaders))
+}
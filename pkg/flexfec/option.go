@@ -0,0 +1,49 @@
+package flexfec
+
+import (
+	"github.com/pion/logging"
+)
+
+// Option can be used to configure the Interceptor.
+type Option func(i *Interceptor) error
+
+// Log sets a logger for the interceptor.
+func Log(log logging.LeveledLogger) Option {
+	return func(i *Interceptor) error {
+		i.log = log
+		return nil
+	}
+}
+
+// NumMediaPackets sets the number of media packets protected together, at most 109.
+// Larger groups have less overhead, but lost packets are recovered later.
+func NumMediaPackets(numMediaPackets int) Option {
+	return func(i *Interceptor) error {
+		if numMediaPackets < 1 || numMediaPackets > maxProtectedPackets {
+			return errInvalidMediaPackets
+		}
+		i.numMediaPackets = numMediaPackets
+		return nil
+	}
+}
+
+// NumFECPackets sets the number of FEC packets sent for every group of media packets.
+// Every FEC packet can recover one lost packet of the group. With AdaptToLoss this is
+// the minimum number of FEC packets.
+func NumFECPackets(numFECPackets int) Option {
+	return func(i *Interceptor) error {
+		i.numFECPackets = numFECPackets
+		return nil
+	}
+}
+
+// AdaptToLoss adjusts the number of FEC packets of every stream to the fraction of packets
+// lost in the latest Receiver Report of the remote peer, sending twice as many FEC packets
+// as packets are expected to be lost. Receiver Reports are only seen while RTCP is read
+// from the RTPSender.
+func AdaptToLoss() Option {
+	return func(i *Interceptor) error {
+		i.adaptive = true
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+// Package h265writer implements H265 media container writer
+package h265writer
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3/pkg/rtpcodecs"
+)
+
+const (
+	naluTypeMask  = 0x7E
+	naluTypeShift = 1
+	naluTypeVPS   = 32
+	naluTypeAP    = 48
+
+	naluHeaderSize     = 2
+	apNALUSizeLength   = 2
+	apFirstNALUTypeIdx = naluHeaderSize + apNALUSizeLength
+)
+
+type (
+	// H265Writer is used to take RTP packets, parse them and
+	// write the data to an io.Writer.
+	// Only streams without decoding order numbers are supported,
+	// therefore single NAL unit packets, 48 (AP) and 49 (FU) are allowed.
+	// https://tools.ietf.org/html/rfc7798#section-4.4
+	H265Writer struct {
+		writer       io.Writer
+		hasKeyFrame  bool
+		cachedPacket *rtpcodecs.H265Packet
+	}
+)
+
+// New builds a new H265 writer
+func New(filename string) (*H265Writer, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWith(f), nil
+}
+
+// NewWith initializes a new H265 writer with an io.Writer output
+func NewWith(w io.Writer) *H265Writer {
+	return &H265Writer{
+		writer: w,
+	}
+}
+
+// WriteRTP adds a new packet and writes the appropriate headers for it
+func (h *H265Writer) WriteRTP(packet *rtp.Packet) error {
+	if len(packet.Payload) == 0 {
+		return nil
+	}
+
+	if !h.hasKeyFrame {
+		if h.hasKeyFrame = isKeyFrame(packet.Payload); !h.hasKeyFrame {
+			// key frame not defined yet. discarding packet
+			return nil
+		}
+	}
+
+	if h.cachedPacket == nil {
+		h.cachedPacket = &rtpcodecs.H265Packet{}
+	}
+
+	data, err := h.cachedPacket.Unmarshal(packet.Payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.writer.Write(data)
+
+	return err
+}
+
+// Close closes the underlying writer
+func (h *H265Writer) Close() error {
+	h.cachedPacket = nil
+	if h.writer != nil {
+		if closer, ok := h.writer.(io.Closer); ok {
+			return closer.Close()
+		}
+	}
+
+	return nil
+}
+
+// isKeyFrame reports if the payload starts with a VPS, either as single NAL
+// unit or as first NAL unit of an AP, so the stream can be decoded from it
+func isKeyFrame(data []byte) bool {
+	if len(data) < naluHeaderSize {
+		return false
+	}
+
+	switch (data[0] & naluTypeMask) >> naluTypeShift {
+	case naluTypeVPS:
+		return true
+	case naluTypeAP:
+		if len(data) <= apFirstNALUTypeIdx || binary.BigEndian.Uint16(data[naluHeaderSize:]) == 0 {
+			return false
+		}
+		return (data[apFirstNALUTypeIdx]&naluTypeMask)>>naluTypeShift == naluTypeVPS
+	default:
+		return false
+	}
+}
@@ -0,0 +1,159 @@
+package h265writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+type writerCloser struct {
+	bytes.Buffer
+}
+
+var errCloseErr = errors.New("close error")
+
+func (w *writerCloser) Close() error {
+	return errCloseErr
+}
+
+func TestNewWith(t *testing.T) {
+	writer := &writerCloser{}
+	h265Writer := NewWith(writer)
+	assert.NotNil(t, h265Writer.Close())
+}
+
+func TestIsKeyFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    bool
+	}{
+		{
+			"When given a non-keyframe; it should return false",
+			[]byte{0x02, 0x01, 0x90},
+			false,
+		},
+		{
+			"When given a VPS; it should return true",
+			[]byte{0x40, 0x01, 0x0C},
+			true,
+		},
+		{
+			"When given an AP starting with a VPS; it should return true",
+			[]byte{0x60, 0x01, 0x00, 0x03, 0x40, 0x01, 0x0C, 0x00, 0x03, 0x42, 0x01, 0x01},
+			true,
+		},
+		{
+			"When given an AP without VPS; it should return false",
+			[]byte{0x60, 0x01, 0x00, 0x03, 0x02, 0x01, 0x90},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := isKeyFrame(tt.payload)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWriteRTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     []byte
+		hasKeyFrame bool
+		wantBytes   []byte
+		wantErr     error
+		reuseWriter bool
+	}{
+		{
+			"When given an empty payload; it should return nil",
+			[]byte{},
+			false,
+			[]byte{},
+			nil,
+			false,
+		},
+		{
+			"When no keyframe is defined; it should discard the packet",
+			[]byte{0x02, 0x01, 0x90},
+			false,
+			[]byte{},
+			nil,
+			false,
+		},
+		{
+			"When a valid Single NAL Unit packet is given; it should unpack it without error",
+			[]byte{0x02, 0x01, 0x90},
+			true,
+			[]byte{0x00, 0x00, 0x00, 0x01, 0x02, 0x01, 0x90},
+			nil,
+			false,
+		},
+		{
+			"When a valid AP packet is given; it should unpack it without error",
+			[]byte{0x60, 0x01, 0x00, 0x03, 0x40, 0x01, 0x0C, 0x00, 0x03, 0x42, 0x01, 0x01},
+			false,
+			[]byte{0x00, 0x00, 0x00, 0x01, 0x40, 0x01, 0x0C, 0x00, 0x00, 0x00, 0x01, 0x42, 0x01, 0x01},
+			nil,
+			false,
+		},
+		{
+			"When a valid FU start packet is given; it should unpack it without error",
+			[]byte{0x62, 0x01, 0x93, 0x90, 0x90},
+			true,
+			[]byte{},
+			nil,
+			true,
+		},
+		{
+			"When a valid FU end packet is given; it should unpack it without error",
+			[]byte{0x62, 0x01, 0x53, 0x90, 0x90},
+			true,
+			[]byte{0x00, 0x00, 0x00, 0x01, 0x26, 0x01, 0x90, 0x90, 0x90, 0x90},
+			nil,
+			false,
+		},
+	}
+
+	var reuseWriter *bytes.Buffer
+	var reuseH265Writer *H265Writer
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &bytes.Buffer{}
+			h265Writer := &H265Writer{
+				hasKeyFrame: tt.hasKeyFrame,
+				writer:      writer,
+			}
+			if reuseWriter != nil {
+				writer = reuseWriter
+			}
+			if reuseH265Writer != nil {
+				h265Writer = reuseH265Writer
+			}
+			packet := &rtp.Packet{
+				Payload: tt.payload,
+			}
+
+			err := h265Writer.WriteRTP(packet)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.True(t, bytes.Equal(tt.wantBytes, writer.Bytes()))
+			if !tt.reuseWriter {
+				assert.Nil(t, h265Writer.Close())
+				reuseWriter = nil
+				reuseH265Writer = nil
+			} else {
+				reuseWriter = writer
+				reuseH265Writer = h265Writer
+			}
+		})
+	}
+}
@@ -165,3 +165,28 @@ func TestIVFReader_EOFWhenNoFramesLeft(t *testing.T) {
 
 	assert.Equal(io.EOF, err)
 }
+
+func TestIVFReader_ParseAV1(t *testing.T) {
+	assert := assert.New(t)
+
+	// Frame Length - 7
+	// Timestamp - None
+	// Frame Payload - Temporal delimiter and a frame OBU
+	temporalUnit := []byte{
+		0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x12, 0x00, 0x32, 0x03,
+		0x01, 0x02, 0x03,
+	}
+
+	ivf := buildIVFContainer(&temporalUnit)
+	copy(ivf.Bytes()[8:12], "AV01")
+
+	reader, header, err := NewWith(ivf)
+	assert.Nil(err, "IVFReader should be created")
+	assert.Equal("AV01", header.FourCC, "FourCC should be 'AV01'")
+
+	payload, frameHeader, err := reader.ParseNextFrame()
+	assert.Nil(err, "Should have parsed the temporal unit without error")
+	assert.Equal(uint32(7), frameHeader.FrameSize, "Frame header frameSize should be 7")
+	assert.Equal(temporalUnit[ivfFrameHeaderSize:], payload)
+}
@@ -6,14 +6,28 @@ import (
 	"errors"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/pion/rtp"
 	"github.com/pion/rtp/codecs"
+	"github.com/pion/webrtc/v3/pkg/rtpcodecs"
 )
 
 var (
 	errFileNotOpened    = errors.New("file not opened")
 	errInvalidNilPacket = errors.New("invalid nil packet")
+	errCodecUnsupported = errors.New("codec is not supported by IVF")
+)
+
+const (
+	mimeTypeVP8 = "video/VP8"
+	mimeTypeAV1 = "video/AV1"
+
+	fourCCVP8 = "VP80"
+	fourCCAV1 = "AV01"
+
+	// av1TemporalDelimiterOBU is the header of a temporal delimiter OBU with size field
+	av1TemporalDelimiterOBU = 0x12
 )
 
 // IVFWriter is used to take RTP packets and write them to an IVF on disk
@@ -22,15 +36,37 @@ type IVFWriter struct {
 	count        uint64
 	seenKeyFrame bool
 	currentFrame []byte
+
+	isAV1     bool
+	av1Packet rtpcodecs.AV1Packet
+}
+
+// An Option configures an IVFWriter.
+type Option func(i *IVFWriter) error
+
+// WithCodec sets the codec of the RTP packets that are written. Supported are
+// VP8, which is the default, and AV1.
+func WithCodec(mimeType string) Option {
+	return func(i *IVFWriter) error {
+		switch {
+		case strings.EqualFold(mimeType, mimeTypeVP8):
+			i.isAV1 = false
+		case strings.EqualFold(mimeType, mimeTypeAV1):
+			i.isAV1 = true
+		default:
+			return errCodecUnsupported
+		}
+		return nil
+	}
 }
 
 // New builds a new IVF writer
-func New(fileName string) (*IVFWriter, error) {
+func New(fileName string, opts ...Option) (*IVFWriter, error) {
 	f, err := os.Create(fileName)
 	if err != nil {
 		return nil, err
 	}
-	writer, err := NewWith(f)
+	writer, err := NewWith(f, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +75,7 @@ func New(fileName string) (*IVFWriter, error) {
 }
 
 // NewWith initialize a new IVF writer with an io.Writer output
-func NewWith(out io.Writer) (*IVFWriter, error) {
+func NewWith(out io.Writer, opts ...Option) (*IVFWriter, error) {
 	if out == nil {
 		return nil, errFileNotOpened
 	}
@@ -48,6 +84,11 @@ func NewWith(out io.Writer) (*IVFWriter, error) {
 		ioWriter:     out,
 		seenKeyFrame: false,
 	}
+	for _, o := range opts {
+		if err := o(writer); err != nil {
+			return nil, err
+		}
+	}
 	if err := writer.writeHeader(); err != nil {
 		return nil, err
 	}
@@ -59,7 +100,7 @@ func (i *IVFWriter) writeHeader() error {
 	copy(header[0:], "DKIF")                        // DKIF
 	binary.LittleEndian.PutUint16(header[4:], 0)    // Version
 	binary.LittleEndian.PutUint16(header[6:], 32)   // Header size
-	copy(header[8:], "VP80")                        // FOURCC
+	copy(header[8:], i.fourCC())                    // FOURCC
 	binary.LittleEndian.PutUint16(header[12:], 640) // Width in pixels
 	binary.LittleEndian.PutUint16(header[14:], 480) // Height in pixels
 	binary.LittleEndian.PutUint32(header[16:], 30)  // Framerate denominator
@@ -71,12 +112,23 @@ func (i *IVFWriter) writeHeader() error {
 	return err
 }
 
+func (i *IVFWriter) fourCC() string {
+	if i.isAV1 {
+		return fourCCAV1
+	}
+	return fourCCVP8
+}
+
 // WriteRTP adds a new packet and writes the appropriate headers for it
 func (i *IVFWriter) WriteRTP(packet *rtp.Packet) error {
 	if i.ioWriter == nil {
 		return errFileNotOpened
 	}
 
+	if i.isAV1 {
+		return i.writeAV1(packet)
+	}
+
 	vp8Packet := codecs.VP8Packet{}
 	if _, err := vp8Packet.Unmarshal(packet.Payload); err != nil {
 		return err
@@ -99,6 +151,36 @@ func (i *IVFWriter) WriteRTP(packet *rtp.Packet) error {
 		return nil
 	}
 
+	return i.writeFrame()
+}
+
+// writeAV1 depacketizes an AV1 packet. Every temporal unit is written as one frame
+// in the low overhead bitstream format, starting with a temporal delimiter.
+func (i *IVFWriter) writeAV1(packet *rtp.Packet) error {
+	obus, err := i.av1Packet.Unmarshal(packet.Payload)
+	if err != nil {
+		return err
+	}
+
+	if !i.seenKeyFrame {
+		if i.av1Packet.Z || !i.av1Packet.N {
+			return nil
+		}
+		i.seenKeyFrame = true
+	}
+
+	if i.currentFrame == nil {
+		i.currentFrame = []byte{av1TemporalDelimiterOBU, 0x00}
+	}
+	i.currentFrame = append(i.currentFrame, obus...)
+
+	if !packet.Marker {
+		return nil
+	}
+	return i.writeFrame()
+}
+
+func (i *IVFWriter) writeFrame() error {
 	frameHeader := make([]byte, 12)
 	binary.LittleEndian.PutUint32(frameHeader[0:], uint32(len(i.currentFrame))) // Frame length
 	binary.LittleEndian.PutUint64(frameHeader[4:], i.count)                     // PTS
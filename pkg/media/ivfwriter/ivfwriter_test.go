@@ -230,3 +230,29 @@ func TestIVFWriter_AddPacketAndClose(t *testing.T) {
 		}
 	}
 }
+
+func TestIVFWriter_AV1(t *testing.T) {
+	t.Run("Unsupported codec", func(t *testing.T) {
+		_, err := NewWith(&bytes.Buffer{}, WithCodec("video/H264"))
+		assert.ErrorIs(t, err, errCodecUnsupported)
+	})
+
+	t.Run("Fragmented temporal unit", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		writer, err := NewWith(buffer, WithCodec(mimeTypeAV1))
+		assert.NoError(t, err)
+		assert.Equal(t, fourCCAV1, string(buffer.Bytes()[8:12]))
+
+		// Dropped, no keyframe was seen yet
+		assert.NoError(t, writer.WriteRTP(&rtp.Packet{Header: rtp.Header{Marker: true}, Payload: []byte{0x00, 0x02, 0x30, 0x01}}))
+
+		assert.NoError(t, writer.WriteRTP(&rtp.Packet{Payload: []byte{0x48, 0x03, 0x08, 0x01, 0x02, 0x02, 0x30, 0x04}}))
+		assert.NoError(t, writer.WriteRTP(&rtp.Packet{Header: rtp.Header{Marker: true}, Payload: []byte{0x80, 0x02, 0x05, 0x06}}))
+		assert.NoError(t, writer.Close())
+
+		assert.Equal(t, []byte{
+			0x0B, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x12, 0x00, 0x0A, 0x02, 0x01, 0x02, 0x32, 0x03, 0x04, 0x05, 0x06,
+		}, buffer.Bytes()[32:])
+	})
+}
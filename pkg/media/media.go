@@ -2,6 +2,7 @@
 package media
 
 import (
+	"math"
 	"time"
 
 	"github.com/pion/rtp"
@@ -14,6 +15,27 @@ type Sample struct {
 	Duration           time.Duration
 	PacketTimestamp    uint32
 	PrevDroppedPackets uint16
+
+	// AudioLevel is sent in the RFC 6464 ssrc-audio-level header extension of
+	// every packet of the sample, if the extension was negotiated
+	AudioLevel *AudioLevel
+}
+
+// AudioLevel is the level of audio as described in RFC 6464
+type AudioLevel struct {
+	// Level is the attenuation in -dBov from 0 (loudest) to 127 (silence)
+	Level uint8
+	// Voice reports if the audio contains speech
+	Voice bool
+}
+
+// Linear converts Level into a linear value from 0 (silence) to 1 (loudest),
+// the way RTCRtpSynchronizationSource.audioLevel is defined in WebRTC
+func (a AudioLevel) Linear() float64 {
+	if a.Level >= 127 {
+		return 0
+	}
+	return math.Pow(10, -float64(a.Level)/20)
 }
 
 // Writer defines an interface to handle
@@ -40,6 +40,9 @@ type SampleBuilder struct {
 
 	// number of packets forced to be dropped
 	droppedPackets uint16
+
+	// converts the RTP timestamp of a sample into its wall clock time
+	rtpTimestampToTime func(uint32) (time.Time, bool)
 }
 
 // New constructs a new SampleBuilder.
@@ -47,7 +50,8 @@ type SampleBuilder struct {
 // maxLate is measured in RTP packet sequence numbers.
 // A large maxLate will result in less packet loss but higher latency.
 // The depacketizer extracts media samples from RTP packets.
-// Several depacketizers are available in package github.com/pion/rtp/codecs.
+// Several depacketizers are available in package github.com/pion/rtp/codecs,
+// and github.com/pion/webrtc/v3/pkg/rtpcodecs provides ones for AV1 and H265.
 func New(maxLate uint16, depacketizer rtp.Depacketizer, sampleRate uint32, opts ...Option) *SampleBuilder {
 	s := &SampleBuilder{maxLate: maxLate, depacketizer: depacketizer, sampleRate: sampleRate}
 	for _, o := range opts {
@@ -273,6 +277,12 @@ func (s *SampleBuilder) buildSample(purgingBuffers bool) *media.Sample {
 
 	s.droppedPackets = 0
 
+	if s.rtpTimestampToTime != nil {
+		if timestamp, ok := s.rtpTimestampToTime(sampleTimestamp); ok {
+			sample.Timestamp = timestamp
+		}
+	}
+
 	s.preparedSamples[s.prepared.tail] = sample
 	s.prepared.tail++
 
@@ -327,7 +337,8 @@ func timestampDistance(x, y uint32) uint32 {
 type Option func(o *SampleBuilder)
 
 // WithPartitionHeadChecker assigns a codec-specific PartitionHeadChecker to SampleBuilder.
-// Several PartitionHeadCheckers are available in package github.com/pion/rtp/codecs.
+// Several PartitionHeadCheckers are available in package github.com/pion/rtp/codecs,
+// and github.com/pion/webrtc/v3/pkg/rtpcodecs provides ones for AV1 and H265.
 func WithPartitionHeadChecker(checker rtp.PartitionHeadChecker) Option {
 	return func(o *SampleBuilder) {
 		o.partitionHeadChecker = checker
@@ -350,3 +361,14 @@ func WithMaxTimeDelay(maxLateDuration time.Duration) Option {
 		o.maxLateTimestamp = uint32(int64(o.sampleRate) * totalMillis / 1000)
 	}
 }
+
+// WithRTPTimestampToTime sets a function that converts the RTP timestamp of a sample
+// into wall clock time, which is stored in the Timestamp of the built media.Sample.
+// Pass TrackRemote.SenderTime to stamp samples with the time of the sender, which
+// synchronizes the samples of tracks with the same StreamID. The Timestamp is left
+// unset while the function returns false.
+func WithRTPTimestampToTime(f func(rtpTimestamp uint32) (time.Time, bool)) Option {
+	return func(o *SampleBuilder) {
+		o.rtpTimestampToTime = f
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/pion/webrtc/v3/pkg/rtpcodecs"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -379,3 +380,67 @@ func TestSampleBuilderWithPacketReleaseHandler(t *testing.T) {
 		t.Errorf("Unexpected packet released by samples built")
 	}
 }
+
+func TestSampleBuilderAV1(t *testing.T) {
+	payloader := &rtpcodecs.AV1Payloader{}
+	sequenceHeader := []byte{0x0A, 0x03, 0x01, 0x02, 0x03}
+	frame := append([]byte{0x32, 0x20}, make([]byte, 32)...)
+	temporalUnit := append(append([]byte{}, sequenceHeader...), frame...)
+
+	s := New(10, &rtpcodecs.AV1Packet{}, 90000, WithPartitionHeadChecker(&rtpcodecs.AV1PartitionHeadChecker{}))
+
+	var sequenceNumber uint16
+	push := func(timestamp uint32, skipFirst bool) {
+		payloads := payloader.Payload(20, temporalUnit)
+		for i, payload := range payloads {
+			sequenceNumber++
+			if skipFirst && i == 0 {
+				continue
+			}
+			s.Push(&rtp.Packet{
+				Header:  rtp.Header{SequenceNumber: sequenceNumber, Timestamp: timestamp, Marker: i == len(payloads)-1},
+				Payload: payload,
+			})
+		}
+	}
+
+	// The head of the first temporal unit is lost, it must be dropped
+	push(0, true)
+	push(3000, false)
+	push(6000, false)
+
+	// The first Pop drops the incomplete temporal unit
+	assert.Nil(t, s.Pop())
+
+	sample := s.Pop()
+	assert.NotNil(t, sample)
+	assert.Equal(t, uint32(3000), sample.PacketTimestamp)
+	assert.Equal(t, temporalUnit, sample.Data)
+	assert.Nil(t, s.Pop())
+}
+
+func TestSampleBuilderWithRTPTimestampToTime(t *testing.T) {
+	base := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	rtpTimestampToTime := func(rtpTimestamp uint32) (time.Time, bool) {
+		if rtpTimestamp < 90000 {
+			return time.Time{}, false
+		}
+		return base.Add(time.Duration(rtpTimestamp) * time.Second / 90000), true
+	}
+
+	s := New(10, &fakeDepacketizer{}, 90000, WithRTPTimestampToTime(rtpTimestampToTime))
+	for i, timestamp := range []uint32{0, 90000, 93000} {
+		s.Push(&rtp.Packet{
+			Header:  rtp.Header{SequenceNumber: uint16(i), Timestamp: timestamp, Marker: true},
+			Payload: []byte{0x01},
+		})
+	}
+
+	sample := s.Pop()
+	assert.NotNil(t, sample)
+	assert.True(t, sample.Timestamp.IsZero(), "Timestamp must be unset when it can't be converted")
+
+	sample = s.Pop()
+	assert.NotNil(t, sample)
+	assert.Equal(t, base.Add(time.Second), sample.Timestamp)
+}
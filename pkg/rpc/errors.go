@@ -0,0 +1,26 @@
+package rpc
+
+import (
+	"errors"
+)
+
+var (
+	// ErrClosed is returned by calls that didn't complete before the Conn closed
+	ErrClosed = errors.New("rpc: connection closed")
+
+	// ErrMethodNotFound is returned by calls of a method the remote peer didn't register,
+	// or registered for the other kind of call
+	ErrMethodNotFound = errors.New("rpc: method not found")
+
+	errFrameTooShort = errors.New("rpc: frame too short")
+	errMethodTooLong = errors.New("rpc: method name too long")
+)
+
+// Error is an error returned by the handler of the remote peer
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return "rpc: remote error: " + e.Message
+}
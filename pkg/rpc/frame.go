@@ -0,0 +1,115 @@
+package rpc
+
+import (
+	"encoding/binary"
+)
+
+const (
+	frameTypeRequest uint8 = iota + 1
+	frameTypeStreamRequest
+	frameTypeResponse
+	frameTypeStreamData
+	frameTypeError
+	frameTypeCancel
+)
+
+const (
+	frameHeaderSize       = 5
+	frameMethodLengthSize = 2
+	frameErrorCodeSize    = 1
+)
+
+// Error codes of error frames
+const (
+	errorCodeApplication uint8 = iota + 1
+	errorCodeMethodNotFound
+)
+
+// frame is a single message of the RPC protocol. Requests carry the method name,
+// and the ID of a call is chosen by the peer making it.
+type frame struct {
+	typ     uint8
+	id      uint32
+	method  string
+	payload []byte
+}
+
+func (f *frame) marshal() []byte {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |     Type      |                   Call ID                     |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |               | Method length (requests only) |  Method name  |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                            Payload                            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	size := frameHeaderSize + len(f.payload)
+	if f.isRequest() {
+		size += frameMethodLengthSize + len(f.method)
+	}
+
+	raw := make([]byte, frameHeaderSize, size)
+	raw[0] = f.typ
+	binary.BigEndian.PutUint32(raw[1:], f.id)
+	if f.isRequest() {
+		raw = raw[:frameHeaderSize+frameMethodLengthSize]
+		binary.BigEndian.PutUint16(raw[frameHeaderSize:], uint16(len(f.method)))
+		raw = append(raw, f.method...)
+	}
+	return append(raw, f.payload...)
+}
+
+func (f *frame) unmarshal(raw []byte) error {
+	if len(raw) < frameHeaderSize {
+		return errFrameTooShort
+	}
+
+	f.typ = raw[0]
+	f.id = binary.BigEndian.Uint32(raw[1:])
+	raw = raw[frameHeaderSize:]
+
+	f.method = ""
+	if f.isRequest() {
+		if len(raw) < frameMethodLengthSize {
+			return errFrameTooShort
+		}
+		methodLength := int(binary.BigEndian.Uint16(raw))
+		raw = raw[frameMethodLengthSize:]
+		if len(raw) < methodLength {
+			return errFrameTooShort
+		}
+		f.method = string(raw[:methodLength])
+		raw = raw[methodLength:]
+	}
+
+	f.payload = raw
+	return nil
+}
+
+func (f *frame) isRequest() bool {
+	return f.typ == frameTypeRequest || f.typ == frameTypeStreamRequest
+}
+
+// errorFrame returns the frame failing a call, its payload is the error code followed
+// by the error message
+func errorFrame(id uint32, code uint8, message string) frame {
+	payload := make([]byte, frameErrorCodeSize, frameErrorCodeSize+len(message))
+	payload[0] = code
+	return frame{typ: frameTypeError, id: id, payload: append(payload, message...)}
+}
+
+// err returns the error carried by an error frame
+func (f *frame) err() error {
+	if len(f.payload) < frameErrorCodeSize {
+		return &Error{}
+	}
+
+	message := string(f.payload[frameErrorCodeSize:])
+	if f.payload[0] == errorCodeMethodNotFound {
+		return ErrMethodNotFound
+	}
+	return &Error{Message: message}
+}
@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrame(t *testing.T) {
+	for _, f := range []frame{
+		{typ: frameTypeRequest, id: 1, method: "echo", payload: []byte{0x01, 0x02}},
+		{typ: frameTypeStreamRequest, id: 0xFFFFFFFF, method: "", payload: []byte{}},
+		{typ: frameTypeResponse, id: 2, payload: []byte("response")},
+		{typ: frameTypeCancel, id: 3, payload: []byte{}},
+	} {
+		parsed := frame{}
+		assert.NoError(t, parsed.unmarshal(f.marshal()))
+		assert.Equal(t, f, parsed)
+	}
+
+	for _, raw := range [][]byte{
+		{},
+		{frameTypeResponse, 0x00, 0x00, 0x00},
+		{frameTypeRequest, 0x00, 0x00, 0x00, 0x01, 0x00},
+		{frameTypeRequest, 0x00, 0x00, 0x00, 0x01, 0x00, 0x05, 'e', 'c'},
+	} {
+		assert.Equal(t, errFrameTooShort, (&frame{}).unmarshal(raw))
+	}
+}
+
+func TestErrorFrame(t *testing.T) {
+	f := errorFrame(1, errorCodeApplication, "failed")
+	parsed := frame{}
+	assert.NoError(t, parsed.unmarshal(f.marshal()))
+	assert.Equal(t, &Error{Message: "failed"}, parsed.err())
+
+	f = errorFrame(1, errorCodeMethodNotFound, "missing")
+	assert.Equal(t, ErrMethodNotFound, f.err())
+}
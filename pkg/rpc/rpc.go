@@ -0,0 +1,335 @@
+// Package rpc provides request/response messaging over WebRTC data channels, with
+// cancellation and server streaming. Both peers can register methods and call the
+// methods of the other peer over the same data channel.
+package rpc
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/internal/dcchannel"
+)
+
+const (
+	// streamQueueSize is the number of streamed responses queued for a ClientStream
+	// before the data channel stops being read
+	streamQueueSize = 64
+	// maxMethodLength is the longest method name a frame can carry
+	maxMethodLength = math.MaxUint16
+)
+
+// Handler handles a call of a registered method. The context is canceled once the
+// caller cancels the call or the Conn closes.
+type Handler func(ctx context.Context, request []byte) ([]byte, error)
+
+// StreamHandler handles a streaming call of a registered method. The call ends once
+// the handler returns.
+type StreamHandler func(ctx context.Context, request []byte, stream *ServerStream) error
+
+type handler struct {
+	unary  Handler
+	stream StreamHandler
+}
+
+// Conn makes and serves calls over a DataChannel. Every call is a binary message,
+// so requests and responses are limited by the max-message-size of the data channel,
+// larger responses can be split with a StreamHandler.
+//
+// Requests are served concurrently, while streamed responses are read in order: a
+// ClientStream that isn't read stops the data channel being read once its queue is full.
+type Conn struct {
+	ch *dcchannel.Channel
+
+	mu       sync.Mutex
+	handlers map[string]handler
+	calls    map[uint32]*call
+	serving  map[uint32]context.CancelFunc
+	nextID   uint32
+	err      error
+
+	// writeLock serializes writes, while waiting for it can be canceled
+	writeLock chan struct{}
+
+	ctx          context.Context
+	cancel       context.CancelFunc
+	closeOnce    sync.Once
+	shutdownOnce sync.Once
+}
+
+// NewConn returns a Conn over dc. The Conn takes over the OnOpen, OnClose and
+// OnBufferedAmountLow handlers of dc, and reads its messages with ReadMessage, so dc
+// must not have an OnMessage handler. It should be created before dc opens, for example
+// in the OnDataChannel handler. Detached data channels are supported, the Conn detaches
+// them itself once they are open.
+//
+// Calls block until dc is open.
+func NewConn(dc *webrtc.DataChannel) *Conn {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Conn{
+		ch:        dcchannel.New(dc),
+		handlers:  map[string]handler{},
+		calls:     map[uint32]*call{},
+		serving:   map[uint32]context.CancelFunc{},
+		writeLock: make(chan struct{}, 1),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	go c.readLoop()
+
+	return c
+}
+
+// DataChannel returns the data channel of the Conn
+func (c *Conn) DataChannel() *webrtc.DataChannel {
+	return c.ch.DataChannel()
+}
+
+// Handle registers the handler of a method, replacing the handler registered before
+func (c *Conn) Handle(method string, h Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[method] = handler{unary: h}
+}
+
+// HandleStream registers the streaming handler of a method, replacing the handler
+// registered before
+func (c *Conn) HandleStream(method string, h StreamHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[method] = handler{stream: h}
+}
+
+// Call calls a method of the remote peer and returns its response. If ctx is done
+// before the response is received, the call is canceled and ctx.Err() is returned.
+// Errors returned by the remote handler are an *Error.
+func (c *Conn) Call(ctx context.Context, method string, request []byte) ([]byte, error) {
+	cl, err := c.startCall(ctx, frameTypeRequest, method, request)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-cl.responses:
+		return response, nil
+	case <-cl.done:
+		select {
+		case response := <-cl.responses:
+			return response, nil
+		default:
+			return nil, cl.err
+		}
+	case <-ctx.Done():
+		c.cancelCall(cl, ctx.Err())
+		return nil, ctx.Err()
+	}
+}
+
+// CallStream calls a streaming method of the remote peer, the responses are read from
+// the returned ClientStream. ctx applies to the whole call.
+func (c *Conn) CallStream(ctx context.Context, method string, request []byte) (*ClientStream, error) {
+	cl, err := c.startCall(ctx, frameTypeStreamRequest, method, request)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientStream{c: c, call: cl, ctx: ctx}, nil
+}
+
+// Close closes the data channel. Pending calls return ErrClosed, and the contexts of
+// the handlers being run are canceled.
+func (c *Conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.shutdown()
+		err = c.ch.Close()
+	})
+	return err
+}
+
+// startCall registers a new call and sends its request
+func (c *Conn) startCall(ctx context.Context, typ uint8, method string, request []byte) (*call, error) {
+	if len(method) > maxMethodLength {
+		return nil, errMethodTooLong
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.nextID++
+	cl := newCall(c.nextID, typ == frameTypeStreamRequest)
+	c.calls[cl.id] = cl
+	c.mu.Unlock()
+
+	if err := c.write(ctx, frame{typ: typ, id: cl.id, method: method, payload: request}); err != nil {
+		c.removeCall(cl)
+		return nil, err
+	}
+	return cl, nil
+}
+
+// cancelCall ends a call before its response is received, and asks the remote peer
+// to cancel its handler
+func (c *Conn) cancelCall(cl *call, err error) {
+	if !c.removeCall(cl) {
+		return
+	}
+	cl.finish(err)
+
+	go func() {
+		_ = c.write(c.ctx, frame{typ: frameTypeCancel, id: cl.id})
+	}()
+}
+
+// removeCall unregisters a call and returns whether it was still registered
+func (c *Conn) removeCall(cl *call) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.calls[cl.id] != cl {
+		return false
+	}
+	delete(c.calls, cl.id)
+	return true
+}
+
+// readLoop handles the messages received until the data channel or the Conn closes
+func (c *Conn) readLoop() {
+	defer c.shutdown()
+
+	for {
+		raw, err := c.ch.Read(c.ctx)
+		if err != nil {
+			return
+		}
+		c.handleMessage(raw)
+	}
+}
+
+func (c *Conn) handleMessage(raw []byte) {
+	f := frame{}
+	if err := f.unmarshal(raw); err != nil {
+		return
+	}
+
+	switch f.typ {
+	case frameTypeRequest, frameTypeStreamRequest:
+		c.serve(f)
+	case frameTypeCancel:
+		c.mu.Lock()
+		cancel, ok := c.serving[f.id]
+		c.mu.Unlock()
+		if ok {
+			cancel()
+		}
+	case frameTypeResponse, frameTypeStreamData, frameTypeError:
+		c.mu.Lock()
+		cl, ok := c.calls[f.id]
+		c.mu.Unlock()
+		if ok {
+			c.handleResponse(cl, f)
+		}
+	}
+}
+
+// handleResponse passes a response to a call. Streamed responses block while the queue
+// of the call is full.
+func (c *Conn) handleResponse(cl *call, f frame) {
+	switch f.typ {
+	case frameTypeStreamData:
+		select {
+		case cl.responses <- f.payload:
+		case <-cl.done:
+		case <-c.ctx.Done():
+		}
+	case frameTypeResponse:
+		if c.removeCall(cl) {
+			if !cl.stream {
+				cl.responses <- f.payload
+			}
+			cl.finish(nil)
+		}
+	case frameTypeError:
+		if c.removeCall(cl) {
+			cl.finish(f.err())
+		}
+	}
+}
+
+// serve runs the handler of a request in its own goroutine
+func (c *Conn) serve(f frame) {
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	c.mu.Lock()
+	h, ok := c.handlers[f.method]
+	c.serving[f.id] = cancel
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.serving, f.id)
+			c.mu.Unlock()
+			cancel()
+		}()
+
+		var response []byte
+		var err error
+		switch {
+		case f.typ == frameTypeRequest && ok && h.unary != nil:
+			response, err = h.unary(ctx, f.payload)
+		case f.typ == frameTypeStreamRequest && ok && h.stream != nil:
+			err = h.stream(ctx, f.payload, &ServerStream{c: c, id: f.id, ctx: ctx})
+		default:
+			_ = c.write(ctx, errorFrame(f.id, errorCodeMethodNotFound, f.method))
+			return
+		}
+
+		// The caller doesn't wait for the response of a canceled call
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			_ = c.write(ctx, errorFrame(f.id, errorCodeApplication, err.Error()))
+			return
+		}
+		_ = c.write(ctx, frame{typ: frameTypeResponse, id: f.id, payload: response})
+	}()
+}
+
+// write sends a frame once the data channel is open, and blocks while it buffers too
+// much data. Waiting stops once ctx is done.
+func (c *Conn) write(ctx context.Context, f frame) error {
+	select {
+	case c.writeLock <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-c.writeLock }()
+
+	err := c.ch.Write(ctx, f.marshal())
+	if err != nil && (err == io.ErrClosedPipe || c.ctx.Err() != nil) {
+		return ErrClosed
+	}
+	return err
+}
+
+// shutdown fails the pending calls and cancels the handlers being run
+func (c *Conn) shutdown() {
+	c.shutdownOnce.Do(func() {
+		c.cancel()
+
+		c.mu.Lock()
+		c.err = ErrClosed
+		calls := c.calls
+		c.calls = map[uint32]*call{}
+		c.mu.Unlock()
+
+		for _, cl := range calls {
+			cl.finish(ErrClosed)
+		}
+	})
+}
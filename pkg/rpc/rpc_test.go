@@ -0,0 +1,167 @@
+// +build !js
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/internal/dctest"
+	"github.com/stretchr/testify/assert"
+)
+
+// connectedPair returns two connected PeerConnections, and a Conn on each end of a data channel
+func connectedPair(t *testing.T, api *webrtc.API) (*webrtc.PeerConnection, *webrtc.PeerConnection, *Conn, *Conn) {
+	pcOffer, pcAnswer := dctest.NewPair(t, api)
+
+	accepted := make(chan *Conn, 1)
+	pcAnswer.OnDataChannel(func(dc *webrtc.DataChannel) {
+		accepted <- NewConn(dc)
+	})
+
+	dc, err := pcOffer.CreateDataChannel("rpc", nil)
+	assert.NoError(t, err)
+	conn := NewConn(dc)
+
+	dctest.Signal(t, pcOffer, pcAnswer)
+	return pcOffer, pcAnswer, conn, <-accepted
+}
+
+// blocking is signaled once the handler of "block" starts, and once it is canceled
+type blocking struct {
+	started, canceled chan struct{}
+}
+
+// register adds the methods used by the tests
+func register(c *Conn) blocking {
+	b := blocking{started: make(chan struct{}, 1), canceled: make(chan struct{}, 1)}
+	c.Handle("echo", func(ctx context.Context, request []byte) ([]byte, error) {
+		return request, nil
+	})
+	c.Handle("fail", func(ctx context.Context, request []byte) ([]byte, error) {
+		return nil, errors.New(string(request))
+	})
+	c.Handle("block", func(ctx context.Context, request []byte) ([]byte, error) {
+		b.started <- struct{}{}
+		<-ctx.Done()
+		b.canceled <- struct{}{}
+		return nil, ctx.Err()
+	})
+	c.HandleStream("count", func(ctx context.Context, request []byte, stream *ServerStream) error {
+		n, err := strconv.Atoi(string(request))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if err := stream.Send([]byte(strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return b
+}
+
+func testCalls(t *testing.T, caller *Conn, callee blocking) {
+	t.Run("Call", func(t *testing.T) {
+		response, err := caller.Call(context.Background(), "echo", []byte("hello"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("hello"), response)
+
+		// Requests larger than the buffered amount limit
+		large := bytes.Repeat([]byte{0xAB}, 60000)
+		for i := 0; i < 20; i++ {
+			response, err = caller.Call(context.Background(), "echo", large)
+			assert.NoError(t, err)
+			assert.Equal(t, large, response)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := caller.Call(context.Background(), "fail", []byte("failed"))
+		assert.Equal(t, &Error{Message: "failed"}, err)
+	})
+
+	t.Run("MethodNotFound", func(t *testing.T) {
+		_, err := caller.Call(context.Background(), "missing", nil)
+		assert.Equal(t, ErrMethodNotFound, err)
+
+		_, err = caller.Call(context.Background(), "count", []byte("1"))
+		assert.Equal(t, ErrMethodNotFound, err)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := caller.Call(ctx, "block", nil)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		<-callee.started
+		<-callee.canceled
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		stream, err := caller.CallStream(context.Background(), "count", []byte("200"))
+		assert.NoError(t, err)
+
+		for i := 0; i < 200; i++ {
+			response, recvErr := stream.Recv()
+			assert.NoError(t, recvErr)
+			assert.Equal(t, strconv.Itoa(i), string(response))
+		}
+		_, err = stream.Recv()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("StreamError", func(t *testing.T) {
+		stream, err := caller.CallStream(context.Background(), "count", []byte("NaN"))
+		assert.NoError(t, err)
+
+		_, err = stream.Recv()
+		assert.IsType(t, &Error{}, err)
+	})
+}
+
+func TestConn(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	dctest.Run(t, webrtc.SettingEngine{}, func(t *testing.T, api *webrtc.API) {
+		pcOffer, pcAnswer, offerer, answerer := connectedPair(t, api)
+		defer dctest.ClosePair(t, pcOffer, pcAnswer)
+
+		offererBlocking, answererBlocking := register(offerer), register(answerer)
+
+		// Both peers make and serve calls
+		testCalls(t, offerer, answererBlocking)
+		testCalls(t, answerer, offererBlocking)
+
+		t.Run("Close", func(t *testing.T) {
+			done := make(chan error)
+			go func() {
+				_, err := offerer.Call(context.Background(), "block", nil)
+				done <- err
+			}()
+
+			<-answererBlocking.started
+			assert.NoError(t, offerer.Close())
+			assert.Equal(t, ErrClosed, <-done)
+
+			// The handlers of the remote peer are canceled once the data channel closes
+			<-answererBlocking.canceled
+
+			_, err := offerer.Call(context.Background(), "echo", nil)
+			assert.Equal(t, ErrClosed, err)
+		})
+	})
+}
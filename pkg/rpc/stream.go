@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// call is a call made to the remote peer, waiting for its responses
+type call struct {
+	id        uint32
+	stream    bool
+	responses chan []byte
+
+	done       chan struct{}
+	finishOnce sync.Once
+	err        error
+}
+
+func newCall(id uint32, stream bool) *call {
+	queueSize := 1
+	if stream {
+		queueSize = streamQueueSize
+	}
+	return &call{
+		id:        id,
+		stream:    stream,
+		responses: make(chan []byte, queueSize),
+		done:      make(chan struct{}),
+	}
+}
+
+// finish ends the call, err is nil once every response was received
+func (cl *call) finish(err error) {
+	cl.finishOnce.Do(func() {
+		cl.err = err
+		close(cl.done)
+	})
+}
+
+// ServerStream sends the responses of a streaming call
+type ServerStream struct {
+	c   *Conn
+	id  uint32
+	ctx context.Context
+}
+
+// Send sends a response to the caller. It blocks while the data channel buffers too
+// much data, and returns an error once the call is canceled.
+func (s *ServerStream) Send(response []byte) error {
+	return s.c.write(s.ctx, frame{typ: frameTypeStreamData, id: s.id, payload: response})
+}
+
+// ClientStream receives the responses of a streaming call
+type ClientStream struct {
+	c    *Conn
+	call *call
+	ctx  context.Context
+}
+
+// Recv returns the next response. It returns io.EOF once the handler of the remote peer
+// returned without error, and ctx.Err() once the context of the call is done.
+func (s *ClientStream) Recv() ([]byte, error) {
+	select {
+	case response := <-s.call.responses:
+		return response, nil
+	case <-s.call.done:
+		// Responses received before the call ended are read first
+		select {
+		case response := <-s.call.responses:
+			return response, nil
+		default:
+		}
+		if s.call.err == nil {
+			return nil, io.EOF
+		}
+		return nil, s.call.err
+	case <-s.ctx.Done():
+		s.c.cancelCall(s.call, s.ctx.Err())
+		return nil, s.ctx.Err()
+	}
+}
+
+// Close cancels the call if it didn't end yet
+func (s *ClientStream) Close() error {
+	s.c.cancelCall(s.call, context.Canceled)
+	return nil
+}
@@ -0,0 +1,254 @@
+package rtpcodecs
+
+const (
+	av1ZMask     = 0x80
+	av1YMask     = 0x40
+	av1WMask     = 0x30
+	av1WShift    = 4
+	av1NMask     = 0x08
+	av1HeaderLen = 1
+
+	obuTypeMask          = 0x78
+	obuTypeShift         = 3
+	obuExtensionFlagMask = 0x04
+	obuHasSizeFieldMask  = 0x02
+
+	obuTypeSequenceHeader    = 1
+	obuTypeTemporalDelimiter = 2
+	obuTypeTileList          = 8
+	obuTypePadding           = 15
+)
+
+// AV1Payloader payloads AV1 temporal units, as described in the
+// RTP Payload Format For AV1 (https://aomediacodec.github.io/av1-rtp-spec/)
+type AV1Payloader struct{}
+
+// Payload fragments an AV1 temporal unit in the low overhead bitstream format across
+// one or more byte arrays. Temporal delimiters, tile lists and padding OBUs are dropped,
+// and the size field of every other OBU is removed.
+func (p *AV1Payloader) Payload(mtu int, payload []byte) [][]byte {
+	/*
+	 * https://aomediacodec.github.io/av1-rtp-spec/#44-av1-aggregation-header
+	 *
+	 *  0 1 2 3 4 5 6 7
+	 * +-+-+-+-+-+-+-+-+
+	 * |Z|Y| W |N|-|-|-|
+	 * +-+-+-+-+-+-+-+-+
+	 *
+	 * W is always 0, so every OBU element is preceded by its LEB128 length
+	 */
+	if payload == nil || mtu <= av1HeaderLen+1 {
+		return [][]byte{}
+	}
+
+	obus, isNewSequence := splitOBUs(payload)
+
+	out := [][]byte{}
+	current := make([]byte, av1HeaderLen, mtu)
+	if isNewSequence {
+		current[0] |= av1NMask
+	}
+
+	for _, obu := range obus {
+		for len(obu) > 0 {
+			available := mtu - len(current)
+			if leb128Size(uint(len(obu)))+len(obu) <= available {
+				current = appendLEB128(current, uint(len(obu)))
+				current = append(current, obu...)
+				break
+			}
+
+			// Fragment the OBU, the last element of this packet continues in the next one
+			n := available - 1
+			for n > 0 && leb128Size(uint(n))+n > available {
+				n--
+			}
+			if n > 0 {
+				current = appendLEB128(current, uint(n))
+				current = append(current, obu[:n]...)
+				current[0] |= av1YMask
+				obu = obu[n:]
+			}
+
+			if len(current) > av1HeaderLen {
+				out = append(out, current)
+			}
+			current = make([]byte, av1HeaderLen, mtu)
+			if n > 0 {
+				current[0] |= av1ZMask
+			}
+		}
+	}
+
+	if len(current) > av1HeaderLen {
+		out = append(out, current)
+	}
+	return out
+}
+
+// splitOBUs parses the OBUs of a temporal unit in the low overhead bitstream format.
+// The returned OBUs have no size field and temporal delimiters, tile lists and padding
+// are removed. It also reports if the temporal unit contains a sequence header.
+func splitOBUs(payload []byte) (obus [][]byte, hasSequenceHeader bool) {
+	for len(payload) > 0 {
+		header := payload[0]
+		headerLen := 1
+		if header&obuExtensionFlagMask != 0 {
+			headerLen++
+		}
+		if len(payload) < headerLen {
+			return obus, hasSequenceHeader
+		}
+
+		obuEnd := len(payload)
+		dataStart := headerLen
+		if header&obuHasSizeFieldMask != 0 {
+			size, n, err := readLEB128(payload[headerLen:])
+			if err != nil || uint(len(payload)-headerLen-n) < size {
+				return obus, hasSequenceHeader
+			}
+			dataStart = headerLen + n
+			obuEnd = dataStart + int(size)
+		}
+
+		switch (header & obuTypeMask) >> obuTypeShift {
+		case obuTypeTemporalDelimiter, obuTypeTileList, obuTypePadding:
+		default:
+			if (header&obuTypeMask)>>obuTypeShift == obuTypeSequenceHeader {
+				hasSequenceHeader = true
+			}
+
+			obu := make([]byte, 0, headerLen+obuEnd-dataStart)
+			obu = append(obu, header&^obuHasSizeFieldMask)
+			obu = append(obu, payload[1:headerLen]...)
+			obu = append(obu, payload[dataStart:obuEnd]...)
+			obus = append(obus, obu)
+		}
+
+		payload = payload[obuEnd:]
+	}
+
+	return obus, hasSequenceHeader
+}
+
+// AV1Packet represents the AV1 header that is stored in the payload of an RTP Packet.
+// It keeps the fragments of OBUs that are split across packets, so one AV1Packet must
+// be used per stream and packets must be passed in order.
+type AV1Packet struct {
+	// Z is set if the first OBU element is the continuation of an OBU of the previous packet
+	Z bool
+	// Y is set if the last OBU element continues in the next packet
+	Y bool
+	// W is the amount of OBU elements, or 0 if every element is preceded by its length
+	W uint8
+	// N is set if the packet is the first packet of a coded video sequence
+	N bool
+
+	// OBUElements contains the OBU elements of the last unmarshaled packet
+	OBUElements [][]byte
+
+	fragment []byte
+}
+
+// Unmarshal parses the passed byte slice and returns the complete OBUs it contains in the
+// low overhead bitstream format, i.e. with their size field set. OBUs that are fragmented
+// are returned when their last fragment is unmarshaled.
+func (p *AV1Packet) Unmarshal(payload []byte) ([]byte, error) {
+	if payload == nil {
+		return nil, errNilPacket
+	} else if len(payload) < av1HeaderLen {
+		return nil, errShortPacket
+	}
+
+	p.Z = payload[0]&av1ZMask != 0
+	p.Y = payload[0]&av1YMask != 0
+	p.W = (payload[0] & av1WMask) >> av1WShift
+	p.N = payload[0]&av1NMask != 0
+	p.OBUElements = p.OBUElements[:0]
+
+	for offset := av1HeaderLen; offset < len(payload); {
+		elementLen := len(payload) - offset
+		if p.W == 0 || len(p.OBUElements) < int(p.W)-1 {
+			size, n, err := readLEB128(payload[offset:])
+			if err != nil {
+				return nil, err
+			}
+			offset += n
+			if uint(len(payload)-offset) < size {
+				return nil, errInvalidOBUElement
+			}
+			elementLen = int(size)
+		}
+
+		p.OBUElements = append(p.OBUElements, payload[offset:offset+elementLen])
+		offset += elementLen
+	}
+
+	// A fragment that isn't continued means packets were lost
+	if !p.Z {
+		p.fragment = p.fragment[:0]
+	}
+
+	out := []byte{}
+	for i, element := range p.OBUElements {
+		isFirst, isLast := i == 0, i == len(p.OBUElements)-1
+
+		if isFirst && p.Z {
+			if len(p.fragment) == 0 {
+				// The start of this OBU was lost
+				if isLast && p.Y {
+					return out, nil
+				}
+				continue
+			}
+			element = append(p.fragment, element...)
+			p.fragment = p.fragment[:0]
+		}
+
+		if isLast && p.Y {
+			p.fragment = append(p.fragment[:0], element...)
+			break
+		}
+
+		out = appendOBU(out, element)
+	}
+
+	return out, nil
+}
+
+// appendOBU appends obu with its size field set to out
+func appendOBU(out, obu []byte) []byte {
+	if len(obu) == 0 {
+		return out
+	}
+
+	headerLen := 1
+	if obu[0]&obuExtensionFlagMask != 0 {
+		headerLen++
+	}
+	if len(obu) < headerLen {
+		return out
+	}
+
+	out = append(out, obu[0]|obuHasSizeFieldMask)
+	out = append(out, obu[1:headerLen]...)
+	out = appendLEB128(out, uint(len(obu)-headerLen))
+	return append(out, obu[headerLen:]...)
+}
+
+// IsDetectedFinalPacketInSequence returns true of the packet passed in has the
+// marker bit set indicated the end of a packet sequence
+func (p *AV1Packet) IsDetectedFinalPacketInSequence(rtpPacketMarketBit bool) bool {
+	return rtpPacketMarketBit
+}
+
+// AV1PartitionHeadChecker checks AV1 partition head
+type AV1PartitionHeadChecker struct{}
+
+// IsPartitionHead checks whether if this is a head of the AV1 partition
+func (*AV1PartitionHeadChecker) IsPartitionHead(packet []byte) bool {
+	if len(packet) < av1HeaderLen {
+		return false
+	}
+	return packet[0]&av1ZMask == 0
+}
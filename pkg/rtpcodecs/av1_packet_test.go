@@ -0,0 +1,165 @@
+package rtpcodecs
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestLEB128(t *testing.T) {
+	for _, v := range []uint{0, 1, 127, 128, 300, 16383, 16384, 1<<32 - 1} {
+		encoded := appendLEB128(nil, v)
+		if len(encoded) != leb128Size(v) {
+			t.Fatalf("leb128Size(%d) = %d, encoded %d bytes", v, leb128Size(v), len(encoded))
+		}
+
+		decoded, n, err := readLEB128(encoded)
+		if err != nil {
+			t.Fatal(err)
+		} else if decoded != v || n != len(encoded) {
+			t.Fatalf("readLEB128(%v) = %d, %d, expected %d, %d", encoded, decoded, n, v, len(encoded))
+		}
+	}
+
+	if _, _, err := readLEB128([]byte{0x80, 0x80}); !errors.Is(err, errInvalidLEB128) {
+		t.Fatalf("Unterminated value must fail, got %v", err)
+	}
+}
+
+func TestAV1Payloader(t *testing.T) {
+	temporalDelimiter := []byte{0x12, 0x00}
+	sequenceHeader := []byte{0x0A, 0x03, 0x01, 0x02, 0x03}
+	frame := append([]byte{0x32, 0x81, 0x01}, bytes.Repeat([]byte{0xAB}, 129)...)
+
+	temporalUnit := append(append(append([]byte{}, temporalDelimiter...), sequenceHeader...), frame...)
+
+	t.Run("Aggregation", func(t *testing.T) {
+		pck := AV1Payloader{}
+		res := pck.Payload(1500, temporalUnit)
+		if len(res) != 1 {
+			t.Fatalf("Expected one packet, got %d", len(res))
+		}
+
+		expected := append([]byte{0x08, 0x04, 0x08, 0x01, 0x02, 0x03, 0x82, 0x01, 0x30}, bytes.Repeat([]byte{0xAB}, 129)...)
+		if !bytes.Equal(res[0], expected) {
+			t.Fatalf("Unexpected payload %v", res[0])
+		}
+	})
+
+	t.Run("Fragmentation", func(t *testing.T) {
+		pck := AV1Payloader{}
+		res := pck.Payload(40, temporalUnit)
+		if len(res) != 4 {
+			t.Fatalf("Expected 4 packets, got %d", len(res))
+		}
+
+		for i, p := range res {
+			if len(p) > 40 {
+				t.Fatalf("Packet %d exceeds the MTU", i)
+			}
+			if isFirst := i == 0; (p[0]&av1ZMask == 0) != isFirst {
+				t.Fatalf("Packet %d has wrong Z bit", i)
+			}
+			if isLast := i == len(res)-1; (p[0]&av1YMask == 0) != isLast {
+				t.Fatalf("Packet %d has wrong Y bit", i)
+			}
+		}
+		if res[0][0]&av1NMask == 0 {
+			t.Fatal("First packet of a sequence must have the N bit set")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		pck := AV1Payloader{}
+		if res := pck.Payload(1500, nil); len(res) != 0 {
+			t.Fatal("Nil payload must result in no packets")
+		}
+		if res := pck.Payload(1, temporalUnit); len(res) != 0 {
+			t.Fatal("Too small MTU must result in no packets")
+		}
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		pck := AV1Payloader{}
+		depacketizer := &AV1Packet{}
+
+		for _, mtu := range []int{3, 10, 40, 1500} {
+			out := []byte{}
+			for _, p := range pck.Payload(mtu, temporalUnit) {
+				obus, err := depacketizer.Unmarshal(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out = append(out, obus...)
+			}
+
+			if expected := temporalUnit[len(temporalDelimiter):]; !bytes.Equal(out, expected) {
+				t.Fatalf("MTU %d: expected %v, got %v", mtu, expected, out)
+			}
+		}
+	})
+}
+
+func TestAV1Packet_Unmarshal(t *testing.T) {
+	pck := &AV1Packet{}
+
+	if _, err := pck.Unmarshal(nil); !errors.Is(err, errNilPacket) {
+		t.Fatal("Nil payload must fail")
+	}
+	if _, err := pck.Unmarshal([]byte{}); !errors.Is(err, errShortPacket) {
+		t.Fatal("Empty payload must fail")
+	}
+	if _, err := pck.Unmarshal([]byte{0x00, 0x05, 0x30}); !errors.Is(err, errInvalidOBUElement) {
+		t.Fatal("Element larger than the payload must fail")
+	}
+
+	// W=2, the last element has no length field
+	out, err := pck.Unmarshal([]byte{0x20, 0x02, 0x30, 0x01, 0x30, 0x02})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []byte{0x32, 0x01, 0x01, 0x32, 0x01, 0x02}; !bytes.Equal(out, expected) {
+		t.Fatalf("Expected %v, got %v", expected, out)
+	}
+	if len(pck.OBUElements) != 2 {
+		t.Fatalf("Expected 2 OBU elements, got %d", len(pck.OBUElements))
+	}
+
+	t.Run("Lost fragment", func(t *testing.T) {
+		pck := &AV1Packet{}
+
+		// The first fragment is lost, the continuation is dropped
+		out, err := pck.Unmarshal([]byte{0x80, 0x01, 0x01, 0x02, 0x30, 0x02})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []byte{0x32, 0x01, 0x02}; !bytes.Equal(out, expected) {
+			t.Fatalf("Expected %v, got %v", expected, out)
+		}
+
+		// A fragment that is not continued is dropped
+		if out, err = pck.Unmarshal([]byte{0x40, 0x02, 0x30, 0x01}); err != nil || len(out) != 0 {
+			t.Fatalf("Expected no OBUs, got %v %v", out, err)
+		}
+		if out, err = pck.Unmarshal([]byte{0x00, 0x02, 0x30, 0x03}); err != nil {
+			t.Fatal(err)
+		}
+		if expected := []byte{0x32, 0x01, 0x03}; !bytes.Equal(out, expected) {
+			t.Fatalf("Expected %v, got %v", expected, out)
+		}
+	})
+}
+
+func TestAV1PartitionHeadChecker(t *testing.T) {
+	checker := &AV1PartitionHeadChecker{}
+
+	if checker.IsPartitionHead(nil) {
+		t.Fatal("Nil packet must not be a partition head")
+	}
+	if !checker.IsPartitionHead([]byte{0x08, 0x00}) {
+		t.Fatal("Packet without Z bit must be a partition head")
+	}
+	if checker.IsPartitionHead([]byte{0x80, 0x00}) {
+		t.Fatal("Packet with Z bit must not be a partition head")
+	}
+}
@@ -0,0 +1,289 @@
+package rtpcodecs
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	h265NALUHeaderSize = 2
+	h265FUHeaderSize   = 1
+	h265APNALUSize     = 2
+
+	h265NALUTypeMask     = 0x7E
+	h265NALUTypeShift    = 1
+	h265ForbiddenBitMask = 0x80
+	h265LayerIDMask      = 0x01F8
+	h265TIDMask          = 0x07
+	h265FUTypeMask       = 0x3F
+	h265FUStartBitmask   = 0x80
+	h265FUEndBitmask     = 0x40
+
+	h265NALUTypeAUD  = 35
+	h265NALUTypeFD   = 38
+	h265NALUTypeAP   = 48
+	h265NALUTypeFU   = 49
+	h265NALUTypePACI = 50
+)
+
+func annexbNALUStartCode() []byte { return []byte{0x00, 0x00, 0x00, 0x01} }
+
+// h265NALUType returns the type of the NAL unit whose header starts at b
+func h265NALUType(b []byte) uint8 {
+	return (b[0] & h265NALUTypeMask) >> h265NALUTypeShift
+}
+
+// emitNALUs calls emit for every NAL unit of an Annex B byte stream. If the
+// stream has no start codes it is emitted as one NAL unit.
+func emitNALUs(nals []byte, emit func([]byte)) {
+	nextStartCode := func(start int) (index, length int) {
+		zeroCount := 0
+		for i, b := range nals[start:] {
+			if b == 0 {
+				zeroCount++
+				continue
+			} else if b == 1 && zeroCount >= 2 {
+				return start + i - zeroCount, zeroCount + 1
+			}
+			zeroCount = 0
+		}
+		return -1, -1
+	}
+
+	index, length := nextStartCode(0)
+	if index == -1 {
+		emit(nals)
+		return
+	}
+
+	for index != -1 {
+		start := index + length
+		if index, length = nextStartCode(start); index != -1 {
+			emit(nals[start:index])
+		} else {
+			emit(nals[start:])
+		}
+	}
+}
+
+// H265Payloader payloads H265 packets, as described in RFC 7798
+type H265Payloader struct{}
+
+// Payload fragments a H265 access unit in Annex B format across one or more byte arrays.
+// NAL units that fit into the MTU together are sent as an aggregation packet (AP), NAL
+// units that are larger than the MTU are sent as fragmentation units (FU).
+func (p *H265Payloader) Payload(mtu int, payload []byte) [][]byte {
+	var payloads [][]byte
+	if len(payload) == 0 || mtu <= h265NALUHeaderSize+h265FUHeaderSize {
+		return payloads
+	}
+
+	var aggregated [][]byte
+	aggregatedSize := h265NALUHeaderSize
+
+	flushAggregated := func() {
+		switch len(aggregated) {
+		case 0:
+			return
+		case 1:
+			payloads = append(payloads, append([]byte{}, aggregated[0]...))
+		default:
+			payloads = append(payloads, marshalH265AP(aggregated, aggregatedSize))
+		}
+		aggregated = aggregated[:0]
+		aggregatedSize = h265NALUHeaderSize
+	}
+
+	emitNALUs(payload, func(nalu []byte) {
+		if len(nalu) < h265NALUHeaderSize {
+			return
+		}
+
+		if naluType := h265NALUType(nalu); naluType == h265NALUTypeAUD || naluType == h265NALUTypeFD {
+			return
+		}
+
+		if len(nalu) <= mtu {
+			if aggregatedSize+h265APNALUSize+len(nalu) > mtu {
+				flushAggregated()
+			}
+			aggregated = append(aggregated, nalu)
+			aggregatedSize += h265APNALUSize + len(nalu)
+			return
+		}
+
+		flushAggregated()
+		payloads = append(payloads, marshalH265FU(mtu, nalu)...)
+	})
+	flushAggregated()
+
+	return payloads
+}
+
+// marshalH265AP builds an aggregation packet of the given NAL units
+func marshalH265AP(nalus [][]byte, size int) []byte {
+	/*
+	 * https://tools.ietf.org/html/rfc7798#section-4.4.2
+	 *
+	 *  0                   1
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |F|   Type    |  LayerId  | TID |
+	 * +-------------+-----------------+
+	 *
+	 * F is set if any aggregated NAL unit has it set, LayerId and TID are the
+	 * lowest of all aggregated NAL units
+	 */
+	forbidden := uint16(0)
+	layerID, tid := uint16(h265LayerIDMask), uint16(h265TIDMask)
+	for _, nalu := range nalus {
+		header := binary.BigEndian.Uint16(nalu)
+		forbidden |= header & (h265ForbiddenBitMask << 8)
+		if header&h265LayerIDMask < layerID {
+			layerID = header & h265LayerIDMask
+		}
+		if header&h265TIDMask < tid {
+			tid = header & h265TIDMask
+		}
+	}
+
+	out := make([]byte, h265NALUHeaderSize, size)
+	binary.BigEndian.PutUint16(out, forbidden|h265NALUTypeAP<<(8+h265NALUTypeShift)|layerID|tid)
+	for _, nalu := range nalus {
+		out = append(out, byte(len(nalu)>>8), byte(len(nalu)))
+		out = append(out, nalu...)
+	}
+	return out
+}
+
+// marshalH265FU splits a NAL unit into fragmentation units of at most mtu bytes
+func marshalH265FU(mtu int, nalu []byte) [][]byte {
+	/*
+	 * https://tools.ietf.org/html/rfc7798#section-4.4.3
+	 *
+	 * +---------------+
+	 * |0|1|2|3|4|5|6|7|
+	 * +-+-+-+-+-+-+-+-+
+	 * |S|E|  FuType   |
+	 * +---------------+
+	 */
+	var payloads [][]byte
+
+	naluType := h265NALUType(nalu)
+	data := nalu[h265NALUHeaderSize:]
+	maxFragmentSize := mtu - h265NALUHeaderSize - h265FUHeaderSize
+
+	for first := true; len(data) > 0; first = false {
+		fragmentSize := maxFragmentSize
+		if len(data) < fragmentSize {
+			fragmentSize = len(data)
+		}
+
+		out := make([]byte, h265NALUHeaderSize+h265FUHeaderSize+fragmentSize)
+		out[0] = nalu[0]&^h265NALUTypeMask | h265NALUTypeFU<<h265NALUTypeShift
+		out[1] = nalu[1]
+		out[2] = naluType
+		if first {
+			out[2] |= h265FUStartBitmask
+		}
+		if fragmentSize == len(data) {
+			out[2] |= h265FUEndBitmask
+		}
+		copy(out[h265NALUHeaderSize+h265FUHeaderSize:], data[:fragmentSize])
+
+		payloads = append(payloads, out)
+		data = data[fragmentSize:]
+	}
+
+	return payloads
+}
+
+// H265Packet represents the H265 header that is stored in the payload of an RTP Packet.
+// Only streams without decoding order numbers (sprop-max-don-diff=0) are supported.
+type H265Packet struct {
+	fuBuffer []byte
+}
+
+// IsDetectedFinalPacketInSequence returns true of the packet passed in has the
+// marker bit set indicated the end of a packet sequence
+func (p *H265Packet) IsDetectedFinalPacketInSequence(rtpPacketMarketBit bool) bool {
+	return rtpPacketMarketBit
+}
+
+// Unmarshal parses the passed byte slice and returns the NAL units it contains in Annex B
+// format. Fragmented NAL units are returned when their last fragment is unmarshaled.
+func (p *H265Packet) Unmarshal(payload []byte) ([]byte, error) {
+	if payload == nil {
+		return nil, errNilPacket
+	} else if len(payload) <= h265NALUHeaderSize {
+		return nil, fmt.Errorf("%w: %d <= %d", errShortPacket, len(payload), h265NALUHeaderSize)
+	}
+
+	switch naluType := h265NALUType(payload); naluType {
+	case h265NALUTypeAP:
+		result := []byte{}
+		for offset := h265NALUHeaderSize; offset < len(payload); {
+			if len(payload) < offset+h265APNALUSize {
+				return nil, fmt.Errorf("%w: AP NAL unit size is truncated", errShortPacket)
+			}
+			naluSize := int(binary.BigEndian.Uint16(payload[offset:]))
+			offset += h265APNALUSize
+
+			if len(payload) < offset+naluSize {
+				return nil, fmt.Errorf("%w: AP declared size(%d) is larger than buffer(%d)", errShortPacket, naluSize, len(payload)-offset)
+			}
+
+			result = append(result, annexbNALUStartCode()...)
+			result = append(result, payload[offset:offset+naluSize]...)
+			offset += naluSize
+		}
+		return result, nil
+
+	case h265NALUTypeFU:
+		if len(payload) <= h265NALUHeaderSize+h265FUHeaderSize {
+			return nil, errShortPacket
+		}
+
+		fuHeader := payload[h265NALUHeaderSize]
+		if fuHeader&h265FUStartBitmask != 0 {
+			p.fuBuffer = append(p.fuBuffer[:0],
+				payload[0]&^h265NALUTypeMask|(fuHeader&h265FUTypeMask)<<h265NALUTypeShift,
+				payload[1],
+			)
+		} else if len(p.fuBuffer) == 0 {
+			// The start of this NAL unit was lost
+			return []byte{}, nil
+		}
+		p.fuBuffer = append(p.fuBuffer, payload[h265NALUHeaderSize+h265FUHeaderSize:]...)
+
+		if fuHeader&h265FUEndBitmask == 0 {
+			return []byte{}, nil
+		}
+
+		result := append(annexbNALUStartCode(), p.fuBuffer...)
+		p.fuBuffer = p.fuBuffer[:0]
+		return result, nil
+
+	case h265NALUTypePACI:
+		return nil, fmt.Errorf("%w: %d", errUnhandledNALUType, naluType)
+
+	default:
+		return append(annexbNALUStartCode(), payload...), nil
+	}
+}
+
+// H265PartitionHeadChecker checks H265 partition head
+type H265PartitionHeadChecker struct{}
+
+// IsPartitionHead checks if this is the head of a packetized nalu stream.
+func (*H265PartitionHeadChecker) IsPartitionHead(packet []byte) bool {
+	if len(packet) <= h265NALUHeaderSize {
+		return false
+	}
+
+	if h265NALUType(packet) == h265NALUTypeFU {
+		return packet[h265NALUHeaderSize]&h265FUStartBitmask != 0
+	}
+
+	return true
+}
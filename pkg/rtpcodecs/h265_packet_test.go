@@ -0,0 +1,134 @@
+package rtpcodecs
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestH265Payloader(t *testing.T) {
+	vps := []byte{0x40, 0x01, 0x0C, 0x01}
+	sps := []byte{0x42, 0x01, 0x01, 0x01, 0x60}
+	pps := []byte{0x44, 0x01, 0xC1, 0x72}
+	aud := []byte{0x46, 0x01, 0x50}
+	idr := append([]byte{0x26, 0x01}, bytes.Repeat([]byte{0xAF}, 100)...)
+
+	accessUnit := []byte{}
+	for _, nalu := range [][]byte{aud, vps, sps, pps, idr} {
+		accessUnit = append(accessUnit, annexbNALUStartCode()...)
+		accessUnit = append(accessUnit, nalu...)
+	}
+
+	t.Run("Aggregation", func(t *testing.T) {
+		pck := H265Payloader{}
+		res := pck.Payload(1500, accessUnit)
+		if len(res) != 1 {
+			t.Fatalf("Expected one packet, got %d", len(res))
+		}
+		if naluType := h265NALUType(res[0]); naluType != h265NALUTypeAP {
+			t.Fatalf("Expected an AP, got type %d", naluType)
+		}
+		if !bytes.Equal(res[0][:4], []byte{0x60, 0x01, 0x00, 0x04}) {
+			t.Fatalf("Unexpected AP header %v", res[0][:4])
+		}
+	})
+
+	t.Run("Fragmentation", func(t *testing.T) {
+		pck := H265Payloader{}
+		res := pck.Payload(40, accessUnit)
+		if len(res) != 4 {
+			t.Fatalf("Expected 4 packets, got %d", len(res))
+		}
+
+		if naluType := h265NALUType(res[0]); naluType != h265NALUTypeAP {
+			t.Fatalf("Expected an AP, got type %d", naluType)
+		}
+		for i, p := range res[1:] {
+			if len(p) > 40 {
+				t.Fatalf("Packet %d exceeds the MTU", i)
+			}
+			if naluType := h265NALUType(p); naluType != h265NALUTypeFU {
+				t.Fatalf("Expected a FU, got type %d", naluType)
+			}
+			if p[2]&h265FUTypeMask != 19 {
+				t.Fatalf("Expected FU of type 19, got %d", p[2]&h265FUTypeMask)
+			}
+		}
+		if res[1][2]&h265FUStartBitmask == 0 || res[3][2]&h265FUEndBitmask == 0 {
+			t.Fatal("FU start and end bits are not set")
+		}
+	})
+
+	t.Run("Single NAL unit", func(t *testing.T) {
+		pck := H265Payloader{}
+		res := pck.Payload(1500, idr)
+		if len(res) != 1 || !bytes.Equal(res[0], idr) {
+			t.Fatalf("Expected the NAL unit to be sent as is, got %v", res)
+		}
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		expected := accessUnit[len(annexbNALUStartCode())+len(aud):]
+
+		for _, mtu := range []int{10, 40, 1500} {
+			pck := H265Payloader{}
+			depacketizer := &H265Packet{}
+
+			out := []byte{}
+			for _, p := range pck.Payload(mtu, accessUnit) {
+				nalus, err := depacketizer.Unmarshal(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out = append(out, nalus...)
+			}
+
+			if !bytes.Equal(out, expected) {
+				t.Fatalf("MTU %d: expected %v, got %v", mtu, expected, out)
+			}
+		}
+	})
+}
+
+func TestH265Packet_Unmarshal(t *testing.T) {
+	pck := &H265Packet{}
+
+	if _, err := pck.Unmarshal(nil); !errors.Is(err, errNilPacket) {
+		t.Fatal("Nil payload must fail")
+	}
+	if _, err := pck.Unmarshal([]byte{0x26, 0x01}); !errors.Is(err, errShortPacket) {
+		t.Fatal("Payload without data must fail")
+	}
+	if _, err := pck.Unmarshal([]byte{0x60, 0x01, 0x00, 0x05, 0x26}); !errors.Is(err, errShortPacket) {
+		t.Fatal("AP with truncated NAL unit must fail")
+	}
+	if _, err := pck.Unmarshal([]byte{0x64, 0x01, 0x00}); !errors.Is(err, errUnhandledNALUType) {
+		t.Fatal("PACI must fail")
+	}
+
+	// The continuation of a FU whose start was lost is dropped
+	out, err := pck.Unmarshal([]byte{0x62, 0x01, 0x53, 0xAA})
+	if err != nil || len(out) != 0 {
+		t.Fatalf("Expected no NAL units, got %v %v", out, err)
+	}
+}
+
+func TestH265PartitionHeadChecker(t *testing.T) {
+	checker := &H265PartitionHeadChecker{}
+
+	for _, test := range []struct {
+		Name    string
+		Payload []byte
+		IsHead  bool
+	}{
+		{"Nil", nil, false},
+		{"Single NAL unit", []byte{0x26, 0x01, 0xAF}, true},
+		{"AP", []byte{0x60, 0x01, 0x00, 0x01, 0x40}, true},
+		{"FU start", []byte{0x62, 0x01, 0x93, 0xAF}, true},
+		{"FU continuation", []byte{0x62, 0x01, 0x13, 0xAF}, false},
+	} {
+		if isHead := checker.IsPartitionHead(test.Payload); isHead != test.IsHead {
+			t.Errorf("%s: expected %v, got %v", test.Name, test.IsHead, isHead)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package rtpcodecs
+
+const (
+	leb128ValueMask    = 0x7F
+	leb128ContinueMask = 0x80
+	leb128MaxBytes     = 8
+)
+
+// leb128Size returns the amount of bytes needed to encode v
+func leb128Size(v uint) int {
+	size := 1
+	for v >>= 7; v != 0; v >>= 7 {
+		size++
+	}
+	return size
+}
+
+// appendLEB128 appends v encoded as unsigned LEB128 to b
+func appendLEB128(b []byte, v uint) []byte {
+	for {
+		c := byte(v & leb128ValueMask)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|leb128ContinueMask)
+	}
+}
+
+// readLEB128 decodes an unsigned LEB128 value from the start of b and
+// returns it together with the amount of bytes read
+func readLEB128(b []byte) (uint, int, error) {
+	var v uint
+	for i := 0; i < len(b) && i < leb128MaxBytes; i++ {
+		v |= uint(b[i]&leb128ValueMask) << (7 * i)
+		if b[i]&leb128ContinueMask == 0 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, errInvalidLEB128
+}
@@ -0,0 +1,14 @@
+// Package rtpcodecs implements the RTP payload formats that are not provided by
+// github.com/pion/rtp/codecs. The types follow the same conventions, so they can
+// be used as a rtp.Payloader, rtp.Depacketizer or rtp.PartitionHeadChecker.
+package rtpcodecs
+
+import "errors"
+
+var (
+	errShortPacket       = errors.New("packet is not large enough")
+	errNilPacket         = errors.New("invalid nil packet")
+	errInvalidLEB128     = errors.New("invalid LEB128 value")
+	errInvalidOBUElement = errors.New("OBU element exceeds packet size")
+	errUnhandledNALUType = errors.New("NALU Type is unhandled")
+)
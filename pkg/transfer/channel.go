@@ -0,0 +1,71 @@
+package transfer
+
+import (
+	"context"
+	"io"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/internal/dcchannel"
+)
+
+// channel exchanges the frames of a transfer over a data channel, detached or not
+type channel struct {
+	ch *dcchannel.Channel
+}
+
+// newChannel takes over the OnOpen, OnClose and OnBufferedAmountLow handlers of dc,
+// whose messages are read with ReadMessage
+func newChannel(dc *webrtc.DataChannel) *channel {
+	return &channel{ch: dcchannel.New(dc)}
+}
+
+// release drops the messages received once the transfer returned
+func (c *channel) release() {
+	c.ch.Release()
+}
+
+// read returns the next frame received. The messages received before the data
+// channel closed are still read.
+func (c *channel) read(ctx context.Context) (frame, error) {
+	msg, err := c.ch.Read(ctx)
+	if err == io.EOF {
+		return frame{}, ErrClosed
+	} else if err != nil {
+		return frame{}, err
+	}
+	return decode(msg)
+}
+
+// poll returns the next frame if one was received already
+func (c *channel) poll() (frame, bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	f, err := c.read(ctx)
+	if err == context.Canceled {
+		return frame{}, false, nil
+	}
+	return f, true, err
+}
+
+func decode(msg []byte) (frame, error) {
+	f := frame{}
+	if err := f.unmarshal(msg); err != nil {
+		return frame{}, err
+	}
+	return f, nil
+}
+
+// write sends a frame once the data channel is open, and blocks while it buffers
+// too much data
+func (c *channel) write(ctx context.Context, f frame) error {
+	raw, err := f.marshal()
+	if err != nil {
+		return err
+	}
+
+	if err = c.ch.Write(ctx, raw); err == io.ErrClosedPipe {
+		return ErrClosed
+	}
+	return err
+}
@@ -0,0 +1,37 @@
+package transfer
+
+import (
+	"errors"
+)
+
+var (
+	// ErrClosed is returned when the data channel closes before the transfer completes
+	ErrClosed = errors.New("transfer: data channel closed")
+
+	// ErrChunkHashMismatch is returned by Receive when a chunk doesn't match its hash
+	ErrChunkHashMismatch = errors.New("transfer: chunk hash mismatch")
+
+	// ErrFileHashMismatch is returned by Receive when the received file doesn't match
+	// the hash of the file sent
+	ErrFileHashMismatch = errors.New("transfer: file hash mismatch")
+
+	// ErrInvalidOffset is returned when the transfer is resumed from an offset beyond
+	// the end of the file
+	ErrInvalidOffset = errors.New("transfer: invalid resume offset")
+
+	// ErrSizeMismatch is returned when the size of the data sent doesn't match the
+	// size of the metadata
+	ErrSizeMismatch = errors.New("transfer: size mismatch")
+
+	errUnexpectedFrame = errors.New("transfer: unexpected frame")
+	errFrameTooShort   = errors.New("transfer: frame too short")
+)
+
+// RemoteError is an error reported by the remote peer, which aborted the transfer
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return "transfer: remote error: " + e.Message
+}
@@ -0,0 +1,109 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+)
+
+const (
+	frameTypeOffer uint8 = iota + 1
+	frameTypeAccept
+	frameTypeChunk
+	frameTypeEnd
+	frameTypeResult
+)
+
+const (
+	frameTypeSize   = 1
+	frameOffsetSize = 8
+	// frameChunkHeaderSize is the size of the header of chunk and end frames, the
+	// type followed by the offset and the SHA-256 hash
+	frameChunkHeaderSize = frameTypeSize + frameOffsetSize + sha256.Size
+)
+
+// frame is a single message of the transfer protocol:
+//
+//   - offer: the metadata encoded in JSON, sent first by the sender
+//   - accept: the offset the receiver resumes from
+//   - chunk: the offset and the hash of the data that follows
+//   - end: the size of the file and the hash of the whole file
+//   - result: empty once the receiver verified the file, the error message otherwise
+type frame struct {
+	typ      uint8
+	offset   uint64
+	hash     [sha256.Size]byte
+	metadata Metadata
+	payload  []byte
+}
+
+func (f *frame) marshal() ([]byte, error) {
+	switch f.typ {
+	case frameTypeOffer:
+		metadata, err := json.Marshal(f.metadata)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{f.typ}, metadata...), nil
+	case frameTypeAccept:
+		raw := make([]byte, frameTypeSize+frameOffsetSize)
+		raw[0] = f.typ
+		binary.BigEndian.PutUint64(raw[frameTypeSize:], f.offset)
+		return raw, nil
+	case frameTypeChunk, frameTypeEnd:
+		raw := make([]byte, frameChunkHeaderSize, frameChunkHeaderSize+len(f.payload))
+		raw[0] = f.typ
+		binary.BigEndian.PutUint64(raw[frameTypeSize:], f.offset)
+		copy(raw[frameTypeSize+frameOffsetSize:], f.hash[:])
+		return append(raw, f.payload...), nil
+	default:
+		return append([]byte{f.typ}, f.payload...), nil
+	}
+}
+
+func (f *frame) unmarshal(raw []byte) error {
+	if len(raw) < frameTypeSize {
+		return errFrameTooShort
+	}
+	f.typ = raw[0]
+	raw = raw[frameTypeSize:]
+
+	switch f.typ {
+	case frameTypeOffer:
+		return json.Unmarshal(raw, &f.metadata)
+	case frameTypeAccept:
+		if len(raw) < frameOffsetSize {
+			return errFrameTooShort
+		}
+		f.offset = binary.BigEndian.Uint64(raw)
+	case frameTypeChunk, frameTypeEnd:
+		if len(raw) < frameChunkHeaderSize-frameTypeSize {
+			return errFrameTooShort
+		}
+		f.offset = binary.BigEndian.Uint64(raw)
+		copy(f.hash[:], raw[frameOffsetSize:])
+		f.payload = raw[frameOffsetSize+sha256.Size:]
+	case frameTypeResult:
+		f.payload = raw
+	default:
+		return errUnexpectedFrame
+	}
+	return nil
+}
+
+// resultFrame returns the frame reporting the outcome of the transfer to the sender
+func resultFrame(err error) frame {
+	f := frame{typ: frameTypeResult}
+	if err != nil {
+		f.payload = []byte(err.Error())
+	}
+	return f
+}
+
+// err returns the error carried by a result frame
+func (f *frame) err() error {
+	if len(f.payload) == 0 {
+		return nil
+	}
+	return &RemoteError{Message: string(f.payload)}
+}
@@ -0,0 +1,48 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrame(t *testing.T) {
+	for _, f := range []frame{
+		{typ: frameTypeOffer, metadata: Metadata{Name: "file.txt", Size: 1234}},
+		{typ: frameTypeOffer, metadata: Metadata{Name: "", Size: -1}},
+		{typ: frameTypeAccept, offset: 0xFFFFFFFFFF},
+		{typ: frameTypeChunk, offset: 16384, hash: sha256.Sum256([]byte("chunk")), payload: []byte("chunk")},
+		{typ: frameTypeEnd, offset: 32768, hash: sha256.Sum256([]byte("file")), payload: []byte{}},
+		{typ: frameTypeResult, payload: []byte("failed")},
+	} {
+		raw, err := f.marshal()
+		assert.NoError(t, err)
+
+		parsed := frame{}
+		assert.NoError(t, parsed.unmarshal(raw))
+		assert.Equal(t, f, parsed)
+	}
+
+	for _, raw := range [][]byte{
+		{},
+		{frameTypeAccept, 0x00, 0x00, 0x00},
+		{frameTypeChunk, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0xAB},
+	} {
+		assert.Equal(t, errFrameTooShort, (&frame{}).unmarshal(raw))
+	}
+	assert.Equal(t, errUnexpectedFrame, (&frame{}).unmarshal([]byte{0xFF}))
+}
+
+func TestResultFrame(t *testing.T) {
+	f := resultFrame(nil)
+	assert.NoError(t, f.err())
+
+	f = resultFrame(errors.New("failed"))
+	raw, err := f.marshal()
+	assert.NoError(t, err)
+	parsed := frame{}
+	assert.NoError(t, parsed.unmarshal(raw))
+	assert.Equal(t, &RemoteError{Message: "failed"}, parsed.err())
+}
@@ -0,0 +1,229 @@
+// Package transfer sends files over WebRTC data channels, with flow control,
+// integrity checks and resumption. Every chunk is sent with its SHA-256 hash, and
+// the receiver verifies the SHA-256 hash of the whole file once received.
+//
+// A transfer interrupted by the data channel closing, for example after an ICE
+// restart, is resumed by sending the file again over a new data channel: the
+// receiver tells the sender how many bytes it has already.
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// chunkSize is the size of the data sent in every chunk, which every
+// max-message-size allows along with the chunk header
+const chunkSize = 16 * 1024
+
+// Metadata describes the file being transferred
+type Metadata struct {
+	// Name is the name of the file
+	Name string `json:"name"`
+	// Size is the size of the file in bytes, or -1 if it isn't known in advance
+	Size int64 `json:"size"`
+}
+
+// File is where a received file is written. The data received is read back to
+// verify the hash of the whole file, so resumed transfers verify the data received
+// before too. *os.File implements File.
+type File interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// OpenFunc returns the file a transfer is written to, once its metadata is
+// received, along with the number of bytes of the file received already, from
+// which the transfer resumes.
+type OpenFunc func(Metadata) (f File, offset int64, err error)
+
+// Send sends the data read from r over dc, and returns once the receiver verified
+// it. Send takes over the OnOpen, OnClose and OnBufferedAmountLow handlers of dc, and
+// reads its messages with ReadMessage, so dc must not have an OnMessage handler. It
+// waits for dc to open. Detached data channels are supported, Send detaches them
+// itself once they are open.
+//
+// When the receiver resumes the transfer, the bytes it has already are read from r
+// and hashed but not sent, so r must always read the file from its start.
+func Send(ctx context.Context, dc *webrtc.DataChannel, metadata Metadata, r io.Reader) error {
+	c := newChannel(dc)
+	defer c.release()
+
+	if err := c.write(ctx, frame{typ: frameTypeOffer, metadata: metadata}); err != nil {
+		return err
+	}
+
+	f, err := c.read(ctx)
+	switch {
+	case err != nil:
+		return err
+	case f.typ == frameTypeResult:
+		return f.err()
+	case f.typ != frameTypeAccept:
+		return errUnexpectedFrame
+	}
+
+	offset := f.offset
+	if metadata.Size >= 0 && offset > uint64(metadata.Size) {
+		return abort(ctx, c, ErrInvalidOffset)
+	}
+
+	// The hash of the whole file covers the bytes received already
+	hash := sha256.New()
+	if _, err = io.CopyN(hash, r, int64(offset)); err != nil {
+		if err == io.EOF {
+			err = ErrInvalidOffset
+		}
+		return abort(ctx, c, err)
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			hash.Write(buf[:n]) // nolint:errcheck
+			if err = sendChunk(ctx, c, offset, buf[:n]); err != nil {
+				return err
+			}
+			offset += uint64(n)
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		} else if readErr != nil {
+			return abort(ctx, c, readErr)
+		}
+	}
+
+	if metadata.Size >= 0 && offset != uint64(metadata.Size) {
+		return abort(ctx, c, ErrSizeMismatch)
+	}
+
+	end := frame{typ: frameTypeEnd, offset: offset}
+	copy(end.hash[:], hash.Sum(nil))
+	if err = c.write(ctx, end); err != nil {
+		return err
+	}
+
+	f, err = c.read(ctx)
+	switch {
+	case err != nil:
+		return err
+	case f.typ != frameTypeResult:
+		return errUnexpectedFrame
+	}
+	return f.err()
+}
+
+// sendChunk sends a chunk, unless the receiver aborted the transfer
+func sendChunk(ctx context.Context, c *channel, offset uint64, data []byte) error {
+	f, ok, err := c.poll()
+	if err != nil {
+		return err
+	} else if ok {
+		// The receiver only sends a result before the end of the file to abort
+		if err = f.err(); f.typ == frameTypeResult && err != nil {
+			return err
+		}
+		return errUnexpectedFrame
+	}
+
+	chunk := frame{typ: frameTypeChunk, offset: offset, hash: sha256.Sum256(data), payload: data}
+	return c.write(ctx, chunk)
+}
+
+// Receiver receives a single file over a data channel
+type Receiver struct {
+	c *channel
+}
+
+// NewReceiver takes over the OnOpen, OnClose and OnBufferedAmountLow handlers of dc,
+// and reads its messages with ReadMessage, so dc must not have an OnMessage handler.
+// It must be called before dc opens, in the OnDataChannel handler. Detached data
+// channels are supported, the Receiver detaches them itself once they are open.
+func NewReceiver(dc *webrtc.DataChannel) *Receiver {
+	return &Receiver{c: newChannel(dc)}
+}
+
+// Receive receives the file, written to the file returned by open, and returns its
+// metadata once the file is verified. Receive must only be called once.
+func (r *Receiver) Receive(ctx context.Context, open OpenFunc) (Metadata, error) {
+	c := r.c
+	defer c.release()
+
+	f, err := c.read(ctx)
+	switch {
+	case err != nil:
+		return Metadata{}, err
+	case f.typ != frameTypeOffer:
+		return Metadata{}, errUnexpectedFrame
+	}
+	metadata := f.metadata
+
+	file, offset, err := open(metadata)
+	if err != nil {
+		return metadata, abort(ctx, c, err)
+	}
+	if offset < 0 || (metadata.Size >= 0 && offset > metadata.Size) {
+		return metadata, abort(ctx, c, ErrInvalidOffset)
+	}
+	if err = c.write(ctx, frame{typ: frameTypeAccept, offset: uint64(offset)}); err != nil {
+		return metadata, err
+	}
+
+	for {
+		if f, err = c.read(ctx); err != nil {
+			return metadata, err
+		}
+
+		switch f.typ {
+		case frameTypeChunk:
+			if f.offset != uint64(offset) {
+				return metadata, abort(ctx, c, fmt.Errorf("%w: chunk at %d, expected %d", errUnexpectedFrame, f.offset, offset))
+			}
+			if sha256.Sum256(f.payload) != f.hash {
+				return metadata, abort(ctx, c, ErrChunkHashMismatch)
+			}
+			if _, err = file.WriteAt(f.payload, offset); err != nil {
+				return metadata, abort(ctx, c, err)
+			}
+			offset += int64(len(f.payload))
+		case frameTypeEnd:
+			if err = verify(file, offset, f); err != nil {
+				return metadata, abort(ctx, c, err)
+			}
+			return metadata, c.write(ctx, resultFrame(nil))
+		case frameTypeResult:
+			return metadata, f.err()
+		default:
+			return metadata, abort(ctx, c, errUnexpectedFrame)
+		}
+	}
+}
+
+// verify checks the size and the hash of the whole file received
+func verify(file File, size int64, end frame) error {
+	if uint64(size) != end.offset {
+		return ErrSizeMismatch
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(file, 0, size)); err != nil {
+		return err
+	}
+	if !bytes.Equal(hash.Sum(nil), end.hash[:]) {
+		return ErrFileHashMismatch
+	}
+	return nil
+}
+
+// abort reports an error to the remote peer, which ends the transfer, and returns it
+func abort(ctx context.Context, c *channel, err error) error {
+	_ = c.write(ctx, resultFrame(err))
+	return err
+}
@@ -0,0 +1,183 @@
+// +build !js
+
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/internal/dctest"
+	"github.com/stretchr/testify/assert"
+)
+
+var errWriteFailed = errors.New("write failed")
+
+// memFile is a File in memory, whose writes fail once it holds limit bytes
+type memFile struct {
+	mu    sync.Mutex
+	data  []byte
+	limit int64
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return bytes.NewReader(f.data).ReadAt(p, off)
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.limit > 0 && off+int64(len(p)) > f.limit {
+		return 0, errWriteFailed
+	}
+	if end := off + int64(len(p)); end > int64(len(f.data)) {
+		f.data = append(f.data, make([]byte, end-int64(len(f.data)))...)
+	}
+	return copy(f.data[off:], p), nil
+}
+
+func (f *memFile) bytes() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]byte{}, f.data...)
+}
+
+// connectedPair returns two connected PeerConnections
+func connectedPair(t *testing.T, api *webrtc.API) (*webrtc.PeerConnection, *webrtc.PeerConnection) {
+	pcOffer, pcAnswer := dctest.NewPair(t, api)
+
+	connected := make(chan struct{})
+	pcOffer.OnDataChannel(func(*webrtc.DataChannel) {})
+	dc, err := pcOffer.CreateDataChannel("negotiation", nil)
+	assert.NoError(t, err)
+	dc.OnOpen(func() { close(connected) })
+
+	dctest.Signal(t, pcOffer, pcAnswer)
+	<-connected
+	return pcOffer, pcAnswer
+}
+
+type result struct {
+	sendErr, receiveErr error
+	metadata            Metadata
+}
+
+// transfer sends data over a new data channel, received into file
+func transfer(t *testing.T, pcOffer, pcAnswer *webrtc.PeerConnection, metadata Metadata, data []byte, file File, offset int64) result {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	received := make(chan result, 1)
+	pcAnswer.OnDataChannel(func(dc *webrtc.DataChannel) {
+		receiver := NewReceiver(dc)
+		go func() {
+			m, err := receiver.Receive(ctx, func(m Metadata) (File, int64, error) {
+				return file, offset, nil
+			})
+			received <- result{receiveErr: err, metadata: m}
+		}()
+	})
+
+	dc, err := pcOffer.CreateDataChannel("transfer", nil)
+	assert.NoError(t, err)
+
+	sendErr := Send(ctx, dc, metadata, bytes.NewReader(data))
+	res := <-received
+	res.sendErr = sendErr
+
+	assert.NoError(t, dc.Close())
+	return res
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	data := make([]byte, n)
+	_, err := rand.Read(data)
+	assert.NoError(t, err)
+	return data
+}
+
+func TestTransfer(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	dctest.Run(t, webrtc.SettingEngine{}, func(t *testing.T, api *webrtc.API) {
+		pcOffer, pcAnswer := connectedPair(t, api)
+		defer dctest.ClosePair(t, pcOffer, pcAnswer)
+
+		data := randomBytes(t, 1024*1024+100)
+		metadata := Metadata{Name: "file.bin", Size: int64(len(data))}
+
+		t.Run("Transfer", func(t *testing.T) {
+			file := &memFile{}
+			res := transfer(t, pcOffer, pcAnswer, metadata, data, file, 0)
+			assert.NoError(t, res.sendErr)
+			assert.NoError(t, res.receiveErr)
+			assert.Equal(t, metadata, res.metadata)
+			assert.Equal(t, data, file.bytes())
+		})
+
+		t.Run("UnknownSize", func(t *testing.T) {
+			file := &memFile{}
+			res := transfer(t, pcOffer, pcAnswer, Metadata{Name: "stream", Size: -1}, data, file, 0)
+			assert.NoError(t, res.sendErr)
+			assert.NoError(t, res.receiveErr)
+			assert.Equal(t, data, file.bytes())
+		})
+
+		t.Run("Empty", func(t *testing.T) {
+			file := &memFile{}
+			res := transfer(t, pcOffer, pcAnswer, Metadata{Name: "empty"}, nil, file, 0)
+			assert.NoError(t, res.sendErr)
+			assert.NoError(t, res.receiveErr)
+			assert.Empty(t, file.bytes())
+		})
+
+		t.Run("Resume", func(t *testing.T) {
+			// The first transfer is aborted by the receiver failing to write
+			file := &memFile{limit: 300000}
+			res := transfer(t, pcOffer, pcAnswer, metadata, data, file, 0)
+			assert.Equal(t, &RemoteError{Message: errWriteFailed.Error()}, res.sendErr)
+			assert.Equal(t, errWriteFailed, res.receiveErr)
+
+			// and resumed over a new data channel
+			received := int64(len(file.bytes()))
+			assert.Greater(t, received, int64(0))
+			file.limit = 0
+			res = transfer(t, pcOffer, pcAnswer, metadata, data, file, received)
+			assert.NoError(t, res.sendErr)
+			assert.NoError(t, res.receiveErr)
+			assert.Equal(t, data, file.bytes())
+		})
+
+		t.Run("Corrupted", func(t *testing.T) {
+			// The data received before doesn't match the file sent
+			file := &memFile{data: randomBytes(t, 1000)}
+			res := transfer(t, pcOffer, pcAnswer, metadata, data, file, 1000)
+			assert.Equal(t, &RemoteError{Message: ErrFileHashMismatch.Error()}, res.sendErr)
+			assert.Equal(t, ErrFileHashMismatch, res.receiveErr)
+		})
+
+		t.Run("InvalidOffset", func(t *testing.T) {
+			res := transfer(t, pcOffer, pcAnswer, metadata, data, &memFile{}, metadata.Size+1)
+			assert.Equal(t, &RemoteError{Message: ErrInvalidOffset.Error()}, res.sendErr)
+			assert.Equal(t, ErrInvalidOffset, res.receiveErr)
+		})
+
+		t.Run("SizeMismatch", func(t *testing.T) {
+			res := transfer(t, pcOffer, pcAnswer, Metadata{Name: "short", Size: 2000}, data[:1000], &memFile{}, 0)
+			assert.Equal(t, ErrSizeMismatch, res.sendErr)
+			assert.Equal(t, &RemoteError{Message: ErrSizeMismatch.Error()}, res.receiveErr)
+		})
+	})
+}
@@ -0,0 +1,97 @@
+package webrtc
+
+// PriorityType indicates the priority of a DataChannel, the share of the
+// SCTP association it is given when several data channels are sending.
+// https://w3c.github.io/webrtc-priority/#rtc-priority-type
+type PriorityType int
+
+const (
+	// PriorityTypeVeryLow is the lowest priority
+	PriorityTypeVeryLow PriorityType = iota + 1
+
+	// PriorityTypeLow is the default priority of data channels
+	PriorityTypeLow
+
+	// PriorityTypeMedium is twice the share of PriorityTypeLow
+	PriorityTypeMedium
+
+	// PriorityTypeHigh is four times the share of PriorityTypeLow
+	PriorityTypeHigh
+)
+
+// This is done this way because of a linter.
+const (
+	priorityTypeVeryLowStr = "very-low"
+	priorityTypeLowStr     = "low"
+	priorityTypeMediumStr  = "medium"
+	priorityTypeHighStr    = "high"
+)
+
+// The priorities of the DCEP DATA_CHANNEL_OPEN message, which are the
+// relative weights of the data channels.
+// https://tools.ietf.org/html/rfc8831#section-6.4
+const (
+	priorityTypeVeryLowWeight = 128
+	priorityTypeLowWeight     = 256
+	priorityTypeMediumWeight  = 512
+	priorityTypeHighWeight    = 1024
+)
+
+func newPriorityType(raw string) PriorityType {
+	switch raw {
+	case priorityTypeVeryLowStr:
+		return PriorityTypeVeryLow
+	case priorityTypeLowStr:
+		return PriorityTypeLow
+	case priorityTypeMediumStr:
+		return PriorityTypeMedium
+	case priorityTypeHighStr:
+		return PriorityTypeHigh
+	default:
+		return PriorityType(Unknown)
+	}
+}
+
+// newPriorityTypeFromWeight returns the priority of a DCEP priority, every
+// priority covers the weights up to its own
+func newPriorityTypeFromWeight(weight uint16) PriorityType {
+	switch {
+	case weight <= priorityTypeVeryLowWeight:
+		return PriorityTypeVeryLow
+	case weight <= priorityTypeLowWeight:
+		return PriorityTypeLow
+	case weight <= priorityTypeMediumWeight:
+		return PriorityTypeMedium
+	default:
+		return PriorityTypeHigh
+	}
+}
+
+func (p PriorityType) String() string {
+	switch p {
+	case PriorityTypeVeryLow:
+		return priorityTypeVeryLowStr
+	case PriorityTypeLow:
+		return priorityTypeLowStr
+	case PriorityTypeMedium:
+		return priorityTypeMediumStr
+	case PriorityTypeHigh:
+		return priorityTypeHighStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// weight returns the DCEP priority, unknown priorities have the weight of PriorityTypeLow
+func (p PriorityType) weight() uint16 {
+	switch p {
+	case PriorityTypeVeryLow:
+		return priorityTypeVeryLowWeight
+	case PriorityTypeMedium:
+		return priorityTypeMediumWeight
+	case PriorityTypeHigh:
+		return priorityTypeHighWeight
+	default:
+		return priorityTypeLowWeight
+	}
+}
@@ -0,0 +1,305 @@
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+)
+
+const (
+	redBlockHeaderSize       = 4
+	redPrimaryHeaderSize     = 1
+	redFollowBit             = 0x80
+	redPayloadTypeMask       = 0x7F
+	redMaxTimestampOffset    = 1<<14 - 1
+	redMaxBlockLength        = 1<<10 - 1
+	redTimestampOffsetShift  = 10
+	redBlockLengthMask       = 0x3FF
+	redMaxRedundancyDistance = 8
+)
+
+// WithREDDistance enables RFC 2198 redundant audio for every binding that negotiated a
+// audio/red codec carrying the codec of the track, like browsers do for Opus. Every packet
+// repeats the payloads of up to distance previous packets, so receivers can recover them
+// when they are lost. The distance is capped at 8 packets.
+func WithREDDistance(distance int) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.redDistance = distance
+	}
+}
+
+// redBlock is a single block of a RFC 2198 RED payload. The timestamp offset
+// is relative to the timestamp of the RTP packet and only set for redundant blocks.
+type redBlock struct {
+	payloadType     uint8
+	timestampOffset uint32
+	payload         []byte
+}
+
+// redPrimaryPayloadType returns the payload type of the primary encoding of a RED codec,
+// the first payload type of its fmtp line. Browsers send the same encoding in every block, like "111/111".
+func redPrimaryPayloadType(codec RTPCodecParameters) (PayloadType, bool) {
+	primary := strings.SplitN(codec.SDPFmtpLine, "/", 2)[0]
+	payloadType, err := strconv.ParseUint(primary, 10, 7)
+	if err != nil {
+		return 0, false
+	}
+	return PayloadType(payloadType), true
+}
+
+// findREDCodec returns the RED codec among codecs that carries the primary payload type
+func findREDCodec(codecs []RTPCodecParameters, primary PayloadType) (RTPCodecParameters, bool) {
+	for _, codec := range codecs {
+		if !strings.EqualFold(codec.MimeType, MimeTypeRED) {
+			continue
+		}
+		if payloadType, ok := redPrimaryPayloadType(codec); ok && payloadType == primary {
+			return codec, true
+		}
+	}
+	return RTPCodecParameters{}, false
+}
+
+func marshalRED(redundant []redBlock, primary redBlock) []byte {
+	/*
+	 * https://tools.ietf.org/html/rfc2198#section-3
+	 *
+	 *  0                   1                    2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |F|   block PT  |  timestamp offset         |   block length    |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *
+	 * The header of the primary block is only a single byte with F unset.
+	 */
+	size := redPrimaryHeaderSize + len(primary.payload)
+	for _, b := range redundant {
+		size += redBlockHeaderSize + len(b.payload)
+	}
+
+	out := make([]byte, 0, size)
+	for _, b := range redundant {
+		header := b.timestampOffset<<redTimestampOffsetShift | uint32(len(b.payload))
+		out = append(out, redFollowBit|b.payloadType, byte(header>>16), byte(header>>8), byte(header))
+	}
+	out = append(out, primary.payloadType&redPayloadTypeMask)
+	for _, b := range redundant {
+		out = append(out, b.payload...)
+	}
+	return append(out, primary.payload...)
+}
+
+// unmarshalRED returns the redundant blocks of a RED payload, oldest first, and the primary block.
+// The payloads point into payload.
+func unmarshalRED(payload []byte) ([]redBlock, redBlock, error) {
+	var redundant []redBlock
+	var lengths []int
+	offset := 0
+	for {
+		if len(payload) <= offset {
+			return nil, redBlock{}, errREDPayloadTooShort
+		}
+		if payload[offset]&redFollowBit == 0 {
+			break
+		}
+		if len(payload) < offset+redBlockHeaderSize {
+			return nil, redBlock{}, errREDPayloadTooShort
+		}
+
+		header := binary.BigEndian.Uint32(payload[offset:])
+		redundant = append(redundant, redBlock{
+			payloadType:     payload[offset] & redPayloadTypeMask,
+			timestampOffset: (header >> redTimestampOffsetShift) & redMaxTimestampOffset,
+		})
+		lengths = append(lengths, int(header&redBlockLengthMask))
+		offset += redBlockHeaderSize
+	}
+
+	primary := redBlock{payloadType: payload[offset] & redPayloadTypeMask}
+	offset += redPrimaryHeaderSize
+
+	for i, length := range lengths {
+		if len(payload) < offset+length {
+			return nil, redBlock{}, errREDPayloadTooShort
+		}
+		redundant[i].payload = payload[offset : offset+length]
+		offset += length
+	}
+	primary.payload = payload[offset:]
+
+	return redundant, primary, nil
+}
+
+// redEncoder wraps the packets of a binding into RED packets, repeating the
+// payloads of up to distance previous packets
+type redEncoder struct {
+	mu                 sync.Mutex
+	payloadType        PayloadType
+	distance           int
+	history            []redBlock
+	historyTimestamps  []uint32
+	lastSequenceNumber uint16
+}
+
+func newREDEncoder(payloadType PayloadType, distance int) *redEncoder {
+	if distance > redMaxRedundancyDistance {
+		distance = redMaxRedundancyDistance
+	}
+	return &redEncoder{payloadType: payloadType, distance: distance}
+}
+
+// encode returns the RED payload for a packet. Receivers assume the redundant blocks
+// belong to the packets right before it, so previous payloads that can't be repeated
+// end the redundancy, and a gap in the sequence numbers clears it.
+func (e *redEncoder) encode(header *rtp.Header, payload []byte) []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.history) != 0 && header.SequenceNumber != e.lastSequenceNumber+1 {
+		e.history, e.historyTimestamps = e.history[:0], e.historyTimestamps[:0]
+	}
+	e.lastSequenceNumber = header.SequenceNumber
+
+	size := redPrimaryHeaderSize + len(payload)
+	first := len(e.history)
+	for first > 0 {
+		b := e.history[first-1]
+		timestampOffset := header.Timestamp - e.historyTimestamps[first-1]
+		size += redBlockHeaderSize + len(b.payload)
+		if timestampOffset > redMaxTimestampOffset || len(b.payload) > redMaxBlockLength || size > rtpOutboundMTU {
+			break
+		}
+		first--
+	}
+
+	redundant := make([]redBlock, 0, len(e.history)-first)
+	for i := first; i < len(e.history); i++ {
+		b := e.history[i]
+		b.timestampOffset = header.Timestamp - e.historyTimestamps[i]
+		redundant = append(redundant, b)
+	}
+	out := marshalRED(redundant, redBlock{payloadType: header.PayloadType, payload: payload})
+
+	if e.distance > 0 {
+		if len(e.history) == e.distance {
+			e.history, e.historyTimestamps = e.history[1:], e.historyTimestamps[1:]
+		}
+		e.history = append(e.history, redBlock{payloadType: header.PayloadType, payload: append([]byte{}, payload...)})
+		e.historyTimestamps = append(e.historyTimestamps, header.Timestamp)
+	}
+
+	return out
+}
+
+// redReceiver decodes the RED packets of a TrackRemote into the packets they carry,
+// recovering lost packets from the redundant blocks
+type redReceiver struct {
+	payloadTypes map[PayloadType]bool
+
+	hasLastSequenceNumber bool
+	lastSequenceNumber    uint16
+
+	pending []redPacket
+}
+
+// redPacket is a packet decoded from a RED packet, with its own copy of the
+// attributes of the RED packet
+type redPacket struct {
+	raw        []byte
+	attributes interceptor.Attributes
+}
+
+// newREDReceiver returns a redReceiver for the RED payload types among codecs,
+// or nil if RED wasn't negotiated
+func newREDReceiver(codecs []RTPCodecParameters) *redReceiver {
+	r := &redReceiver{payloadTypes: map[PayloadType]bool{}}
+	for _, codec := range codecs {
+		if strings.EqualFold(codec.MimeType, MimeTypeRED) {
+			r.payloadTypes[codec.PayloadType] = true
+		}
+	}
+
+	if len(r.payloadTypes) == 0 {
+		return nil
+	}
+	return r
+}
+
+func (r *redReceiver) isRED(payloadType PayloadType) bool {
+	return r.payloadTypes[payloadType]
+}
+
+// handle decodes a RED packet into pending packets. Redundant blocks are only used for
+// packets that were lost, assuming they belong to the packets right before the RED packet
+// like browsers send them. The primary block is always returned, like a plain packet would be.
+func (r *redReceiver) handle(buf []byte, attributes interceptor.Attributes) {
+	packet := rtp.Packet{}
+	if err := packet.Unmarshal(buf); err != nil {
+		return
+	}
+
+	payload := packet.Payload
+	if packet.Padding && len(payload) != 0 && int(payload[len(payload)-1]) <= len(payload) {
+		payload = payload[:len(payload)-int(payload[len(payload)-1])]
+	}
+
+	redundant, primary, err := unmarshalRED(payload)
+	if err != nil {
+		return
+	}
+
+	for i, b := range redundant {
+		sequenceNumber := packet.SequenceNumber - uint16(len(redundant)-i)
+		if !r.hasLastSequenceNumber || int16(sequenceNumber-r.lastSequenceNumber) <= 0 || len(b.payload) == 0 {
+			continue
+		}
+
+		recovered := rtp.Packet{
+			Header: rtp.Header{
+				Version:        packet.Version,
+				PayloadType:    b.payloadType,
+				SequenceNumber: sequenceNumber,
+				Timestamp:      packet.Timestamp - b.timestampOffset,
+				SSRC:           packet.SSRC,
+				CSRC:           packet.CSRC,
+			},
+			Payload: b.payload,
+		}
+		r.push(&recovered, cloneAttributes(attributes))
+	}
+
+	packet.PayloadType = primary.payloadType
+	packet.Payload = primary.payload
+	packet.Padding = false
+	r.push(&packet, cloneAttributes(attributes))
+
+	if !r.hasLastSequenceNumber || int16(packet.SequenceNumber-r.lastSequenceNumber) > 0 {
+		r.lastSequenceNumber = packet.SequenceNumber
+		r.hasLastSequenceNumber = true
+	}
+}
+
+func (r *redReceiver) push(packet *rtp.Packet, attributes interceptor.Attributes) {
+	raw, err := packet.Marshal()
+	if err != nil {
+		return
+	}
+	r.pending = append(r.pending, redPacket{raw: raw, attributes: attributes})
+}
+
+// pop copies the oldest pending packet into b
+func (r *redReceiver) pop(b []byte) (int, interceptor.Attributes, bool) {
+	if len(r.pending) == 0 {
+		return 0, nil, false
+	}
+
+	packet := r.pending[0]
+	r.pending = r.pending[1:]
+	return copy(b, packet.raw), packet.attributes, true
+}
@@ -0,0 +1,273 @@
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	mock_interceptor "github.com/pion/interceptor/pkg/mock"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRED(t *testing.T) {
+	redundant := []redBlock{
+		{payloadType: 111, timestampOffset: 1920, payload: []byte{0x01, 0x02}},
+		{payloadType: 111, timestampOffset: 960, payload: []byte{0x03}},
+	}
+	primary := redBlock{payloadType: 111, payload: []byte{0x04, 0x05, 0x06}}
+
+	raw := marshalRED(redundant, primary)
+	assert.Equal(t, []byte{
+		0xEF, 0x1E, 0x00, 0x02,
+		0xEF, 0x0F, 0x00, 0x01,
+		0x6F,
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06,
+	}, raw)
+
+	parsedRedundant, parsedPrimary, err := unmarshalRED(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, redundant, parsedRedundant)
+	assert.Equal(t, primary, parsedPrimary)
+
+	for _, length := range []int{0, 3, 9, 11} {
+		_, _, err = unmarshalRED(raw[:length])
+		assert.Equal(t, errREDPayloadTooShort, err, "length %d", length)
+	}
+}
+
+func TestFindREDCodec(t *testing.T) {
+	codecs := []RTPCodecParameters{
+		{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeOpus}, PayloadType: 111},
+		{RTPCodecCapability: RTPCodecCapability{MimeType: "audio/RED", SDPFmtpLine: "invalid"}, PayloadType: 62},
+		{RTPCodecCapability: RTPCodecCapability{MimeType: "audio/RED", SDPFmtpLine: "111/111"}, PayloadType: 63},
+	}
+
+	red, ok := findREDCodec(codecs, 111)
+	assert.True(t, ok)
+	assert.Equal(t, PayloadType(63), red.PayloadType)
+
+	_, ok = findREDCodec(codecs, 0)
+	assert.False(t, ok)
+}
+
+func TestDefaultREDCodec(t *testing.T) {
+	m := MediaEngine{}
+	assert.NoError(t, m.RegisterDefaultCodecs())
+	red, ok := findREDCodec(m.audioCodecs, 111)
+	assert.True(t, ok)
+	assert.Equal(t, "111/111", red.SDPFmtpLine)
+
+	// The RED codec follows Opus when it was registered with another payload type
+	m = MediaEngine{}
+	assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeOpus, 48000, 2, "minptime=10;useinbandfec=1", nil},
+		PayloadType:        109,
+	}, RTPCodecTypeAudio))
+	assert.NoError(t, m.RegisterDefaultCodecs())
+	red, ok = findREDCodec(m.audioCodecs, 109)
+	assert.True(t, ok)
+	assert.Equal(t, "109/109", red.SDPFmtpLine)
+}
+
+func TestREDEncoder(t *testing.T) {
+	e := newREDEncoder(63, 2)
+
+	encode := func(sequenceNumber uint16, timestamp uint32, payload []byte) ([]redBlock, redBlock) {
+		redundant, primary, err := unmarshalRED(e.encode(&rtp.Header{PayloadType: 111, SequenceNumber: sequenceNumber, Timestamp: timestamp}, payload))
+		assert.NoError(t, err)
+		return redundant, primary
+	}
+
+	redundant, primary := encode(1, 960, []byte{0x01})
+	assert.Empty(t, redundant)
+	assert.Equal(t, redBlock{payloadType: 111, payload: []byte{0x01}}, primary)
+
+	encode(2, 1920, []byte{0x02})
+	redundant, primary = encode(3, 2880, []byte{0x03})
+	assert.Equal(t, []redBlock{
+		{payloadType: 111, timestampOffset: 1920, payload: []byte{0x01}},
+		{payloadType: 111, timestampOffset: 960, payload: []byte{0x02}},
+	}, redundant)
+	assert.Equal(t, []byte{0x03}, primary.payload)
+
+	t.Run("Distance", func(t *testing.T) {
+		redundant, _ := encode(4, 3840, []byte{0x04})
+		assert.Equal(t, 2, len(redundant))
+		assert.Equal(t, []byte{0x02}, redundant[0].payload)
+	})
+
+	t.Run("Timestamp offset too large", func(t *testing.T) {
+		redundant, _ := encode(5, 3840+redMaxTimestampOffset+1, []byte{0x05})
+		assert.Empty(t, redundant)
+	})
+
+	t.Run("Sequence number gap", func(t *testing.T) {
+		redundant, _ := encode(7, 30000, []byte{0x07})
+		assert.Empty(t, redundant)
+	})
+}
+
+func TestREDReceiver(t *testing.T) {
+	assert.Nil(t, newREDReceiver([]RTPCodecParameters{{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeOpus}, PayloadType: 111}}))
+
+	r := newREDReceiver([]RTPCodecParameters{{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeRED}, PayloadType: 63}})
+	assert.True(t, r.isRED(63))
+	assert.False(t, r.isRED(111))
+
+	e := newREDEncoder(63, 2)
+	redPacket := func(sequenceNumber uint16) []byte {
+		header := rtp.Header{Version: 2, PayloadType: 111, SequenceNumber: sequenceNumber, Timestamp: uint32(sequenceNumber) * 960, SSRC: 5000}
+		payload := e.encode(&header, []byte{byte(sequenceNumber)})
+		header.PayloadType = 63
+
+		raw, err := (&rtp.Packet{Header: header, Payload: payload}).Marshal()
+		assert.NoError(t, err)
+		return raw
+	}
+
+	received := func() []rtp.Packet {
+		packets := []rtp.Packet{}
+		b := make([]byte, receiveMTU)
+		for {
+			n, _, ok := r.pop(b)
+			if !ok {
+				return packets
+			}
+
+			p := rtp.Packet{}
+			assert.NoError(t, p.Unmarshal(append([]byte{}, b[:n]...)))
+			packets = append(packets, p)
+		}
+	}
+
+	assertPackets := func(sequenceNumbers ...uint16) {
+		packets := received()
+		assert.Equal(t, len(sequenceNumbers), len(packets))
+		for i, p := range packets {
+			assert.Equal(t, uint8(111), p.PayloadType)
+			assert.Equal(t, uint32(5000), p.SSRC)
+			assert.Equal(t, sequenceNumbers[i], p.SequenceNumber)
+			assert.Equal(t, uint32(sequenceNumbers[i])*960, p.Timestamp)
+			assert.Equal(t, []byte{byte(sequenceNumbers[i])}, p.Payload)
+		}
+	}
+
+	r.handle(redPacket(1), nil)
+	assertPackets(1)
+
+	// Packets that weren't lost aren't repeated
+	redPacket(2)
+	r.handle(redPacket(3), nil)
+	assertPackets(2, 3)
+
+	// Losses beyond the redundancy distance can't be recovered
+	redPacket(4)
+	redPacket(5)
+	redPacket(6)
+	r.handle(redPacket(7), nil)
+	assertPackets(5, 6, 7)
+
+	// Late packets are passed on like plain packets
+	r.handle(redPacket(8), nil)
+	r.handle(redPacket(8), nil)
+	assertPackets(8, 8)
+
+	// Every packet gets its own copy of the attributes of the RED packet
+	redPacket(9)
+	r.handle(redPacket(10), interceptor.Attributes{"key": "value"})
+	b := make([]byte, receiveMTU)
+	_, first, ok := r.pop(b)
+	assert.True(t, ok)
+	_, second, ok := r.pop(b)
+	assert.True(t, ok)
+	first["key"] = "modified"
+	assert.Equal(t, "value", second["key"])
+}
+
+func TestREDPeerConnection(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// Every third packet the offerer sends is lost
+	var sentMu sync.Mutex
+	sent := 0
+	ir := &interceptor.Registry{}
+	ir.Add(&mock_interceptor.Interceptor{
+		BindLocalStreamFn: func(_ *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+			return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+				assert.Equal(t, uint8(63), header.PayloadType)
+
+				sentMu.Lock()
+				sent++
+				lost := sent%3 == 0
+				sentMu.Unlock()
+				if lost {
+					return len(payload), nil
+				}
+				return writer.Write(header, payload, attributes)
+			})
+		},
+	})
+
+	m := &MediaEngine{}
+	assert.NoError(t, m.RegisterDefaultCodecs())
+	pcOffer, err := NewAPI(WithMediaEngine(m), WithInterceptorRegistry(ir)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion", WithREDDistance(1))
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	received, receivedCancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		assert.Equal(t, MimeTypeOpus, trackRemote.Codec().MimeType)
+
+		var last *rtp.Packet
+		for i := 0; i < 20; i++ {
+			p, _, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			assert.Equal(t, uint8(111), p.PayloadType)
+			if last != nil {
+				assert.Equal(t, last.SequenceNumber+1, p.SequenceNumber)
+				assert.Equal(t, last.Payload[0]+1, p.Payload[0])
+			}
+			last = p
+		}
+		receivedCancel()
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			select {
+			case <-received.Done():
+				return
+			case <-ticker.C:
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{byte(i)}, Duration: 20 * time.Millisecond}))
+			}
+		}
+	}()
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
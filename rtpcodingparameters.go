@@ -1,10 +1,17 @@
 package webrtc
 
+// RTPFecParameters provides information relating to forward error correction (FEC) settings.
+// http://draft.ortc.org/#dom-rtcrtpfecparameters
+type RTPFecParameters struct {
+	SSRC SSRC `json:"ssrc"`
+}
+
 // RTPCodingParameters provides information relating to both encoding and decoding.
 // This is a subset of the RFC since Pion WebRTC doesn't implement encoding/decoding itself
 // http://draft.ortc.org/#dom-rtcrtpcodingparameters
 type RTPCodingParameters struct {
-	RID         string      `json:"rid"`
-	SSRC        SSRC        `json:"ssrc"`
-	PayloadType PayloadType `json:"payloadType"`
+	RID         string           `json:"rid"`
+	SSRC        SSRC             `json:"ssrc"`
+	PayloadType PayloadType      `json:"payloadType"`
+	FEC         RTPFecParameters `json:"fec"`
 }
@@ -0,0 +1,28 @@
+package webrtc
+
+import (
+	"time"
+
+	"github.com/pion/webrtc/v3/pkg/media"
+)
+
+// CSRCAudioLevelURI is the URI of the RFC 6465 csrc-audio-level header extension, in which
+// mixers send the level of every contributing source of a packet
+const CSRCAudioLevelURI = "urn:ietf:params:rtp-hdrext:csrc-audio-level"
+
+// RTPContributingSource contains information about the latest RTP packet received
+// from a contributing source (CSRC), usually a participant mixed into the audio by a MCU.
+// https://www.w3.org/TR/webrtc/#dom-rtcrtpcontributingsource
+type RTPContributingSource struct {
+	// Timestamp is the local time the latest packet was received
+	Timestamp time.Time
+	// Source is the CSRC of the contributing source
+	Source SSRC
+	// RTPTimestamp is the RTP timestamp of the latest packet
+	RTPTimestamp uint32
+	// AudioLevel is the level of the source in the latest packet, taken from the
+	// csrc-audio-level header extension. It is nil if the extension wasn't negotiated
+	// or the packet didn't carry it. Voice is always false, as RFC 6465 has no voice
+	// activity flag.
+	AudioLevel *media.AudioLevel
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/pion/rtcp"
 	"github.com/pion/srtp/v2"
 	"github.com/pion/webrtc/v3/internal/util"
+	"github.com/pion/webrtc/v3/pkg/flexfec"
 )
 
 // trackStreams maintains a mapping of RTP/RTCP streams to a specific track
@@ -26,6 +27,11 @@ type trackStreams struct {
 
 	rtcpReadStream  *srtp.ReadStreamSRTCP
 	rtcpInterceptor interceptor.RTCPReader
+
+	// the FEC stream is only opened if FlexFEC was negotiated and the remote declared it
+	fecStreamInfo  interceptor.StreamInfo
+	fecReadStream  *srtp.ReadStreamSRTP
+	fecInterceptor interceptor.RTPReader
 }
 
 // RTPReceiver allows an application to inspect the receipt of a TrackRemote
@@ -98,6 +104,46 @@ func (r *RTPReceiver) Tracks() []*TrackRemote {
 	return tracks
 }
 
+// GetSynchronizationSources returns information about the latest packet read from every
+// track of this RTPReceiver. Like in WebRTC, tracks that haven't been read from for
+// longer than 10 seconds are omitted. The information is only updated while the tracks are read.
+func (r *RTPReceiver) GetSynchronizationSources() []RTPSynchronizationSource {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	sources := []RTPSynchronizationSource{}
+	for i := range r.tracks {
+		if source, ok := r.tracks[i].track.SynchronizationSource(); ok && now.Sub(source.Timestamp) <= synchronizationSourceTimeout {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// GetContributingSources returns information about the latest packet every CSRC contributed
+// to, for the CSRCs in packets read from the tracks of this RTPReceiver within the last
+// 10 seconds. The information is only updated while the tracks are read.
+func (r *RTPReceiver) GetContributingSources() []RTPContributingSource {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sources := []RTPContributingSource{}
+	for i := range r.tracks {
+		sources = append(sources, r.tracks[i].track.ContributingSources()...)
+	}
+	return sources
+}
+
+func (r *RTPReceiver) collectStats(collector *statsReportCollector) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := range r.tracks {
+		r.tracks[i].track.collectStats(collector)
+	}
+}
+
 // Receive initialize the track and starts all the transports
 func (r *RTPReceiver) Receive(parameters RTPReceiveParameters) error {
 	r.mu.Lock()
@@ -120,6 +166,8 @@ func (r *RTPReceiver) Receive(parameters RTPReceiveParameters) error {
 		}
 
 		globalParams := r.GetParameters()
+		t.track.telephoneEvents = newTelephoneEventReceiver(globalParams.Codecs)
+		t.track.red = newREDReceiver(globalParams.Codecs)
 		codec := RTPCodecCapability{}
 		if len(globalParams.Codecs) != 0 {
 			codec = globalParams.Codecs[0].RTPCodecCapability
@@ -130,6 +178,17 @@ func (r *RTPReceiver) Receive(parameters RTPReceiveParameters) error {
 		if t.rtpReadStream, t.rtpInterceptor, t.rtcpReadStream, t.rtcpInterceptor, err = r.streamsForSSRC(parameters.Encodings[0].SSRC, t.streamInfo); err != nil {
 			return err
 		}
+		r.transport.addSenderReportTrack(t.track)
+
+		if fecCodec, ok := findFlexFECCodec(globalParams.Codecs); ok && parameters.Encodings[0].FEC.SSRC != 0 {
+			t.track.fec = flexfec.NewDecoder(uint32(parameters.Encodings[0].SSRC))
+			t.fecStreamInfo = createStreamInfo("", parameters.Encodings[0].FEC.SSRC, fecCodec.PayloadType, fecCodec.RTPCodecCapability, globalParams.HeaderExtensions)
+			if t.fecReadStream, t.fecInterceptor, err = r.fecStreamForSSRC(parameters.Encodings[0].FEC.SSRC, t.fecStreamInfo); err != nil {
+				return err
+			}
+
+			go r.readFEC(t.track, t.fecInterceptor)
+		}
 
 		r.tracks = append(r.tracks, t)
 	} else {
@@ -174,15 +233,24 @@ func (r *RTPReceiver) ReadSimulcast(b []byte, rid string) (n int, a interceptor.
 }
 
 // ReadRTCP is a convenience method that wraps Read and unmarshal for you.
-// It also runs any configured interceptors.
+// It also runs any configured interceptors. It allocates a new buffer on every
+// call, use ReadRTCPInto to avoid this.
 func (r *RTPReceiver) ReadRTCP() ([]rtcp.Packet, interceptor.Attributes, error) {
-	b := make([]byte, receiveMTU)
-	i, attributes, err := r.Read(b)
+	return r.ReadRTCPInto(make([]byte, receiveMTU))
+}
+
+// ReadRTCPInto reads a single compound RTCP packet into buf and unmarshals it.
+// buf should be at least receiveMTU (1460) bytes long.
+//
+// The packets returned may point into buf, so they are only valid until buf is
+// written to again.
+func (r *RTPReceiver) ReadRTCPInto(buf []byte) ([]rtcp.Packet, interceptor.Attributes, error) {
+	i, attributes, err := r.Read(buf)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	pkts, err := rtcp.Unmarshal(b[:i])
+	pkts, err := rtcp.Unmarshal(buf[:i])
 	if err != nil {
 		return nil, nil, err
 	}
@@ -236,8 +304,14 @@ func (r *RTPReceiver) Stop() error {
 				errs = append(errs, r.tracks[i].rtpReadStream.Close())
 			}
 
+			if r.tracks[i].fecReadStream != nil {
+				errs = append(errs, r.tracks[i].fecReadStream.Close())
+				r.api.interceptor.UnbindRemoteStream(&r.tracks[i].fecStreamInfo)
+			}
+
 			err = util.FlattenErrs(errs)
 			r.api.interceptor.UnbindRemoteStream(&r.tracks[i].streamInfo)
+			r.transport.removeSenderReportTrack(r.tracks[i].track)
 		}
 	default:
 	}
@@ -278,6 +352,8 @@ func (r *RTPReceiver) receiveForRid(rid string, params RTPParameters, ssrc SSRC)
 			r.tracks[i].track.codec = params.Codecs[0]
 			r.tracks[i].track.params = params
 			r.tracks[i].track.ssrc = ssrc
+			r.tracks[i].track.telephoneEvents = newTelephoneEventReceiver(r.GetParameters().Codecs)
+			r.tracks[i].track.red = newREDReceiver(r.GetParameters().Codecs)
 			r.tracks[i].streamInfo = createStreamInfo("", ssrc, params.Codecs[0].PayloadType, params.Codecs[0].RTPCodecCapability, params.HeaderExtensions)
 			r.tracks[i].track.mu.Unlock()
 
@@ -285,6 +361,7 @@ func (r *RTPReceiver) receiveForRid(rid string, params RTPParameters, ssrc SSRC)
 			if r.tracks[i].rtpReadStream, r.tracks[i].rtpInterceptor, r.tracks[i].rtcpReadStream, r.tracks[i].rtcpInterceptor, err = r.streamsForSSRC(ssrc, r.tracks[i].streamInfo); err != nil {
 				return nil, err
 			}
+			r.transport.addSenderReportTrack(r.tracks[i].track)
 
 			return r.tracks[i].track, nil
 		}
@@ -327,6 +404,39 @@ func (r *RTPReceiver) streamsForSSRC(ssrc SSRC, streamInfo interceptor.StreamInf
 	return rtpReadStream, rtpInterceptor, rtcpReadStream, rtcpInterceptor, nil
 }
 
+// fecStreamForSSRC opens the FEC stream of a track, only RTP is read from it
+func (r *RTPReceiver) fecStreamForSSRC(ssrc SSRC, streamInfo interceptor.StreamInfo) (*srtp.ReadStreamSRTP, interceptor.RTPReader, error) {
+	srtpSession, err := r.transport.getSRTPSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fecReadStream, err := srtpSession.OpenReadStream(uint32(ssrc))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fecInterceptor := r.api.interceptor.BindRemoteStream(&streamInfo, interceptor.RTPReaderFunc(func(in []byte, a interceptor.Attributes) (n int, attributes interceptor.Attributes, err error) {
+		n, err = fecReadStream.Read(in)
+		return n, a, err
+	}))
+
+	return fecReadStream, fecInterceptor, nil
+}
+
+// readFEC passes the FEC packets of a track to its decoder until the FEC stream is closed
+func (r *RTPReceiver) readFEC(track *TrackRemote, fecInterceptor interceptor.RTPReader) {
+	b := make([]byte, receiveMTU)
+	for {
+		n, _, err := fecInterceptor.Read(b, interceptor.Attributes{})
+		if err != nil {
+			return
+		}
+
+		track.handleFEC(b[:n])
+	}
+}
+
 // SetReadDeadline sets the max amount of time the RTCP stream will block before returning. 0 is forever.
 func (r *RTPReceiver) SetReadDeadline(t time.Time) error {
 	r.mu.RLock()
@@ -11,6 +11,7 @@ import (
 	"github.com/pion/randutil"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3/pkg/flexfec"
 )
 
 // RTPSender allows an application to control how a given Track is encoded and transmitted to a remote peer
@@ -25,9 +26,19 @@ type RTPSender struct {
 
 	transport *DTLSTransport
 
+	rtpTransceiver *RTPTransceiver
+
 	payloadType PayloadType
 	ssrc        SSRC
 
+	// fecSSRC is only used if FlexFEC is negotiated for video, the
+	// FEC packets are sent as a local stream of their own
+	fecSSRC       SSRC
+	fecStreamInfo interceptor.StreamInfo
+
+	// dtmf is only set for audio tracks
+	dtmf *DTMFSender
+
 	// nolint:godox
 	// TODO(sgotti) remove this when in future we'll avoid replacing
 	// a transceiver sender since we can just check the
@@ -68,6 +79,12 @@ func (api *API) NewRTPSender(track TrackLocal, transport *DTLSTransport) (*RTPSe
 
 	r.srtpStream.rtpSender = r
 
+	if track.Kind() == RTPCodecTypeAudio {
+		r.dtmf = newDTMFSender()
+	} else {
+		r.fecSSRC = SSRC(randutil.NewMathRandomGenerator().Uint32())
+	}
+
 	r.rtcpInterceptor = r.api.interceptor.BindRTCPReader(interceptor.RTPReaderFunc(func(in []byte, a interceptor.Attributes) (n int, attributes interceptor.Attributes, err error) {
 		n, err = r.srtpStream.Read(in)
 		return n, a, err
@@ -76,6 +93,12 @@ func (api *API) NewRTPSender(track TrackLocal, transport *DTLSTransport) (*RTPSe
 	return r, nil
 }
 
+func (r *RTPSender) setRTPTransceiver(rtpTransceiver *RTPTransceiver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rtpTransceiver = rtpTransceiver
+}
+
 func (r *RTPSender) isNegotiated() bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -99,7 +122,7 @@ func (r *RTPSender) Transport() *DTLSTransport {
 // GetParameters describes the current configuration for the encoding and
 // transmission of media on the sender's track.
 func (r *RTPSender) GetParameters() RTPSendParameters {
-	return RTPSendParameters{
+	parameters := RTPSendParameters{
 		RTPParameters: r.api.mediaEngine.getRTPParametersByKind(
 			r.track.Kind(),
 			[]RTPTransceiverDirection{RTPTransceiverDirectionSendonly},
@@ -113,6 +136,20 @@ func (r *RTPSender) GetParameters() RTPSendParameters {
 			},
 		},
 	}
+	if _, ok := r.fecCodec(); ok {
+		parameters.Encodings[0].FEC.SSRC = r.fecSSRC
+	}
+	return parameters
+}
+
+// fecCodec returns the FlexFEC codec if it is registered, or negotiated after the
+// remote description has been applied. FEC is only sent with video.
+func (r *RTPSender) fecCodec() (RTPCodecParameters, bool) {
+	if r.fecSSRC == 0 {
+		return RTPCodecParameters{}, false
+	}
+
+	return findFlexFECCodec(r.api.mediaEngine.getCodecsByKind(RTPCodecTypeVideo))
 }
 
 // Track returns the RTCRtpTransceiver track, or nil
@@ -122,6 +159,12 @@ func (r *RTPSender) Track() TrackLocal {
 	return r.track
 }
 
+// DTMF returns the DTMFSender that sends DTMF tones with the audio of this RTPSender,
+// or nil if the RTPSender doesn't send audio
+func (r *RTPSender) DTMF() *DTMFSender {
+	return r.dtmf
+}
+
 // ReplaceTrack replaces the track currently being used as the sender's source with a new TrackLocal.
 // The new track must be of the same media kind (audio, video, etc) and switching the track should not
 // require negotiation.
@@ -164,10 +207,15 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 
 	writeStream := &interceptorToTrackLocalWriter{}
 	r.context = TrackLocalContext{
-		id:          r.id,
-		params:      r.api.mediaEngine.getRTPParametersByKind(r.track.Kind(), []RTPTransceiverDirection{RTPTransceiverDirectionSendonly}),
-		ssrc:        parameters.Encodings[0].SSRC,
-		writeStream: writeStream,
+		id:                 r.id,
+		params:             r.api.mediaEngine.getRTPParametersByKind(r.track.Kind(), []RTPTransceiverDirection{RTPTransceiverDirectionSendonly}),
+		ssrc:               parameters.Encodings[0].SSRC,
+		writeStream:        writeStream,
+		transportSequencer: r.transport.nextTransportCCSequence,
+		writeReady:         r.transport.srtpReady,
+	}
+	if r.rtpTransceiver != nil {
+		r.context.mid = r.rtpTransceiver.Mid()
 	}
 
 	codec, err := r.track.Bind(r.context)
@@ -176,10 +224,25 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 	}
 	r.context.params.Codecs = []RTPCodecParameters{codec}
 
-	r.streamInfo = createStreamInfo(r.id, parameters.Encodings[0].SSRC, codec.PayloadType, codec.RTPCodecCapability, parameters.HeaderExtensions)
-	rtpInterceptor := r.api.interceptor.BindLocalStream(&r.streamInfo, interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+	srtpWriter := interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
 		return r.srtpStream.WriteRTP(header, payload)
-	}))
+	})
+
+	// The FEC stream has to be bound first, so the FlexFEC interceptor knows where to
+	// write the FEC packets protecting the media stream
+	if fecCodec, ok := r.fecCodec(); ok && parameters.Encodings[0].FEC.SSRC != 0 {
+		r.fecStreamInfo = createStreamInfo(r.id, parameters.Encodings[0].FEC.SSRC, fecCodec.PayloadType, fecCodec.RTPCodecCapability, parameters.HeaderExtensions)
+		flexfec.SetProtectedSSRC(&r.fecStreamInfo, uint32(parameters.Encodings[0].SSRC))
+		r.api.interceptor.BindLocalStream(&r.fecStreamInfo, srtpWriter)
+	}
+
+	r.streamInfo = createStreamInfo(r.id, parameters.Encodings[0].SSRC, codec.PayloadType, codec.RTPCodecCapability, parameters.HeaderExtensions)
+	rtpInterceptor := r.api.interceptor.BindLocalStream(&r.streamInfo, srtpWriter)
+	if r.dtmf != nil {
+		// Audio goes through the DTMFSender, so telephone-events can be interleaved with it
+		r.dtmf.bind(rtpInterceptor, parameters.Encodings[0].SSRC, codec, r.api.mediaEngine.getCodecsByKind(RTPCodecTypeAudio))
+		rtpInterceptor = interceptor.RTPWriterFunc(r.dtmf.writeRTP)
+	}
 	writeStream.interceptor.Store(rtpInterceptor)
 
 	close(r.sendCalled)
@@ -198,6 +261,10 @@ func (r *RTPSender) Stop() error {
 	close(r.stopCalled)
 	r.mu.Unlock()
 
+	if r.dtmf != nil {
+		r.dtmf.close()
+	}
+
 	if !r.hasSent() {
 		return nil
 	}
@@ -207,6 +274,9 @@ func (r *RTPSender) Stop() error {
 	}
 
 	r.api.interceptor.UnbindLocalStream(&r.streamInfo)
+	if r.fecStreamInfo.SSRC != 0 {
+		r.api.interceptor.UnbindLocalStream(&r.fecStreamInfo)
+	}
 
 	return r.srtpStream.Close()
 }
@@ -222,14 +292,23 @@ func (r *RTPSender) Read(b []byte) (n int, a interceptor.Attributes, err error)
 }
 
 // ReadRTCP is a convenience method that wraps Read and unmarshals for you.
+// It allocates a new buffer on every call, use ReadRTCPInto to avoid this.
 func (r *RTPSender) ReadRTCP() ([]rtcp.Packet, interceptor.Attributes, error) {
-	b := make([]byte, receiveMTU)
-	i, attributes, err := r.Read(b)
+	return r.ReadRTCPInto(make([]byte, receiveMTU))
+}
+
+// ReadRTCPInto reads a single compound RTCP packet into buf and unmarshals it.
+// buf should be at least receiveMTU (1460) bytes long.
+//
+// The packets returned may point into buf, so they are only valid until buf is
+// written to again.
+func (r *RTPSender) ReadRTCPInto(buf []byte) ([]rtcp.Packet, interceptor.Attributes, error) {
+	i, attributes, err := r.Read(buf)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	pkts, err := rtcp.Unmarshal(b[:i])
+	pkts, err := rtcp.Unmarshal(buf[:i])
 	if err != nil {
 		return nil, nil, err
 	}
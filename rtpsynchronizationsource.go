@@ -0,0 +1,23 @@
+package webrtc
+
+import (
+	"time"
+
+	"github.com/pion/webrtc/v3/pkg/media"
+)
+
+// RTPSynchronizationSource contains information about the latest RTP packet received
+// from a synchronization source (SSRC).
+// https://www.w3.org/TR/webrtc/#dom-rtcrtpsynchronizationsource
+type RTPSynchronizationSource struct {
+	// Timestamp is the local time the latest packet was received
+	Timestamp time.Time
+	// Source is the SSRC of the synchronization source
+	Source SSRC
+	// RTPTimestamp is the RTP timestamp of the latest packet
+	RTPTimestamp uint32
+	// AudioLevel is the level of the latest packet, taken from the ssrc-audio-level
+	// header extension. It is nil if the extension wasn't negotiated or the packet
+	// didn't carry it.
+	AudioLevel *media.AudioLevel
+}
@@ -0,0 +1,274 @@
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	sctpCommonHeaderSize = 12
+	sctpChunkHeaderSize  = 4
+
+	sctpChunkTypeInit             = 1
+	sctpChunkTypeAbort            = 6
+	sctpChunkTypeShutdownComplete = 14
+
+	// sctpChunkFlagT is set on the chunks sent with the verification tag of their sender
+	sctpChunkFlagT = 0x01
+)
+
+// sctpPacketEvent is what an inbound SCTP packet means for the association
+type sctpPacketEvent int
+
+const (
+	sctpPacketData sctpPacketEvent = iota
+	// sctpPacketStale carries the verification tag of a previous association
+	sctpPacketStale
+	// sctpPacketRestart is an INIT chunk with a new initiate tag, the remote peer
+	// restarted the association, see RFC 4960 section 5.2.2
+	sctpPacketRestart
+	// sctpPacketAbort is an ABORT chunk
+	sctpPacketAbort
+)
+
+// sctpConn is the connection of an SCTP association over the DTLS connection, which
+// outlives the association when it restarts. Once the next association starts, the
+// connection of the previous one hands the next packet it reads over to it and stops
+// writing, so the associations never share the DTLS connection. It is only used when
+// restarts are enabled.
+type sctpConn struct {
+	net.Conn
+
+	// onPeerRestart is called when the remote peer restarts or aborts the association
+	onPeerRestart func()
+
+	mu sync.Mutex
+	// localTag and peerTag are the verification tags of the association, learnt from
+	// the packets written
+	localTag, peerTag uint32
+	established       bool
+	// readable is closed once the previous association stopped reading, and
+	// handedOver is set once the connection handed over to the next one. The
+	// connection reads the DTLS connection in between.
+	readable     chan struct{}
+	readableOnce sync.Once
+	handedOver   bool
+	pending      []byte
+	next         *sctpConn
+	// closed is closed once the connection is closed or aborted
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newSCTPConn(conn net.Conn, onPeerRestart func()) *sctpConn {
+	return &sctpConn{
+		Conn:          conn,
+		onPeerRestart: onPeerRestart,
+		readable:      make(chan struct{}),
+		closed:        make(chan struct{}),
+	}
+}
+
+// handoff lets the connection read, once the previous connection read packet
+func (c *sctpConn) handoff(packet []byte) {
+	c.mu.Lock()
+	c.pending = packet
+	c.mu.Unlock()
+
+	c.readableOnce.Do(func() { close(c.readable) })
+}
+
+// setNext makes the connection hand over to the connection of the next association
+func (c *sctpConn) setNext(next *sctpConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.next = next
+}
+
+// handOver returns the connection of the next association, which the connection
+// hands over to from now on, or nil
+func (c *sctpConn) handOver() *sctpConn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.next != nil {
+		c.handedOver = true
+	}
+	return c.next
+}
+
+// reclaim makes the connection read and write again, unless it handed over to the
+// next association already
+func (c *sctpConn) reclaim() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.handedOver {
+		c.next = nil
+	}
+}
+
+// readsDTLS returns whether the connection reads the DTLS connection
+func (c *sctpConn) readsDTLS() bool {
+	select {
+	case <-c.readable:
+	default:
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return !c.handedOver
+}
+
+func (c *sctpConn) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// setEstablished is called once the association is established, from which an INIT
+// chunk restarts it
+func (c *sctpConn) setEstablished() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.established = true
+}
+
+func (c *sctpConn) Read(p []byte) (int, error) {
+	select {
+	case <-c.readable:
+	case <-c.closed:
+		return 0, io.EOF
+	}
+
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+	if pending != nil {
+		return copy(p, pending), nil
+	}
+
+	for {
+		n, err := c.Conn.Read(p)
+		if c.isClosed() {
+			return 0, io.EOF
+		} else if err != nil {
+			return n, err
+		}
+		packet := p[:n]
+
+		switch event := c.inspect(packet); {
+		case event == sctpPacketStale:
+			continue
+		case event != sctpPacketData:
+			c.onPeerRestart()
+			// The next association doesn't handle the ABORT chunk of the previous one
+			if event == sctpPacketAbort {
+				packet = nil
+			}
+		}
+
+		if next := c.handOver(); next != nil {
+			if packet != nil {
+				packet = append([]byte{}, packet...)
+			}
+			next.handoff(packet)
+			return 0, io.EOF
+		}
+		return n, nil
+	}
+}
+
+// inspect returns what an inbound packet means for the association
+func (c *sctpConn) inspect(packet []byte) sctpPacketEvent {
+	if len(packet) < sctpCommonHeaderSize+sctpChunkHeaderSize {
+		return sctpPacketData
+	}
+
+	c.mu.Lock()
+	localTag, peerTag, established := c.localTag, c.peerTag, c.established
+	c.mu.Unlock()
+
+	chunks := packet[sctpCommonHeaderSize:]
+	if chunks[0] == sctpChunkTypeInit {
+		if established && len(chunks) >= sctpChunkHeaderSize+4 &&
+			binary.BigEndian.Uint32(chunks[sctpChunkHeaderSize:]) != peerTag {
+			return sctpPacketRestart
+		}
+		return sctpPacketData
+	}
+
+	// RFC 4960 section 8.5, the T flag is only set on ABORT and SHUTDOWN COMPLETE chunks
+	verificationTag := binary.BigEndian.Uint32(packet[4:])
+	reflected := (chunks[0] == sctpChunkTypeAbort || chunks[0] == sctpChunkTypeShutdownComplete) &&
+		chunks[1]&sctpChunkFlagT != 0
+	if localTag != 0 && verificationTag != localTag && !(reflected && verificationTag == peerTag) {
+		return sctpPacketStale
+	}
+
+	for len(chunks) >= sctpChunkHeaderSize {
+		if chunks[0] == sctpChunkTypeAbort && established {
+			return sctpPacketAbort
+		}
+
+		length := int(binary.BigEndian.Uint16(chunks[2:]))
+		length += (4 - length%4) % 4
+		if length < sctpChunkHeaderSize || length > len(chunks) {
+			break
+		}
+		chunks = chunks[length:]
+	}
+	return sctpPacketData
+}
+
+func (c *sctpConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	next := c.next
+	if next == nil && len(p) >= sctpCommonHeaderSize+sctpChunkHeaderSize+4 {
+		if p[sctpCommonHeaderSize] == sctpChunkTypeInit {
+			c.localTag = binary.BigEndian.Uint32(p[sctpCommonHeaderSize+sctpChunkHeaderSize:])
+		} else {
+			c.peerTag = binary.BigEndian.Uint32(p[4:])
+		}
+	}
+	c.mu.Unlock()
+
+	// The previous association is dropped once the next one started
+	if next != nil {
+		return len(p), nil
+	}
+	return c.Conn.Write(p)
+}
+
+// Close closes the DTLS connection while the connection reads it. Otherwise the
+// connection is only aborted, see abort.
+func (c *sctpConn) Close() error {
+	reads := c.readsDTLS()
+	c.closeOnce.Do(func() { close(c.closed) })
+	if !reads {
+		return nil
+	}
+	return c.Conn.Close()
+}
+
+// abort stops the connection, without closing the DTLS connection. Its reads return
+// io.EOF, and a read of the DTLS connection in progress is interrupted.
+func (c *sctpConn) abort() {
+	c.closeOnce.Do(func() { close(c.closed) })
+	if c.readsDTLS() {
+		_ = c.Conn.SetReadDeadline(time.Now())
+	}
+}
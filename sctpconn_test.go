@@ -0,0 +1,120 @@
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sctpPacket returns an SCTP packet with the given verification tag and chunks
+func sctpPacket(verificationTag uint32, chunks ...[]byte) []byte {
+	packet := make([]byte, sctpCommonHeaderSize)
+	binary.BigEndian.PutUint32(packet[4:], verificationTag)
+	for _, chunk := range chunks {
+		packet = append(packet, chunk...)
+	}
+	return packet
+}
+
+// sctpChunk returns a chunk with a value of four bytes
+func sctpChunk(typ, flags byte, value uint32) []byte {
+	chunk := []byte{typ, flags, 0, sctpChunkHeaderSize + 4, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(chunk[sctpChunkHeaderSize:], value)
+	return chunk
+}
+
+func TestSCTPConnInspect(t *testing.T) {
+	const localTag, peerTag = 0x1111, 0x2222
+	conn := newSCTPConn(nil, func() {})
+	conn.localTag, conn.peerTag = localTag, peerTag
+
+	const sack = 3
+	for _, testCase := range []struct {
+		name        string
+		established bool
+		packet      []byte
+		event       sctpPacketEvent
+	}{
+		{"Data", true, sctpPacket(localTag, sctpChunk(0, 0, 1)), sctpPacketData},
+		{"Stale", true, sctpPacket(0x3333, sctpChunk(0, 0, 1)), sctpPacketStale},
+		{"Initial INIT", false, sctpPacket(0, sctpChunk(sctpChunkTypeInit, 0, 0x4444)), sctpPacketData},
+		{"Retransmitted INIT", true, sctpPacket(0, sctpChunk(sctpChunkTypeInit, 0, peerTag)), sctpPacketData},
+		{"Restart", true, sctpPacket(0, sctpChunk(sctpChunkTypeInit, 0, 0x4444)), sctpPacketRestart},
+		{"Abort", true, sctpPacket(localTag, sctpChunk(sctpChunkTypeAbort, 0, 0)), sctpPacketAbort},
+		{"Bundled abort", true, sctpPacket(localTag, sctpChunk(sack, 0, 0), sctpChunk(sctpChunkTypeAbort, 0, 0)), sctpPacketAbort},
+		{"Reflected abort", true, sctpPacket(peerTag, sctpChunk(sctpChunkTypeAbort, sctpChunkFlagT, 0)), sctpPacketAbort},
+		{"Abort before established", false, sctpPacket(localTag, sctpChunk(sctpChunkTypeAbort, 0, 0)), sctpPacketData},
+		{"Short", true, []byte{0x00}, sctpPacketData},
+	} {
+		conn.established = testCase.established
+		assert.Equal(t, testCase.event, conn.inspect(testCase.packet), testCase.name)
+	}
+}
+
+func TestSCTPConnHandoff(t *testing.T) {
+	local, remote := net.Pipe()
+	defer func() {
+		assert.NoError(t, remote.Close())
+	}()
+
+	conn := newSCTPConn(local, func() {})
+	conn.handoff(nil)
+
+	// The tags are learnt from the packets written
+	go func() {
+		_, _ = io.ReadFull(remote, make([]byte, 2*(sctpCommonHeaderSize+8)))
+	}()
+	_, err := conn.Write(sctpPacket(0, sctpChunk(sctpChunkTypeInit, 0, 0x1111)))
+	assert.NoError(t, err)
+	_, err = conn.Write(sctpPacket(0x2222, sctpChunk(0, 0, 1)))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x1111), conn.localTag)
+	assert.Equal(t, uint32(0x2222), conn.peerTag)
+
+	// The next connection reads once the previous one read a packet
+	next := newSCTPConn(local, func() {})
+	conn.setNext(next)
+	packet := sctpPacket(0x1111, sctpChunk(0, 0, 1))
+	go func() {
+		_, _ = remote.Write(packet)
+	}()
+
+	buf := make([]byte, 1500)
+	_, err = conn.Read(buf)
+	assert.Equal(t, io.EOF, err)
+	n, err := next.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, packet, buf[:n])
+
+	// The previous connection doesn't write nor close the DTLS connection anymore
+	n, err = conn.Write(packet)
+	assert.NoError(t, err)
+	assert.Equal(t, len(packet), n)
+	assert.NoError(t, conn.Close())
+
+	// A connection that didn't take over is aborted without closing the DTLS
+	// connection, and the previous connection reads it again once reclaimed
+	last := newSCTPConn(local, func() {})
+	next.setNext(last)
+	next.reclaim()
+	assert.NoError(t, last.Close())
+	_, err = last.Read(buf)
+	assert.Equal(t, io.EOF, err)
+
+	go func() {
+		_, _ = remote.Write(packet)
+	}()
+	n, err = next.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, packet, buf[:n])
+
+	// The connection reading the DTLS connection closes it
+	assert.NoError(t, next.Close())
+	_, err = remote.Write(packet)
+	assert.Equal(t, io.ErrClosedPipe, err)
+}
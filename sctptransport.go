@@ -5,6 +5,7 @@ package webrtc
 import (
 	"io"
 	"math"
+	"net"
 	"sync"
 	"time"
 
@@ -16,6 +17,10 @@ import (
 
 const sctpMaxChannels = uint16(65535)
 
+// sctpRestartTimeout is how long a restarting association has to be established
+// before the SCTPTransport closes
+const sctpRestartTimeout = 30 * time.Second
+
 // SCTPTransport provides details about the SCTP transport.
 type SCTPTransport struct {
 	lock sync.RWMutex
@@ -41,7 +46,20 @@ type SCTPTransport struct {
 
 	onErrorHandler func(error)
 
-	sctpAssociation            *sctp.Association
+	sctpAssociation *sctp.Association
+	// conn is the connection of the association over the DTLS transport, or of the
+	// next association while it restarts, and previousConn the connection of the
+	// association being restarted. They are only set when restarts are enabled.
+	conn         *sctpConn
+	previousConn *sctpConn
+	// restarts counts the restarts of the association, and restarting is set until
+	// the association restarted
+	restarts   uint64
+	restarting bool
+	// reopening are the data channels whose stream was created by the remote peer
+	// before they were re-created, once the association restarted
+	reopening map[uint16]*DataChannel
+
 	onDataChannelHandler       func(*DataChannel)
 	onDataChannelOpenedHandler func(*DataChannel)
 
@@ -51,6 +69,13 @@ type SCTPTransport struct {
 	dataChannelsRequested uint32
 	dataChannelsAccepted  uint32
 
+	// scheduler shares the association between the DataChannels by priority
+	scheduler *dataChannelScheduler
+
+	// readBuffers are the buffers of localMaxMessageSize the DataChannels read
+	// messages into, when it is larger than dataChannelBufferSize
+	readBuffers sync.Pool
+
 	api *API
 	log logging.LeveledLogger
 }
@@ -62,11 +87,15 @@ func (api *API) NewSCTPTransport(dtls *DTLSTransport) *SCTPTransport {
 	res := &SCTPTransport{
 		dtlsTransport: dtls,
 		state:         SCTPTransportStateConnecting,
+		scheduler:     newDataChannelScheduler(),
 		api:           api,
 		log:           api.settingEngine.LoggerFactory.NewLogger("ortc"),
 	}
 
-	res.updateMessageSize()
+	res.readBuffers.New = func() interface{} {
+		return make([]byte, res.localMaxMessageSize())
+	}
+	res.updateMessageSize(sctpDefaultMaxMessageSize)
 	res.updateMaxChannels()
 
 	return res
@@ -83,13 +112,22 @@ func (r *SCTPTransport) Transport() *DTLSTransport {
 // GetCapabilities returns the SCTPCapabilities of the SCTPTransport.
 func (r *SCTPTransport) GetCapabilities() SCTPCapabilities {
 	return SCTPCapabilities{
-		MaxMessageSize: 0,
+		MaxMessageSize: r.localMaxMessageSize(),
+	}
+}
+
+// localMaxMessageSize returns the largest message received by the SCTPTransport
+func (r *SCTPTransport) localMaxMessageSize() uint32 {
+	if size := r.api.settingEngine.sctp.MaxMessageSize; size != 0 {
+		return size
 	}
+	return dataChannelBufferSize
 }
 
 // Start the SCTPTransport. Since both local and remote parties must mutually
 // create an SCTPTransport, SCTP SO (Simultaneous Open) is used to establish
-// a connection over SCTP.
+// a connection over SCTP. The MaxMessageSize of remoteCaps limits the messages
+// sent, zero meaning that the remote peer receives messages of any size.
 func (r *SCTPTransport) Start(remoteCaps SCTPCapabilities) error {
 	if r.isStarted {
 		return nil
@@ -101,25 +139,197 @@ func (r *SCTPTransport) Start(remoteCaps SCTPCapabilities) error {
 		return errSCTPTransportDTLS
 	}
 
-	sctpAssociation, err := sctp.Client(sctp.Config{
-		NetConn:       dtlsTransport.conn,
-		LoggerFactory: r.api.settingEngine.LoggerFactory,
-	})
+	r.updateMessageSize(remoteCaps.MaxMessageSize)
+
+	// Packets are only inspected when the association can restart
+	var netConn net.Conn = dtlsTransport.conn
+	var conn *sctpConn
+	if r.api.settingEngine.sctp.Restart {
+		conn = r.newConn(dtlsTransport.conn)
+		conn.handoff(nil)
+		netConn = conn
+	}
+	sctpAssociation, err := sctp.Client(r.associationConfig(netConn))
 	if err != nil {
 		return err
 	}
+	if conn != nil {
+		conn.setEstablished()
+	}
 
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
 	r.sctpAssociation = sctpAssociation
+	r.conn = conn
 	r.state = SCTPTransportStateConnected
 
-	go r.acceptDataChannels(sctpAssociation)
+	go r.acceptDataChannels(sctpAssociation, r.restarts)
 
 	return nil
 }
 
+// newConn returns the connection of an association over the DTLS connection
+func (r *SCTPTransport) newConn(dtlsConn net.Conn) *sctpConn {
+	var conn *sctpConn
+	conn = newSCTPConn(dtlsConn, func() {
+		r.restart(conn)
+	})
+	return conn
+}
+
+func (r *SCTPTransport) associationConfig(conn net.Conn) sctp.Config {
+	maxMessageSize := uint32(math.MaxUint32)
+	if size := r.MaxMessageSize(); size < math.MaxUint32 {
+		maxMessageSize = uint32(size)
+	}
+
+	return sctp.Config{
+		NetConn:              conn,
+		MaxReceiveBufferSize: r.api.settingEngine.sctp.MaxReceiveBufferSize,
+		MaxMessageSize:       maxMessageSize,
+		RTOMax:               float64(r.api.settingEngine.sctp.RTOMax / time.Millisecond),
+		LoggerFactory:        r.api.settingEngine.LoggerFactory,
+	}
+}
+
+// Restart restarts the SCTP association over the DTLS transport, as when the remote
+// peer aborts it. The remote peer restarts its own association too, as specified by
+// RFC 4960 section 5.2.2, if it enabled restarts with SettingEngine.EnableSCTPRestart.
+// Negotiated data channels are re-created on the new association, unless detached
+// or fragmented, and their OnRestart handler is invoked. The other data channels
+// are closed. Restart returns once the restart started.
+//
+// If the association doesn't restart within 30 seconds, for example because the
+// remote peer didn't enable restarts, the SCTPTransport closes along with its data
+// channels, and the OnError handler is invoked.
+func (r *SCTPTransport) Restart() error {
+	if !r.api.settingEngine.sctp.Restart {
+		return ErrSCTPRestartDisabled
+	}
+
+	r.lock.RLock()
+	conn := r.conn
+	r.lock.RUnlock()
+
+	if conn == nil || r.State() != SCTPTransportStateConnected {
+		return errSCTPNotEstablished
+	}
+	r.restart(conn)
+	return nil
+}
+
+// restart starts the next association, unless the association of conn restarted
+// already. The connection of the previous association hands over to the next one
+// once it reads a packet.
+func (r *SCTPTransport) restart(conn *sctpConn) {
+	r.lock.Lock()
+	if r.state != SCTPTransportStateConnected || r.conn != conn {
+		r.lock.Unlock()
+		return
+	}
+
+	next := r.newConn(conn.Conn)
+	previous := r.sctpAssociation
+	r.conn, r.previousConn = next, conn
+	r.restarts++
+	r.restarting = true
+	dataChannels := append([]*DataChannel{}, r.dataChannels...)
+	r.lock.Unlock()
+
+	r.log.Infof("Restarting SCTP association")
+
+	// Messages sent meanwhile are queued until the data channels are re-created,
+	// which are suspended before the previous association stops
+	for _, d := range dataChannels {
+		d.suspend()
+	}
+	conn.setNext(next)
+
+	go r.reconnect(previous, next)
+}
+
+// reconnect establishes the next association and re-creates the data channels on it
+func (r *SCTPTransport) reconnect(previous *sctp.Association, conn *sctpConn) {
+	// The handshake only ends by itself once the INIT chunk retransmissions run out
+	timeout := time.AfterFunc(sctpRestartTimeout, conn.abort)
+	association, err := sctp.Client(r.associationConfig(conn))
+	timeout.Stop()
+
+	r.lock.Lock()
+	if r.conn != conn {
+		// Stopped meanwhile
+		r.lock.Unlock()
+		if err == nil {
+			_ = association.Close()
+		}
+		return
+	}
+	if err != nil {
+		// The previous association is closed by Stop, along with the DTLS connection
+		// it may still be reading
+		r.state = SCTPTransportStateClosed
+		dataChannels := append([]*DataChannel{}, r.dataChannels...)
+		r.lock.Unlock()
+
+		r.log.Errorf("Failed to restart SCTP association: %v", err)
+		for _, d := range dataChannels {
+			d.closeSuspended()
+		}
+		r.onError(err)
+		return
+	}
+
+	r.sctpAssociation = association
+	r.previousConn = nil
+	r.restarting = false
+	r.reopening = map[uint16]*DataChannel{}
+	generation := r.restarts
+	dataChannels := append([]*DataChannel{}, r.dataChannels...)
+	r.lock.Unlock()
+	conn.setEstablished()
+
+	// The previous association stopped reading once the next one was established
+	if err = previous.Close(); err != nil {
+		r.log.Warnf("Failed to close previous SCTP association: %v", err)
+	}
+
+	for _, d := range dataChannels {
+		if !d.isSuspended() {
+			continue
+		}
+
+		stream, err := association.OpenStream(*d.ID(), sctp.PayloadTypeWebRTCBinary)
+		if err != nil {
+			// The remote peer sent a message on the stream already, which is accepted
+			r.lock.Lock()
+			r.reopening[*d.ID()] = d
+			r.lock.Unlock()
+			continue
+		}
+		if err = d.reopen(stream, generation); err != nil {
+			r.log.Errorf("Failed to re-create data channel %d: %v", *d.ID(), err)
+		}
+	}
+
+	go r.acceptDataChannels(association, generation)
+}
+
+// restartedSince returns whether the association restarted since the given restart
+func (r *SCTPTransport) restartedSince(generation uint64) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.restarts > generation
+}
+
+func (r *SCTPTransport) generation() uint64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.restarts
+}
+
 // Stop stops the SCTPTransport
 func (r *SCTPTransport) Stop() error {
 	r.lock.Lock()
@@ -127,22 +337,58 @@ func (r *SCTPTransport) Stop() error {
 	if r.sctpAssociation == nil {
 		return nil
 	}
+	// The next association is abandoned, and the previous one reads the DTLS
+	// connection again unless it handed over already, so closing the association
+	// reading it closes the DTLS connection once
+	if r.restarting {
+		r.previousConn.reclaim()
+		_ = r.conn.Close()
+	}
 	err := r.sctpAssociation.Close()
 	if err != nil {
 		return err
 	}
 
 	r.sctpAssociation = nil
+	r.conn, r.previousConn = nil, nil
 	r.state = SCTPTransportStateClosed
 
 	return nil
 }
 
-func (r *SCTPTransport) acceptDataChannels(a *sctp.Association) {
+// acceptDataChannel accepts the next data channel announced by the remote peer,
+// along with its SCTP stream so the DataChannel can override its ordering. The
+// streams of the negotiated data channels being re-created after a restart are
+// returned with the DataChannel they belong to.
+func (r *SCTPTransport) acceptDataChannel(a *sctp.Association) (*datachannel.DataChannel, *sctp.Stream, *DataChannel, error) {
+	stream, err := a.AcceptStream()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	stream.SetDefaultPayloadType(sctp.PayloadTypeWebRTCBinary)
+
+	r.lock.Lock()
+	reopening, ok := r.reopening[stream.StreamIdentifier()]
+	delete(r.reopening, stream.StreamIdentifier())
+	r.lock.Unlock()
+	if ok {
+		return nil, stream, reopening, nil
+	}
+
+	dc, err := datachannel.Server(stream, &datachannel.Config{
+		LoggerFactory: r.api.settingEngine.LoggerFactory,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return dc, stream, nil, nil
+}
+
+// acceptDataChannels accepts the data channels of the association opened by the
+// given restart
+func (r *SCTPTransport) acceptDataChannels(a *sctp.Association, generation uint64) {
 	for {
-		dc, err := datachannel.Accept(a, &datachannel.Config{
-			LoggerFactory: r.api.settingEngine.LoggerFactory,
-		})
+		dc, stream, reopening, err := r.acceptDataChannel(a)
 		if err != nil {
 			if err != io.EOF {
 				r.log.Errorf("Failed to accept data channel: %v", err)
@@ -150,6 +396,12 @@ func (r *SCTPTransport) acceptDataChannels(a *sctp.Association) {
 			}
 			return
 		}
+		if reopening != nil {
+			if err = reopening.reopen(stream, generation); err != nil {
+				r.log.Errorf("Failed to re-create data channel %d: %v", stream.StreamIdentifier(), err)
+			}
+			continue
+		}
 
 		var (
 			maxRetransmits    *uint16
@@ -178,12 +430,20 @@ func (r *SCTPTransport) acceptDataChannels(a *sctp.Association) {
 		default:
 		}
 
+		// Fragmentation announced for a channel that could lose fragments is ignored
+		protocol, fragmented := parseWireProtocol(dc.Config.Protocol)
+		if fragmented && dc.Config.ChannelType != datachannel.ChannelTypeReliable {
+			protocol, fragmented = dc.Config.Protocol, false
+		}
+
 		sid := dc.StreamIdentifier()
 		rtcDC, err := r.api.newDataChannel(&DataChannelParameters{
 			ID:                &sid,
 			Label:             dc.Config.Label,
-			Protocol:          dc.Config.Protocol,
+			Protocol:          protocol,
 			Negotiated:        dc.Config.Negotiated,
+			Fragmented:        fragmented,
+			Priority:          newPriorityTypeFromWeight(dc.Config.Priority),
 			Ordered:           ordered,
 			MaxPacketLifeTime: maxPacketLifeTime,
 			MaxRetransmits:    maxRetransmits,
@@ -193,9 +453,12 @@ func (r *SCTPTransport) acceptDataChannels(a *sctp.Association) {
 			r.onError(err)
 			return
 		}
+		rtcDC.sctpTransport = r
+		rtcDC.remoteOpened = true
+		rtcDC.generation = generation
 
 		<-r.onDataChannel(rtcDC)
-		rtcDC.handleOpen(dc)
+		rtcDC.handleOpen(dc, stream)
 
 		r.lock.Lock()
 		r.dataChannelsOpened++
@@ -265,14 +528,22 @@ func (r *SCTPTransport) onDataChannel(dc *DataChannel) (done chan struct{}) {
 	return
 }
 
-func (r *SCTPTransport) updateMessageSize() {
+func (r *SCTPTransport) updateMessageSize(remoteMaxMessageSize uint32) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	var remoteMaxMessageSize float64 = 65536 // pion/webrtc#758
-	var canSendSize float64 = 65536          // pion/webrtc#758
+	var canSendSize float64 // the SCTP association sends messages of any size
 
-	r.maxMessageSize = r.calcMessageSize(remoteMaxMessageSize, canSendSize)
+	r.maxMessageSize = r.calcMessageSize(float64(remoteMaxMessageSize), canSendSize)
+}
+
+// MaxMessageSize is the largest message that can be sent, as advertised by the
+// remote peer. It is +Inf if the remote peer receives messages of any size.
+func (r *SCTPTransport) MaxMessageSize() float64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.maxMessageSize
 }
 
 func (r *SCTPTransport) calcMessageSize(remoteMaxMessageSize, canSendSize float64) float64 {
@@ -297,6 +568,9 @@ func (r *SCTPTransport) calcMessageSize(remoteMaxMessageSize, canSendSize float6
 
 func (r *SCTPTransport) updateMaxChannels() {
 	val := sctpMaxChannels
+	if maxChannels := r.api.settingEngine.sctp.MaxChannels; maxChannels != 0 {
+		val = maxChannels
+	}
 	r.maxChannels = &val
 }
 
@@ -332,6 +606,9 @@ func (r *SCTPTransport) collectStats(collector *statsReportCollector) {
 	if association != nil {
 		stats.BytesSent = association.BytesSent()
 		stats.BytesReceived = association.BytesReceived()
+		stats.CongestionWindow = association.CWND()
+		stats.ReceiverWindow = association.RWND()
+		stats.SmoothedRoundTripTime = association.SRTT() / 1000
 	}
 
 	collector.Collect(stats.ID, stats)
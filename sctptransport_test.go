@@ -2,7 +2,16 @@
 
 package webrtc
 
-import "testing"
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/test"
+	"github.com/stretchr/testify/assert"
+)
 
 func TestGenerateDataChannelID(t *testing.T) {
 	sctpTransportWithChannels := func(ids []uint16) *SCTPTransport {
@@ -44,3 +53,224 @@ func TestGenerateDataChannelID(t *testing.T) {
 		}
 	}
 }
+
+func TestSCTPTransportSettings(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const maxMessageSize = 256 * 1024
+
+	s := SettingEngine{}
+	s.SetSCTPMaxMessageSize(maxMessageSize)
+	s.SetSCTPMaxReceiveBufferSize(4 * maxMessageSize)
+	s.SetSCTPMaxChannels(16)
+	s.SetSCTPRTOMax(5 * time.Second)
+	offerPC, answerPC, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, float64(5000), offerPC.SCTP().associationConfig(nil).RTOMax)
+	assert.Equal(t, uint16(16), offerPC.SCTP().MaxChannels())
+	assert.Equal(t, uint32(maxMessageSize), offerPC.SCTP().GetCapabilities().MaxMessageSize)
+
+	dc, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	offer, err := offerPC.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(offer.SDP, "a=max-message-size:262144\r\n"))
+
+	received := make(chan []byte, 1)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(msg DataChannelMessage) {
+			received <- msg.Data
+		})
+	})
+
+	large := make([]byte, maxMessageSize)
+	large[maxMessageSize-1] = 1
+	dc.OnOpen(func() {
+		// The remote peer receives messages up to the advertised size
+		assert.Equal(t, float64(maxMessageSize), offerPC.SCTP().MaxMessageSize())
+		assert.Error(t, dc.Send(make([]byte, maxMessageSize+1)))
+		assert.NoError(t, dc.Send(large))
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	assert.Equal(t, large, <-received)
+
+	stats, ok := offerPC.GetStats()["sctpTransport"].(TransportStats)
+	assert.True(t, ok)
+	assert.NotZero(t, stats.CongestionWindow)
+	assert.NotZero(t, stats.ReceiverWindow)
+	assert.Greater(t, stats.SmoothedRoundTripTime, float64(0))
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestSCTPTransportRestart(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	assert.Equal(t, ErrSCTPRestartDisabled, pc.SCTP().Restart())
+	assert.NoError(t, pc.Close())
+
+	s := SettingEngine{}
+	s.EnableSCTPRestart(true)
+	pcOffer, pcAnswer, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+	assert.NoError(t, err)
+	assert.Equal(t, errSCTPNotEstablished, pcOffer.SCTP().Restart())
+
+	// Both peers share a negotiated data channel, and signalPair creates one in-band
+	type peer struct {
+		dc       *DataChannel
+		opened   chan struct{}
+		received chan []byte
+		restart  chan []DataChannelMessage
+	}
+	newPeer := func(pc *PeerConnection) *peer {
+		negotiated, id := true, uint16(0)
+		dc, dcErr := pc.CreateDataChannel("negotiated", &DataChannelInit{Negotiated: &negotiated, ID: &id})
+		assert.NoError(t, dcErr)
+
+		p := &peer{
+			dc:       dc,
+			opened:   make(chan struct{}),
+			received: make(chan []byte, 1024),
+			restart:  make(chan []DataChannelMessage, 1),
+		}
+		dc.OnOpen(func() { close(p.opened) })
+		dc.OnMessage(func(msg DataChannelMessage) { p.received <- msg.Data })
+		dc.OnRestart(func(lost []DataChannelMessage) { p.restart <- lost })
+		return p
+	}
+	offerer, answerer := newPeer(pcOffer), newPeer(pcAnswer)
+
+	inBandClosed := make(chan struct{}, 2)
+	pcAnswer.OnDataChannel(func(d *DataChannel) {
+		d.OnClose(func() { inBandClosed <- struct{}{} })
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	pcOffer.sctpTransport.lock.RLock()
+	inBand := pcOffer.sctpTransport.dataChannels[len(pcOffer.sctpTransport.dataChannels)-1]
+	pcOffer.sctpTransport.lock.RUnlock()
+	inBand.OnClose(func() { inBandClosed <- struct{}{} })
+
+	<-offerer.opened
+	<-answerer.opened
+
+	// exchange sends a message both ways and waits for it, skipping the messages
+	// received meanwhile
+	exchange := func(message string) {
+		assert.NoError(t, offerer.dc.SendText(message))
+		assert.NoError(t, answerer.dc.SendText(message))
+		for _, p := range []*peer{offerer, answerer} {
+			for received := range p.received {
+				if string(received) == message {
+					break
+				}
+			}
+		}
+	}
+	exchange("before")
+
+	t.Run("Restart", func(t *testing.T) {
+		// The messages sent just before the restart may be lost, the others are received
+		const messages = 64
+		for i := 0; i < messages; i++ {
+			assert.NoError(t, offerer.dc.Send(append([]byte{byte(i)}, make([]byte, 16383)...)))
+		}
+
+		assert.NoError(t, pcOffer.SCTP().Restart())
+		// Messages sent while restarting are sent over the restarted association
+		assert.NoError(t, offerer.dc.SendText("restarting"))
+
+		lost := <-offerer.restart
+		<-answerer.restart
+		<-inBandClosed
+		<-inBandClosed
+		assert.Equal(t, DataChannelStateOpen, offerer.dc.ReadyState())
+		assert.Equal(t, DataChannelStateOpen, answerer.dc.ReadyState())
+		assert.Equal(t, DataChannelStateClosed, inBand.ReadyState())
+
+		received := map[byte]bool{}
+		for msg := range answerer.received {
+			if string(msg) == "restarting" {
+				break
+			}
+			received[msg[0]] = true
+		}
+		// Earlier messages may not have been acknowledged either
+		for _, msg := range lost {
+			if len(msg.Data) == 16384 {
+				received[msg.Data[0]] = true
+			}
+		}
+		assert.Len(t, received, messages)
+
+		exchange("after restart")
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		pcAnswer.sctpTransport.lock.RLock()
+		conn := pcAnswer.sctpTransport.conn
+		pcAnswer.sctpTransport.lock.RUnlock()
+		conn.mu.Lock()
+		verificationTag := conn.peerTag
+		conn.mu.Unlock()
+
+		// The answerer aborts the association, the offerer restarts it
+		abort := make([]byte, sctpCommonHeaderSize+sctpChunkHeaderSize)
+		binary.BigEndian.PutUint16(abort[0:], 5000)
+		binary.BigEndian.PutUint16(abort[2:], 5000)
+		binary.BigEndian.PutUint32(abort[4:], verificationTag)
+		abort[sctpCommonHeaderSize] = sctpChunkTypeAbort
+		binary.BigEndian.PutUint16(abort[sctpCommonHeaderSize+2:], sctpChunkHeaderSize)
+		binary.LittleEndian.PutUint32(abort[8:], crc32.Checksum(abort, crc32.MakeTable(crc32.Castagnoli)))
+		_, err := conn.Conn.Write(abort)
+		assert.NoError(t, err)
+
+		<-offerer.restart
+		<-answerer.restart
+		exchange("after abort")
+	})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestSCTPTransportRestartUnsupported(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// Only the offerer enables restarts, the answerer ignores its INIT chunks
+	s := SettingEngine{}
+	s.EnableSCTPRestart(true)
+	pcOffer, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	opened := make(chan struct{})
+	dc, err := pcOffer.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+	dc.OnOpen(func() { close(opened) })
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-opened
+
+	// Stopping while the association restarts closes it without waiting for the
+	// restart to fail
+	assert.NoError(t, pcOffer.SCTP().Restart())
+	closePairNow(t, pcOffer, pcAnswer)
+}
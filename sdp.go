@@ -23,9 +23,13 @@ type trackDetails struct {
 	streamID string
 	id       string
 	ssrc     SSRC
+	fecSSRC  SSRC
 	rids     []string
 }
 
+// sdpSemanticTokenFECFR is the ssrc-group semantic of a FlexFEC repair flow (RFC 5956)
+const sdpSemanticTokenFECFR = "FEC-FR"
+
 func trackDetailsForSSRC(trackDetails []trackDetails, ssrc SSRC) *trackDetails {
 	for i := range trackDetails {
 		if trackDetails[i].ssrc == ssrc {
@@ -49,6 +53,7 @@ func filterTrackWithSSRC(incomingTracks []trackDetails, ssrc SSRC) []trackDetail
 func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) []trackDetails { // nolint:gocognit
 	incomingTracks := []trackDetails{}
 	rtxRepairFlows := map[uint32]bool{}
+	fecRepairFlows := map[uint32]SSRC{}
 
 	for _, media := range s.MediaDescriptions {
 		// Plan B can have multiple tracks in a signle media section
@@ -95,6 +100,23 @@ func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) [
 						rtxRepairFlows[uint32(rtxRepairFlow)] = true
 						incomingTracks = filterTrackWithSSRC(incomingTracks, SSRC(rtxRepairFlow)) // Remove if rtx was added as track before
 					}
+				} else if split[0] == sdpSemanticTokenFECFR {
+					// Lines like `a=ssrc-group:FEC-FR 2231627014 632943048` declare that the second SSRC
+					// is a FlexFEC repair flow protecting the first one, it is used by the track of the first
+					if len(split) == 3 {
+						protected, err := strconv.ParseUint(split[1], 10, 32)
+						if err != nil {
+							log.Warnf("Failed to parse SSRC: %v", err)
+							continue
+						}
+						fecRepairFlow, err := strconv.ParseUint(split[2], 10, 32)
+						if err != nil {
+							log.Warnf("Failed to parse SSRC: %v", err)
+							continue
+						}
+						fecRepairFlows[uint32(fecRepairFlow)] = SSRC(protected)
+						incomingTracks = filterTrackWithSSRC(incomingTracks, SSRC(fecRepairFlow)) // Remove if fec was added as track before
+					}
 				}
 
 			// Handle `a=msid:<stream_id> <track_label>` for Unified plan. The first value is the same as MediaStream.id
@@ -118,6 +140,9 @@ func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) [
 				if rtxRepairFlow := rtxRepairFlows[uint32(ssrc)]; rtxRepairFlow {
 					continue // This ssrc is a RTX repair flow, ignore
 				}
+				if _, fecRepairFlow := fecRepairFlows[uint32(ssrc)]; fecRepairFlow {
+					continue // This ssrc is a FEC repair flow, it is added to the track it protects below
+				}
 
 				if len(split) == 3 && strings.HasPrefix(split[1], "msid:") {
 					streamID = split[1][len("msid:"):]
@@ -160,6 +185,12 @@ func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) [
 			incomingTracks = append(incomingTracks, newTrack)
 		}
 	}
+
+	for fecRepairFlow, protected := range fecRepairFlows {
+		if trackDetails := trackDetailsForSSRC(incomingTracks, protected); trackDetails != nil {
+			trackDetails.fecSSRC = SSRC(fecRepairFlow)
+		}
+	}
 	return incomingTracks
 }
 
@@ -212,7 +243,7 @@ func addCandidatesToMediaDescriptions(candidates []ICECandidate, m *sdp.MediaDes
 	return nil
 }
 
-func addDataMediaSection(d *sdp.SessionDescription, shouldAddCandidates bool, dtlsFingerprints []DTLSFingerprint, midValue string, iceParams ICEParameters, candidates []ICECandidate, dtlsRole sdp.ConnectionRole, iceGatheringState ICEGatheringState) error {
+func addDataMediaSection(d *sdp.SessionDescription, shouldAddCandidates bool, dtlsFingerprints []DTLSFingerprint, midValue string, iceParams ICEParameters, candidates []ICECandidate, dtlsRole sdp.ConnectionRole, iceGatheringState ICEGatheringState, sctpMaxMessageSize uint32) error {
 	media := (&sdp.MediaDescription{
 		MediaName: sdp.MediaName{
 			Media:   mediaSectionApplication,
@@ -232,6 +263,7 @@ func addDataMediaSection(d *sdp.SessionDescription, shouldAddCandidates bool, dt
 		WithValueAttribute(sdp.AttrKeyMID, midValue).
 		WithPropertyAttribute(RTPTransceiverDirectionSendrecv.String()).
 		WithPropertyAttribute("sctp-port:5000").
+		WithValueAttribute(sctpMaxMessageSizeAttr, strconv.FormatUint(uint64(sctpMaxMessageSize), 10)).
 		WithICECredentials(iceParams.UsernameFragment, iceParams.Password)
 
 	for _, f := range dtlsFingerprints {
@@ -347,6 +379,10 @@ func addTransceiverSDP(d *sdp.SessionDescription, isPlanB, shouldAddCandidates b
 		if mt.Sender() != nil && mt.Sender().Track() != nil {
 			track := mt.Sender().Track()
 			media = media.WithMediaSource(uint32(mt.Sender().ssrc), track.StreamID() /* cname */, track.StreamID() /* streamLabel */, track.ID())
+			if fecSSRC := mt.Sender().GetParameters().Encodings[0].FEC.SSRC; fecSSRC != 0 {
+				media = media.WithValueAttribute(sdp.AttrKeySSRCGroup, fmt.Sprintf("%s %d %d", sdpSemanticTokenFECFR, mt.Sender().ssrc, fecSSRC))
+				media = media.WithMediaSource(uint32(fecSSRC), track.StreamID() /* cname */, track.StreamID() /* streamLabel */, track.ID())
+			}
 			if !isPlanB {
 				media = media.WithPropertyAttribute("msid:" + track.StreamID() + " " + track.ID())
 				break
@@ -379,7 +415,7 @@ type mediaSection struct {
 }
 
 // populateSDP serializes a PeerConnections state into an SDP
-func populateSDP(d *sdp.SessionDescription, isPlanB bool, dtlsFingerprints []DTLSFingerprint, mediaDescriptionFingerprint bool, isICELite bool, mediaEngine *MediaEngine, connectionRole sdp.ConnectionRole, candidates []ICECandidate, iceParams ICEParameters, mediaSections []mediaSection, iceGatheringState ICEGatheringState) (*sdp.SessionDescription, error) {
+func populateSDP(d *sdp.SessionDescription, isPlanB bool, dtlsFingerprints []DTLSFingerprint, mediaDescriptionFingerprint bool, isICELite bool, mediaEngine *MediaEngine, connectionRole sdp.ConnectionRole, candidates []ICECandidate, iceParams ICEParameters, mediaSections []mediaSection, iceGatheringState ICEGatheringState, sctpMaxMessageSize uint32) (*sdp.SessionDescription, error) {
 	var err error
 	mediaDtlsFingerprints := []DTLSFingerprint{}
 
@@ -404,7 +440,7 @@ func populateSDP(d *sdp.SessionDescription, isPlanB bool, dtlsFingerprints []DTL
 		shouldAddID := true
 		shouldAddCandidates := i == 0
 		if m.data {
-			if err = addDataMediaSection(d, shouldAddCandidates, mediaDtlsFingerprints, m.id, iceParams, candidates, connectionRole, iceGatheringState); err != nil {
+			if err = addDataMediaSection(d, shouldAddCandidates, mediaDtlsFingerprints, m.id, iceParams, candidates, connectionRole, iceGatheringState, sctpMaxMessageSize); err != nil {
 				return nil, err
 			}
 		} else {
@@ -553,6 +589,28 @@ func extractICEDetails(desc *sdp.SessionDescription) (string, string, []ICECandi
 	return remoteUfrags[0], remotePwds[0], candidates, nil
 }
 
+// getMaxMessageSize returns the max-message-size advertised in the application media
+// section, zero meaning that messages of any size are received
+func getMaxMessageSize(desc *sdp.SessionDescription) uint32 {
+	for _, m := range desc.MediaDescriptions {
+		if m.MediaName.Media != mediaSectionApplication {
+			continue
+		}
+
+		raw, ok := m.Attribute(sctpMaxMessageSizeAttr)
+		if !ok {
+			break
+		}
+		maxMessageSize, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			break
+		}
+		return uint32(maxMessageSize)
+	}
+
+	return sctpDefaultMaxMessageSize
+}
+
 func haveApplicationMediaSection(desc *sdp.SessionDescription) bool {
 	for _, m := range desc.MediaDescriptions {
 		if m.MediaName.Media == mediaSectionApplication {
@@ -196,6 +196,8 @@ func TestTrackDetailsFromSDP(t *testing.T) {
 						{Key: "sendonly"},
 						{Key: "msid", Value: "video_stream_id video_trk_id"},
 						{Key: "ssrc", Value: "5000"},
+						{Key: "ssrc-group", Value: "FEC-FR 5000 6000"},
+						{Key: "ssrc", Value: "6000"},
 					},
 				},
 				{
@@ -232,11 +234,15 @@ func TestTrackDetailsFromSDP(t *testing.T) {
 		if track := trackDetailsForSSRC(tracks, 4000); track != nil {
 			assert.Fail(t, "got the rtx track ssrc:3000 which should have been skipped")
 		}
+		if track := trackDetailsForSSRC(tracks, 6000); track != nil {
+			assert.Fail(t, "got the fec track ssrc:6000 which should have been skipped")
+		}
 		if track := trackDetailsForSSRC(tracks, 5000); track == nil {
 			assert.Fail(t, "missing video track with ssrc:5000")
 		} else {
 			assert.Equal(t, RTPCodecTypeVideo, track.kind)
 			assert.Equal(t, SSRC(5000), track.ssrc)
+			assert.Equal(t, SSRC(6000), track.fecSSRC)
 			assert.Equal(t, "video_trk_id", track.id)
 			assert.Equal(t, "video_stream_id", track.streamID)
 		}
@@ -303,6 +309,28 @@ func TestHaveApplicationMediaSection(t *testing.T) {
 	})
 }
 
+func TestGetMaxMessageSize(t *testing.T) {
+	application := func(attributes ...sdp.Attribute) *sdp.SessionDescription {
+		return &sdp.SessionDescription{
+			MediaDescriptions: []*sdp.MediaDescription{
+				{
+					MediaName:  sdp.MediaName{Media: "audio"},
+					Attributes: []sdp.Attribute{{Key: sctpMaxMessageSizeAttr, Value: "1"}},
+				},
+				{
+					MediaName:  sdp.MediaName{Media: mediaSectionApplication},
+					Attributes: attributes,
+				},
+			},
+		}
+	}
+
+	assert.Equal(t, uint32(sctpDefaultMaxMessageSize), getMaxMessageSize(application()))
+	assert.Equal(t, uint32(sctpDefaultMaxMessageSize), getMaxMessageSize(application(sdp.Attribute{Key: sctpMaxMessageSizeAttr, Value: "invalid"})))
+	assert.Equal(t, uint32(0), getMaxMessageSize(application(sdp.Attribute{Key: sctpMaxMessageSizeAttr, Value: "0"})))
+	assert.Equal(t, uint32(262144), getMaxMessageSize(application(sdp.Attribute{Key: sctpMaxMessageSizeAttr, Value: "262144"})))
+}
+
 func TestMediaDescriptionFingerprints(t *testing.T) {
 	engine := &MediaEngine{}
 	assert.NoError(t, engine.RegisterDefaultCodecs())
@@ -347,7 +375,7 @@ func TestMediaDescriptionFingerprints(t *testing.T) {
 			s, err = populateSDP(s, false,
 				dtlsFingerprints,
 				SDPMediaDescriptionFingerprints,
-				false, engine, sdp.ConnectionRoleActive, []ICECandidate{}, ICEParameters{}, media, ICEGatheringStateNew)
+				false, engine, sdp.ConnectionRoleActive, []ICECandidate{}, ICEParameters{}, media, ICEGatheringStateNew, sctpDefaultMaxMessageSize)
 			assert.NoError(t, err)
 
 			sdparray, err := s.Marshal()
@@ -377,7 +405,7 @@ func TestPopulateSDP(t *testing.T) {
 
 		d := &sdp.SessionDescription{}
 
-		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, &m, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete)
+		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, &m, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, sctpDefaultMaxMessageSize)
 		assert.Nil(t, err)
 
 		// Test contains rid map keys
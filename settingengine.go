@@ -45,6 +45,13 @@ type SettingEngine struct {
 		UsernameFragment       string
 		Password               string
 	}
+	sctp struct {
+		MaxReceiveBufferSize uint32
+		MaxMessageSize       uint32
+		MaxChannels          uint16
+		RTOMax               time.Duration
+		Restart              bool
+	}
 	replayProtection struct {
 		DTLS  *uint
 		SRTP  *uint
@@ -62,7 +69,10 @@ type SettingEngine struct {
 	iceUDPMux                                 ice.UDPMux
 	iceProxyDialer                            proxy.Dialer
 	disableMediaEngineCopy                    bool
+	disableMedia                              bool
 	srtpProtectionProfiles                    []dtls.SRTPProtectionProfile
+	dataChannelMaxFragmentedMessageSize       uint64
+	dataChannelReceiveQueueSize               int
 }
 
 // DetachDataChannels enables detaching data channels. When enabled
@@ -72,6 +82,60 @@ func (e *SettingEngine) DetachDataChannels() {
 	e.detach.DataChannels = true
 }
 
+// SetSCTPMaxReceiveBufferSize sets the size of the SCTP receive buffer, which is
+// the receive window advertised to the remote peer. Defaults to 1 MiB.
+func (e *SettingEngine) SetSCTPMaxReceiveBufferSize(size uint32) {
+	e.sctp.MaxReceiveBufferSize = size
+}
+
+// SetSCTPMaxMessageSize sets the largest data channel message received, which is
+// advertised with the max-message-size SDP attribute. Defaults to 65535 bytes.
+// Every data channel that isn't fragmented waits for messages with a buffer of
+// this size, so large sizes are better served by fragmented data channels, see
+// DataChannelInit.Fragmented.
+func (e *SettingEngine) SetSCTPMaxMessageSize(size uint32) {
+	e.sctp.MaxMessageSize = size
+}
+
+// SetSCTPRTOMax sets the largest retransmission timeout of the SCTP association,
+// which bounds the time between retransmissions once packets are lost. Defaults
+// to 60 seconds. The smallest retransmission timeout is fixed at 1 second, as
+// pion/sctp doesn't allow to configure it.
+func (e *SettingEngine) SetSCTPRTOMax(rtoMax time.Duration) {
+	e.sctp.RTOMax = rtoMax
+}
+
+// SetSCTPMaxChannels sets the number of SCTP streams, which is the value reported
+// by SCTPTransport.MaxChannels and bounds the IDs of the data channels created
+// locally. Defaults to 65535.
+func (e *SettingEngine) SetSCTPMaxChannels(maxChannels uint16) {
+	e.sctp.MaxChannels = maxChannels
+}
+
+// EnableSCTPRestart enables restarting the SCTP association over the DTLS transport
+// with SCTPTransport.Restart, instead of closing the data channels when the remote
+// peer aborts the association. The association is restarted as well when the remote
+// peer restarts it, and the messages sent by data channels with an OnRestart handler
+// are kept until acknowledged to report those that may have been lost. Both peers
+// have to enable restarts, see SCTPTransport.Restart.
+func (e *SettingEngine) EnableSCTPRestart(isEnabled bool) {
+	e.sctp.Restart = isEnabled
+}
+
+// SetDataChannelMaxFragmentedMessageSize sets the largest message reassembled by
+// fragmented data channels, see DataChannelInit.Fragmented. Larger messages are
+// dropped and reported to the OnError handler of the DataChannel. Defaults to 16 MiB.
+func (e *SettingEngine) SetDataChannelMaxFragmentedMessageSize(size uint64) {
+	e.dataChannelMaxFragmentedMessageSize = size
+}
+
+// SetDataChannelReceiveQueueSize sets the number of received messages queued
+// for DataChannel.ReadMessage, above which the data channel isn't read anymore
+// until messages are read. Defaults to 64.
+func (e *SettingEngine) SetDataChannelReceiveQueueSize(size int) {
+	e.dataChannelReceiveQueueSize = size
+}
+
 // SetSRTPProtectionProfiles allows the user to override the default SRTP Protection Profiles
 // The default srtp protection profiles are provided by the function `defaultSrtpProtectionProfiles`
 func (e *SettingEngine) SetSRTPProtectionProfiles(profiles ...dtls.SRTPProtectionProfile) {
@@ -279,3 +343,12 @@ func (e *SettingEngine) SetICEProxyDialer(d proxy.Dialer) {
 func (e *SettingEngine) DisableMediaEngineCopy(isDisabled bool) {
 	e.disableMediaEngineCopy = isDisabled
 }
+
+// DisableMedia makes PeerConnections only carry data channels. The MediaEngine and the
+// interceptors of the API aren't used, no SRTP session is started, and no goroutine
+// reads RTP or RTCP. Offers only contain the application media section, and the audio
+// and video media sections of remote offers are rejected. Adding tracks or transceivers
+// returns ErrMediaDisabled.
+func (e *SettingEngine) DisableMedia(isDisabled bool) {
+	e.disableMedia = isDisabled
+}
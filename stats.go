@@ -779,6 +779,20 @@ type DataChannelStats struct {
 	// BytesReceived represents the total number of bytes received on this
 	// datachannel not including headers or padding.
 	BytesReceived uint64 `json:"bytesReceived"`
+
+	// MessagesQueued is the number of received messages queued for ReadMessage.
+	// It isn't part of the W3C stats.
+	MessagesQueued uint32 `json:"messagesQueued"`
+
+	// MessagesDropped is the number of messages sent with SendWithOptions that
+	// were dropped before they were handed to SCTP. Messages abandoned by SCTP
+	// partial reliability aren't counted, as pion/sctp doesn't report them. It
+	// isn't part of the W3C stats.
+	MessagesDropped uint32 `json:"messagesDropped"`
+
+	// BytesDropped is the number of payload bytes of the dropped messages.
+	// It isn't part of the W3C stats.
+	BytesDropped uint64 `json:"bytesDropped"`
 }
 
 // MediaStreamStats contains statistics related to a specific MediaStream.
@@ -1174,6 +1188,18 @@ type TransportStats struct {
 	// transport, as defined in the "Profile" column of the IANA DTLS-SRTP protection
 	// profile registry.
 	SRTPCipher string `json:"srtpCipher"`
+
+	// CongestionWindow is the congestion window of the SCTP association in bytes.
+	// Only set for the SCTP transport.
+	CongestionWindow uint32 `json:"congestionWindow"`
+
+	// ReceiverWindow is the receive window advertised by the remote peer of the SCTP
+	// association in bytes. Only set for the SCTP transport.
+	ReceiverWindow uint32 `json:"receiverWindow"`
+
+	// SmoothedRoundTripTime is the smoothed round trip time of the SCTP association
+	// in seconds. Only set for the SCTP transport.
+	SmoothedRoundTripTime float64 `json:"smoothedRoundTripTime"`
 }
 
 // StatsICECandidatePairState is the state of an ICE candidate pair used in the
@@ -15,9 +15,18 @@ type TrackLocalWriter interface {
 // in Interceptors.
 type TrackLocalContext struct {
 	id          string
+	mid         string
 	params      RTPParameters
 	ssrc        SSRC
 	writeStream TrackLocalWriter
+
+	// transportSequencer returns the next transport-wide sequence number of the
+	// PeerConnection, it is nil if the sender isn't bound to a DTLSTransport
+	transportSequencer func() uint16
+
+	// writeReady is closed once writeStream is able to send packets,
+	// before that writes are dropped. A nil channel means it is always ready
+	writeReady <-chan struct{}
 }
 
 // CodecParameters returns the negotiated RTPCodecParameters. These are the codecs supported by both
@@ -44,6 +53,12 @@ func (t *TrackLocalContext) WriteStream() TrackLocalWriter {
 	return t.writeStream
 }
 
+// Mid returns the mid of the RTPTransceiver this TrackLocal is sent with. It is empty
+// if the RTPSender isn't part of a RTPTransceiver
+func (t *TrackLocalContext) Mid() string {
+	return t.mid
+}
+
 // ID is a unique identifier that is used for both Bind/Unbind
 func (t *TrackLocalContext) ID() string {
 	return t.id
@@ -0,0 +1,325 @@
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3/internal/util"
+)
+
+// forwarderHistorySize is the amount of forwarded packets that are remembered
+// to translate NACKs back to their source
+const forwarderHistorySize = 1024
+
+type forwarderSource struct {
+	track     *TrackRemote
+	ssrc      SSRC
+	writeRTCP func([]rtcp.Packet) error
+}
+
+// requestKeyframe sends a PLI to the source, so it can be switched to
+func (s *forwarderSource) requestKeyframe() error {
+	return s.writeRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: uint32(s.ssrc)}})
+}
+
+type forwardedPacket struct {
+	valid          bool
+	sequenceNumber uint16
+	sourceSequence uint16
+	source         *forwarderSource
+}
+
+// TrackLocalForwarder is a TrackLocal that forwards the packets of one of several
+// TrackRemotes, for example the simulcast layers of a publisher or the tracks of the
+// active speaker. Switching between sources only happens on a keyframe of the new
+// source, and SSRC, sequence numbers and timestamps are rewritten so subscribers see
+// one continuous stream.
+//
+// RTCP read from the RTPSenders of the subscribers is passed to HandleRTCP, which
+// translates NACKs and keyframe requests and sends them to the RTPReceiver of the
+// source they belong to.
+type TrackLocalForwarder struct {
+	mu       sync.Mutex
+	rtpTrack *TrackLocalStaticRTP
+
+	sources         map[*TrackRemote]*forwarderSource
+	active, pending *forwarderSource
+
+	started                 bool
+	sequenceNumberOffset    uint16
+	timestampOffset         uint32
+	lastSequenceNumber      uint16
+	lastTimestamp           uint32
+	lastPacketTime          time.Time
+	history                 [forwarderHistorySize]forwardedPacket
+	onSourceSwitchedHandler func(*TrackRemote)
+}
+
+// NewTrackLocalForwarder returns a TrackLocalForwarder. The options are passed
+// to the TrackLocalStaticRTP that is used to write to every binding.
+func NewTrackLocalForwarder(c RTPCodecCapability, id, streamID string, options ...func(*TrackLocalStaticRTP)) (*TrackLocalForwarder, error) {
+	rtpTrack, err := NewTrackLocalStaticRTP(c, id, streamID, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TrackLocalForwarder{
+		rtpTrack: rtpTrack,
+		sources:  map[*TrackRemote]*forwarderSource{},
+	}, nil
+}
+
+// ID is the unique identifier for this Track. This should be unique for the
+// stream, but doesn't have to globally unique. A common example would be 'audio' or 'video'
+// and StreamID would be 'desktop' or 'webcam'
+func (f *TrackLocalForwarder) ID() string { return f.rtpTrack.ID() }
+
+// StreamID is the group this track belongs too. This must be unique
+func (f *TrackLocalForwarder) StreamID() string { return f.rtpTrack.StreamID() }
+
+// Kind controls if this TrackLocal is audio or video
+func (f *TrackLocalForwarder) Kind() RTPCodecType { return f.rtpTrack.Kind() }
+
+// Codec gets the Codec of the track
+func (f *TrackLocalForwarder) Codec() RTPCodecCapability { return f.rtpTrack.Codec() }
+
+// Bind is called by the PeerConnection after negotiation is complete
+// This asserts that the code requested is supported by the remote peer.
+// If so it setups all the state (SSRC and PayloadType) to have a call
+func (f *TrackLocalForwarder) Bind(t TrackLocalContext) (RTPCodecParameters, error) {
+	return f.rtpTrack.Bind(t)
+}
+
+// Unbind implements the teardown logic when the track is no longer needed. This happens
+// because a track has been stopped.
+func (f *TrackLocalForwarder) Unbind(t TrackLocalContext) error {
+	return f.rtpTrack.Unbind(t)
+}
+
+// AddSource registers a TrackRemote and the RTPReceiver it belongs to as a possible
+// source. The first source that is added becomes the pending one: a keyframe is
+// requested from it, and it becomes the active source once the keyframe arrives.
+func (f *TrackLocalForwarder) AddSource(track *TrackRemote, receiver *RTPReceiver) error {
+	if track == nil || receiver == nil {
+		return errTrackLocalForwarderSourceNil
+	}
+
+	return f.addSource(track, func(pkts []rtcp.Packet) error {
+		_, err := receiver.Transport().WriteRTCP(pkts)
+		return err
+	})
+}
+
+func (f *TrackLocalForwarder) addSource(track *TrackRemote, writeRTCP func([]rtcp.Packet) error) error {
+	f.mu.Lock()
+	if _, ok := f.sources[track]; ok {
+		f.mu.Unlock()
+		return errTrackLocalForwarderSourceExists
+	}
+
+	source := &forwarderSource{track: track, ssrc: track.SSRC(), writeRTCP: writeRTCP}
+	f.sources[track] = source
+	isPending := f.active == nil && f.pending == nil
+	if isPending {
+		f.pending = source
+	}
+	f.mu.Unlock()
+
+	if !isPending {
+		return nil
+	}
+	return source.requestKeyframe()
+}
+
+// RemoveSource removes a source. If it was the active source nothing is forwarded
+// until SwitchSource is called.
+func (f *TrackLocalForwarder) RemoveSource(track *TrackRemote) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	source, ok := f.sources[track]
+	if !ok {
+		return
+	}
+
+	delete(f.sources, track)
+	if f.active == source {
+		f.active = nil
+	}
+	if f.pending == source {
+		f.pending = nil
+	}
+	for i := range f.history {
+		if f.history[i].source == source {
+			f.history[i] = forwardedPacket{}
+		}
+	}
+}
+
+// SwitchSource requests to forward the given source instead of the current one.
+// A keyframe is requested from the new source, and the switch happens as soon as
+// it arrives. Until then the current source keeps being forwarded.
+func (f *TrackLocalForwarder) SwitchSource(track *TrackRemote) error {
+	f.mu.Lock()
+	source, ok := f.sources[track]
+	if !ok {
+		f.mu.Unlock()
+		return errTrackLocalForwarderSourceNotFound
+	}
+
+	if f.active == source {
+		f.pending = nil
+		f.mu.Unlock()
+		return nil
+	}
+	f.pending = source
+	f.mu.Unlock()
+
+	return source.requestKeyframe()
+}
+
+// ActiveSource returns the source that is currently being forwarded, or nil
+func (f *TrackLocalForwarder) ActiveSource() *TrackRemote {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.active == nil {
+		return nil
+	}
+	return f.active.track
+}
+
+// OnSourceSwitched sets an event handler which is invoked when a pending switch
+// completed and the given source is being forwarded
+func (f *TrackLocalForwarder) OnSourceSwitched(handler func(*TrackRemote)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onSourceSwitchedHandler = handler
+}
+
+// WriteRTP forwards a packet read from source. Packets of sources that aren't active
+// are dropped, unless it is the start of a keyframe of the pending source.
+func (f *TrackLocalForwarder) WriteRTP(source *TrackRemote, p *rtp.Packet) error {
+	f.mu.Lock()
+
+	var switchedTo *TrackRemote
+	if f.pending != nil && f.pending.track == source && f.canSwitch(p) {
+		f.switchTo(f.pending, p)
+		switchedTo = source
+	}
+
+	if f.active == nil || f.active.track != source {
+		f.mu.Unlock()
+		return nil
+	}
+
+	packet := *p
+	packet.SequenceNumber = p.SequenceNumber + f.sequenceNumberOffset
+	packet.Timestamp = p.Timestamp + f.timestampOffset
+
+	if diff := packet.SequenceNumber - f.lastSequenceNumber; diff != 0 && diff < 0x8000 {
+		f.lastSequenceNumber = packet.SequenceNumber
+		f.lastTimestamp = packet.Timestamp
+		f.lastPacketTime = time.Now()
+	}
+
+	f.history[packet.SequenceNumber%forwarderHistorySize] = forwardedPacket{
+		valid:          true,
+		sequenceNumber: packet.SequenceNumber,
+		sourceSequence: p.SequenceNumber,
+		source:         f.active,
+	}
+
+	handler := f.onSourceSwitchedHandler
+	f.mu.Unlock()
+
+	if switchedTo != nil && handler != nil {
+		go handler(switchedTo)
+	}
+
+	return f.rtpTrack.writeRTP(&packet, nil)
+}
+
+// canSwitch reports if p is a valid point to start forwarding a new source
+func (f *TrackLocalForwarder) canSwitch(p *rtp.Packet) bool {
+	mimeType := f.rtpTrack.Codec().MimeType
+	return !canDetectKeyframes(mimeType) || isKeyframeStart(mimeType, p.Payload)
+}
+
+// switchTo makes source active and computes the offsets so that p directly
+// follows the last forwarded packet. Caller must hold the lock.
+func (f *TrackLocalForwarder) switchTo(source *forwarderSource, p *rtp.Packet) {
+	f.active = source
+	f.pending = nil
+
+	if !f.started {
+		f.started = true
+		f.lastSequenceNumber = p.SequenceNumber - 1
+		f.lastTimestamp = p.Timestamp
+		f.lastPacketTime = time.Now()
+		return
+	}
+
+	// Advance the timestamp by the time that passed since the last packet, so
+	// the receiver doesn't see the new source as a burst of late frames
+	timestampDelta := uint32(1)
+	if clockRate := f.rtpTrack.Codec().ClockRate; clockRate != 0 {
+		if elapsed := uint32(time.Since(f.lastPacketTime).Seconds() * float64(clockRate)); elapsed > timestampDelta {
+			timestampDelta = elapsed
+		}
+	}
+
+	f.sequenceNumberOffset = f.lastSequenceNumber + 1 - p.SequenceNumber
+	f.timestampOffset = f.lastTimestamp + timestampDelta - p.Timestamp
+}
+
+// HandleRTCP translates RTCP received from a subscriber and sends it to the sources.
+// PLIs and FIRs are sent as a PLI to the active source, NACKs are rewritten to the
+// original sequence numbers and sent to the source that the lost packets came from.
+// Other packets are ignored.
+func (f *TrackLocalForwarder) HandleRTCP(pkts []rtcp.Packet) error {
+	f.mu.Lock()
+	requestKeyframe := false
+	lost := map[*forwarderSource][]uint16{}
+
+	for _, pkt := range pkts {
+		switch pkt := pkt.(type) {
+		case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+			requestKeyframe = true
+		case *rtcp.TransportLayerNack:
+			for i := range pkt.Nacks {
+				pkt.Nacks[i].Range(func(seq uint16) bool {
+					if h := f.history[seq%forwarderHistorySize]; h.valid && h.sequenceNumber == seq {
+						lost[h.source] = append(lost[h.source], h.sourceSequence)
+					}
+					return true
+				})
+			}
+		}
+	}
+
+	active := f.active
+	f.mu.Unlock()
+
+	var errs []error
+	if requestKeyframe && active != nil {
+		if err := active.writeRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: uint32(active.ssrc)}}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for source, sequenceNumbers := range lost {
+		if err := source.writeRTCP([]rtcp.Packet{&rtcp.TransportLayerNack{
+			MediaSSRC: uint32(source.ssrc),
+			Nacks:     rtcp.NackPairsFromSequenceNumbers(sequenceNumbers),
+		}}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return util.FlattenErrs(errs)
+}
@@ -0,0 +1,109 @@
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TrackLocalForwarder(t *testing.T) {
+	vp8Keyframe := []byte{0x10, 0x00}
+	vp8Interframe := []byte{0x10, 0x01}
+
+	forwarder, err := NewTrackLocalForwarder(RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000}, "video", "pion")
+	assert.NoError(t, err)
+
+	writer := &countingTrackLocalWriter{packets: make(chan rtp.Header, 10)}
+	_, err = forwarder.Bind(newTestTrackLocalContext("subscriber", 1234, writer))
+	assert.NoError(t, err)
+
+	low, high := &TrackRemote{ssrc: 1}, &TrackRemote{ssrc: 2}
+	lowRTCP, highRTCP := []rtcp.Packet{}, []rtcp.Packet{}
+	assert.NoError(t, forwarder.addSource(low, func(pkts []rtcp.Packet) error {
+		lowRTCP = append(lowRTCP, pkts...)
+		return nil
+	}))
+	assert.NoError(t, forwarder.addSource(high, func(pkts []rtcp.Packet) error {
+		highRTCP = append(highRTCP, pkts...)
+		return nil
+	}))
+	assert.ErrorIs(t, forwarder.addSource(low, nil), errTrackLocalForwarderSourceExists)
+
+	// A keyframe is only requested from the first source, which is pending
+	assert.Equal(t, []rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 1}}, lowRTCP)
+	assert.Empty(t, highRTCP)
+
+	write := func(source *TrackRemote, seq uint16, ts uint32, payload []byte) {
+		assert.NoError(t, forwarder.WriteRTP(source, &rtp.Packet{
+			Header:  rtp.Header{SSRC: uint32(source.ssrc), SequenceNumber: seq, Timestamp: ts},
+			Payload: payload,
+		}))
+	}
+	expect := func(seq uint16, ts uint32) {
+		header := <-writer.packets
+		assert.Equal(t, uint32(1234), header.SSRC)
+		assert.Equal(t, seq, header.SequenceNumber)
+		assert.Equal(t, ts, header.Timestamp)
+	}
+
+	// Nothing is forwarded until the first source sends a keyframe
+	write(low, 100, 1000, vp8Interframe)
+	write(low, 101, 1000, vp8Keyframe)
+	expect(101, 1000)
+	write(low, 102, 4000, vp8Interframe)
+	expect(102, 4000)
+	assert.Equal(t, low, forwarder.ActiveSource())
+
+	assert.ErrorIs(t, forwarder.SwitchSource(&TrackRemote{}), errTrackLocalForwarderSourceNotFound)
+	assert.NoError(t, forwarder.SwitchSource(high))
+	assert.Equal(t, []rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 2}}, highRTCP)
+
+	// The current source keeps being forwarded until the new one sends a keyframe
+	write(high, 5000, 90000, vp8Interframe)
+	write(low, 103, 7000, vp8Interframe)
+	expect(103, 7000)
+
+	write(high, 5001, 93000, vp8Keyframe)
+	header := <-writer.packets
+	assert.Equal(t, uint16(104), header.SequenceNumber)
+	assert.True(t, header.Timestamp > 7000, "Timestamp must keep increasing after a switch")
+	timestampOffset := header.Timestamp - 93000
+
+	write(low, 104, 10000, vp8Interframe)
+	write(high, 5002, 96000, vp8Interframe)
+	expect(105, 96000+timestampOffset)
+	assert.Equal(t, high, forwarder.ActiveSource())
+
+	t.Run("HandleRTCP", func(t *testing.T) {
+		lowRTCP, highRTCP = nil, nil
+
+		assert.NoError(t, forwarder.HandleRTCP([]rtcp.Packet{
+			&rtcp.PictureLossIndication{MediaSSRC: 1234},
+			&rtcp.TransportLayerNack{MediaSSRC: 1234, Nacks: rtcp.NackPairsFromSequenceNumbers([]uint16{102, 103, 105, 200})},
+		}))
+
+		assert.Equal(t, []rtcp.Packet{
+			&rtcp.TransportLayerNack{MediaSSRC: 1, Nacks: rtcp.NackPairsFromSequenceNumbers([]uint16{102, 103})},
+		}, lowRTCP)
+		assert.Equal(t, []rtcp.Packet{
+			&rtcp.PictureLossIndication{MediaSSRC: 2},
+			&rtcp.TransportLayerNack{MediaSSRC: 2, Nacks: rtcp.NackPairsFromSequenceNumbers([]uint16{5002})},
+		}, highRTCP)
+	})
+
+	t.Run("RemoveSource", func(t *testing.T) {
+		forwarder.RemoveSource(high)
+		assert.Nil(t, forwarder.ActiveSource())
+
+		write(high, 5003, 99000, vp8Keyframe)
+		select {
+		case <-writer.packets:
+			assert.Fail(t, "Removed source must not be forwarded")
+		default:
+		}
+	})
+}
@@ -5,8 +5,13 @@ package webrtc
 import (
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/pion/interceptor"
 	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3/internal/senderreport"
 	"github.com/pion/webrtc/v3/internal/util"
 	"github.com/pion/webrtc/v3/pkg/media"
 )
@@ -19,6 +24,132 @@ type trackBinding struct {
 	ssrc        SSRC
 	payloadType PayloadType
 	writeStream TrackLocalWriter
+	queue       *bindingQueue
+
+	// headerExtensions maps the URI of every header extension the binding
+	// negotiated to its ID
+	headerExtensions   map[string]uint8
+	mid                []byte
+	sendTimeExtensions *sendTimeExtensions
+
+	// red wraps the packets into RED packets, if enabled and negotiated
+	red *redEncoder
+}
+
+// sendTimeExtensions writes the header extensions of a binding that describe when a
+// packet is sent. With a binding queue, they are written when a packet leaves the
+// queue, so packets that are dropped don't use transport-wide sequence numbers.
+type sendTimeExtensions struct {
+	absSendTimeID      uint8
+	transportCCID      uint8
+	transportSequencer func() uint16
+	profile            uint16
+}
+
+func newSendTimeExtensions(headerExtensions map[string]uint8, transportSequencer func() uint16) *sendTimeExtensions {
+	e := &sendTimeExtensions{
+		absSendTimeID: headerExtensions[sdp.ABSSendTimeURI],
+		profile:       extensionProfileOneByte,
+	}
+	if transportSequencer != nil {
+		e.transportCCID = headerExtensions[sdp.TransportCCURI]
+		e.transportSequencer = transportSequencer
+	}
+	for _, id := range headerExtensions {
+		if id > maxOneByteHeaderExtensionID {
+			e.profile = extensionProfileTwoByte
+		}
+	}
+	return e
+}
+
+// set adds the abs-send-time and transport-wide-cc extensions to a packet sent at sendTime
+func (e *sendTimeExtensions) set(h *rtp.Header, sendTime time.Time) error {
+	if e.absSendTimeID == 0 && e.transportCCID == 0 {
+		return nil
+	}
+
+	if !h.Extension {
+		h.Extension = true
+		h.ExtensionProfile = e.profile
+	}
+
+	if e.absSendTimeID != 0 {
+		payload, err := rtp.NewAbsSendTimeExtension(sendTime).Marshal()
+		if err != nil {
+			return err
+		}
+		if err := h.SetExtension(e.absSendTimeID, payload); err != nil {
+			return err
+		}
+	}
+
+	if e.transportCCID != 0 {
+		payload, err := (&rtp.TransportCCExtension{TransportSequence: e.transportSequencer()}).Marshal()
+		if err != nil {
+			return err
+		}
+		if err := h.SetExtension(e.transportCCID, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *trackBinding) stats() TrackLocalBindingStats {
+	return TrackLocalBindingStats{
+		ID:             b.id,
+		SSRC:           b.ssrc,
+		QueueDepth:     b.queue.depth(),
+		PacketsDropped: atomic.LoadUint64(&b.queue.dropped),
+	}
+}
+
+const (
+	extensionProfileOneByte     = 0xBEDE
+	extensionProfileTwoByte     = 0x1000
+	maxOneByteHeaderExtensionID = 14
+)
+
+type trackAttributeKey int
+
+// audioLevelAttributeKey is the interceptor.Attributes key of the media.AudioLevel
+// sent in the ssrc-audio-level header extension of a packet
+const audioLevelAttributeKey trackAttributeKey = iota
+
+func audioLevelFromAttributes(attributes interceptor.Attributes) (media.AudioLevel, bool) {
+	if attributes == nil {
+		return media.AudioLevel{}, false
+	}
+
+	audioLevel, ok := attributes.Get(audioLevelAttributeKey).(media.AudioLevel)
+	return audioLevel, ok
+}
+
+// cloneAttributes returns a shallow copy of attributes
+func cloneAttributes(attributes interceptor.Attributes) interceptor.Attributes {
+	if attributes == nil {
+		return nil
+	}
+
+	clone := make(interceptor.Attributes, len(attributes))
+	for key, value := range attributes {
+		clone[key] = value
+	}
+	return clone
+}
+
+// isPerBindingHeaderExtension reports if the extension describes a single outbound
+// stream. These are never forwarded from the source, the ones TrackLocalStaticRTP
+// supports are generated for every binding instead
+func isPerBindingHeaderExtension(uri string) bool {
+	switch uri {
+	case sdp.SDESMidURI, sdp.SDESRTPStreamIDURI, sdp.ABSSendTimeURI, sdp.TransportCCURI:
+		return true
+	default:
+		return false
+	}
 }
 
 // TrackLocalStaticRTP  is a TrackLocal that has a pre-set codec and accepts RTP Packets.
@@ -28,16 +159,40 @@ type TrackLocalStaticRTP struct {
 	bindings     []trackBinding
 	codec        RTPCodecCapability
 	id, streamID string
+
+	queueSize     int
+	dropPolicy    BindingDropPolicy
+	redDistance   int
+	slowThreshold time.Duration
+	onSlowBinding func(TrackLocalBindingStats)
+
+	// sourceHeaderExtensions maps the ID of every header extension of incoming packets to its URI
+	sourceHeaderExtensions map[uint8]string
+
+	gopCache                *gopCache
+	onKeyframeRequest       func()
+	keyframeRequestInterval time.Duration
+	keyframeRequestMu       sync.Mutex
+	lastKeyframeRequest     time.Time
 }
 
 // NewTrackLocalStaticRTP returns a TrackLocalStaticRTP.
-func NewTrackLocalStaticRTP(c RTPCodecCapability, id, streamID string) (*TrackLocalStaticRTP, error) {
-	return &TrackLocalStaticRTP{
-		codec:    c,
-		bindings: []trackBinding{},
-		id:       id,
-		streamID: streamID,
-	}, nil
+func NewTrackLocalStaticRTP(c RTPCodecCapability, id, streamID string, options ...func(*TrackLocalStaticRTP)) (*TrackLocalStaticRTP, error) {
+	t := &TrackLocalStaticRTP{
+		codec:         c,
+		bindings:      []trackBinding{},
+		id:            id,
+		streamID:      streamID,
+		slowThreshold: defaultSlowBindingThreshold,
+
+		keyframeRequestInterval: defaultKeyframeRequestInterval,
+	}
+
+	for _, o := range options {
+		o(t)
+	}
+
+	return t, nil
 }
 
 // Bind is called by the PeerConnection after negotiation is complete
@@ -49,12 +204,42 @@ func (s *TrackLocalStaticRTP) Bind(t TrackLocalContext) (RTPCodecParameters, err
 
 	parameters := RTPCodecParameters{RTPCodecCapability: s.codec}
 	if codec, matchType := codecParametersFuzzySearch(parameters, t.CodecParameters()); matchType != codecMatchNone {
-		s.bindings = append(s.bindings, trackBinding{
-			ssrc:        t.SSRC(),
-			payloadType: codec.PayloadType,
-			writeStream: t.WriteStream(),
-			id:          t.ID(),
-		})
+		binding := trackBinding{
+			ssrc:             t.SSRC(),
+			payloadType:      codec.PayloadType,
+			writeStream:      t.WriteStream(),
+			id:               t.ID(),
+			headerExtensions: map[string]uint8{},
+			mid:              []byte(t.Mid()),
+		}
+		for _, e := range t.HeaderExtensions() {
+			binding.headerExtensions[e.URI] = uint8(e.ID)
+		}
+		binding.sendTimeExtensions = newSendTimeExtensions(binding.headerExtensions, t.transportSequencer)
+
+		if s.redDistance > 0 {
+			if red, ok := findREDCodec(t.CodecParameters(), codec.PayloadType); ok {
+				binding.red = newREDEncoder(red.PayloadType, s.redDistance)
+			}
+		}
+
+		queueSize := s.queueSize
+		if s.gopCache != nil && queueSize < 2*s.gopCache.maxPackets {
+			queueSize = 2 * s.gopCache.maxPackets
+		}
+		if queueSize > 0 {
+			binding.queue = newBindingQueue(queueSize, s.dropPolicy, s.codec.MimeType)
+			go binding.queue.run(binding.writeStream, binding.sendTimeExtensions, t.writeReady)
+		}
+
+		if s.gopCache != nil {
+			if err := s.replayGOPCache(&binding); err != nil {
+				binding.queue.close()
+				return RTPCodecParameters{}, err
+			}
+		}
+
+		s.bindings = append(s.bindings, binding)
 		return codec, nil
 	}
 
@@ -69,6 +254,9 @@ func (s *TrackLocalStaticRTP) Unbind(t TrackLocalContext) error {
 
 	for i := range s.bindings {
 		if s.bindings[i].id == t.ID() {
+			if s.bindings[i].queue != nil {
+				s.bindings[i].queue.close()
+			}
 			s.bindings[i] = s.bindings[len(s.bindings)-1]
 			s.bindings = s.bindings[:len(s.bindings)-1]
 			return nil
@@ -123,27 +311,179 @@ func (s *TrackLocalStaticRTP) WriteRTP(p *rtp.Packet) error {
 		rtpPacketPool.Put(ipacket)
 	}()
 	*packet = *p
-	return s.writeRTP(packet)
+	return s.writeRTP(packet, nil)
 }
 
-// writeRTP is like WriteRTP, except that it may modify the packet p
-func (s *TrackLocalStaticRTP) writeRTP(p *rtp.Packet) error {
+// writeRTP is like WriteRTP, except that it may modify the packet p.
+// The attributes are passed to the interceptors of every binding.
+func (s *TrackLocalStaticRTP) writeRTP(p *rtp.Packet, attributes interceptor.Attributes) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	writeErrs := []error{}
+	var slowBindings []TrackLocalBindingStats
 
-	for _, b := range s.bindings {
-		p.Header.SSRC = uint32(b.ssrc)
-		p.Header.PayloadType = uint8(b.payloadType)
-		if _, err := b.writeStream.WriteRTP(&p.Header, p.Payload); err != nil {
+	if s.gopCache != nil && !s.gopCache.push(s.codec.MimeType, p) {
+		s.requestKeyframe()
+	}
+
+	source := p.Header
+	payload := p.Payload
+	defer func() {
+		p.Payload = payload
+	}()
+
+	for i := range s.bindings {
+		isSlow, err := s.writeBinding(&s.bindings[i], p, &source, payload, attributes)
+		if err != nil {
 			writeErrs = append(writeErrs, err)
 		}
+		if isSlow {
+			slowBindings = append(slowBindings, s.bindings[i].stats())
+		}
+	}
+
+	if handler := s.onSlowBinding; handler != nil {
+		for _, stats := range slowBindings {
+			go handler(stats)
+		}
 	}
 
 	return util.FlattenErrs(writeErrs)
 }
 
+// writeBinding writes p with the payload type, SSRC and header extensions of the
+// binding, and returns true if the binding is slow. Every binding gets its own copy
+// of attributes and header extensions, as its interceptors may modify or keep them.
+func (s *TrackLocalStaticRTP) writeBinding(b *trackBinding, p *rtp.Packet, source *rtp.Header, payload []byte, attributes interceptor.Attributes) (bool, error) {
+	attributes = cloneAttributes(attributes)
+	p.Header.SSRC = uint32(b.ssrc)
+	p.Header.PayloadType = uint8(b.payloadType)
+	p.Payload = payload
+	if b.red != nil {
+		p.Payload = b.red.encode(&p.Header, payload)
+		p.Header.PayloadType = uint8(b.red.payloadType)
+	}
+
+	p.Header.Extensions = nil
+	if err := s.setHeaderExtensions(&p.Header, source, b, attributes); err != nil {
+		return false, err
+	}
+
+	if b.queue != nil {
+		return b.queue.push(p, attributes, s.slowThreshold)
+	}
+
+	if err := b.sendTimeExtensions.set(&p.Header, time.Now()); err != nil {
+		return false, err
+	}
+	_, err := writeRTPWithAttributes(b.writeStream, &p.Header, p.Payload, attributes)
+	return false, err
+}
+
+// setHeaderExtensions writes the header extensions of source into dst using the IDs
+// negotiated by the binding, and adds the sdes:mid extension and the extensions passed
+// in attributes. Without the header extensions of the source, its extensions are written
+// unchanged. The extensions describing when the packet is sent are added by
+// sendTimeExtensions.
+func (s *TrackLocalStaticRTP) setHeaderExtensions(dst, source *rtp.Header, b *trackBinding, attributes interceptor.Attributes) error {
+	dst.Extension = true
+	dst.ExtensionProfile = extensionProfileOneByte
+	if s.sourceHeaderExtensions == nil && source.Extension && source.ExtensionProfile&0xFFF0 == extensionProfileTwoByte {
+		dst.ExtensionProfile = extensionProfileTwoByte
+	}
+	for _, id := range b.headerExtensions {
+		if id > maxOneByteHeaderExtensionID {
+			dst.ExtensionProfile = extensionProfileTwoByte
+		}
+	}
+
+	for _, sourceID := range source.GetExtensionIDs() {
+		// RFC 3550 extensions have no ID and can't be combined with the generated ones
+		if s.sourceHeaderExtensions == nil && sourceID != 0 {
+			if err := dst.SetExtension(sourceID, source.GetExtension(sourceID)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		uri, ok := s.sourceHeaderExtensions[sourceID]
+		if !ok || isPerBindingHeaderExtension(uri) {
+			continue
+		}
+
+		if id, ok := b.headerExtensions[uri]; ok {
+			if err := dst.SetExtension(id, source.GetExtension(sourceID)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if id, ok := b.headerExtensions[sdp.SDESMidURI]; ok && len(b.mid) != 0 {
+		if err := dst.SetExtension(id, b.mid); err != nil {
+			return err
+		}
+	}
+
+	if audioLevel, ok := audioLevelFromAttributes(attributes); ok {
+		if id, ok := b.headerExtensions[sdp.AudioLevelURI]; ok {
+			payload, err := (&rtp.AudioLevelExtension{Level: audioLevel.Level, Voice: audioLevel.Voice}).Marshal()
+			if err != nil {
+				return err
+			}
+			if err := dst.SetExtension(id, payload); err != nil {
+				return err
+			}
+		}
+	}
+
+	dst.Extension = len(dst.Extensions) != 0
+	return nil
+}
+
+// SetSourceHeaderExtensions sets the header extensions negotiated by the source of the
+// packets written to this track, usually the result of TrackRemote.HeaderExtensions.
+// Extensions of incoming packets are then rewritten to the ID every binding negotiated,
+// and stripped if the binding didn't negotiate them. Until it is called, the extensions
+// of incoming packets are forwarded unchanged.
+//
+// The sdes:mid, abs-send-time and transport-wide-cc extensions are always generated
+// per binding if it negotiated them.
+func (s *TrackLocalStaticRTP) SetSourceHeaderExtensions(extensions []RTPHeaderExtensionParameter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sourceHeaderExtensions = map[uint8]string{}
+	for _, e := range extensions {
+		s.sourceHeaderExtensions[uint8(e.ID)] = e.URI
+	}
+}
+
+// OnSlowBinding sets an event handler which is invoked when a binding has been
+// dropping packets for longer than the threshold set by WithSlowBindingThreshold.
+// Use it to find and remove PeerConnections that can't keep up. Only used together
+// with WithBindingQueue.
+func (s *TrackLocalStaticRTP) OnSlowBinding(f func(TrackLocalBindingStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onSlowBinding = f
+}
+
+// BindingStats returns the queue statistics of every binding. Only populated
+// when the track was created with WithBindingQueue.
+func (s *TrackLocalStaticRTP) BindingStats() []TrackLocalBindingStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := []TrackLocalBindingStats{}
+	for _, b := range s.bindings {
+		if b.queue != nil {
+			stats = append(stats, b.stats())
+		}
+	}
+	return stats
+}
+
 // Write writes a RTP Packet as a buffer to the TrackLocalStaticRTP
 // If one PeerConnection fails the packets will still be sent to
 // all PeerConnections. The error message will contain the ID of the failed
@@ -160,7 +500,7 @@ func (s *TrackLocalStaticRTP) Write(b []byte) (n int, err error) {
 		return 0, err
 	}
 
-	return len(b), s.writeRTP(packet)
+	return len(b), s.writeRTP(packet, nil)
 }
 
 // TrackLocalStaticSample is a TrackLocal that has a pre-set codec and accepts Samples.
@@ -170,11 +510,22 @@ type TrackLocalStaticSample struct {
 	sequencer  rtp.Sequencer
 	rtpTrack   *TrackLocalStaticRTP
 	clockRate  float64
+
+	captureTimeBase     *sampleTimestampBase
+	packetTimestampBase *sampleTimestampBase
+}
+
+// sampleTimestampBase maps the timestamp of the first sample that had one to the
+// RTP timestamp it was sent with. Later samples are sent relative to it.
+type sampleTimestampBase struct {
+	captureTime     time.Time
+	packetTimestamp uint32
+	rtpTimestamp    uint32
 }
 
 // NewTrackLocalStaticSample returns a TrackLocalStaticSample
-func NewTrackLocalStaticSample(c RTPCodecCapability, id, streamID string) (*TrackLocalStaticSample, error) {
-	rtpTrack, err := NewTrackLocalStaticRTP(c, id, streamID)
+func NewTrackLocalStaticSample(c RTPCodecCapability, id, streamID string, options ...func(*TrackLocalStaticRTP)) (*TrackLocalStaticSample, error) {
+	rtpTrack, err := NewTrackLocalStaticRTP(c, id, streamID, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -245,6 +596,15 @@ func (s *TrackLocalStaticSample) Unbind(t TrackLocalContext) error {
 // If one PeerConnection fails the packets will still be sent to
 // all PeerConnections. The error message will contain the ID of the failed
 // PeerConnections so you can remove them
+//
+// The RTP timestamp is derived from the capture time in Sample.Timestamp if it is set,
+// which has to be taken from the same clock as time.Now. The capture time is also passed
+// to the interceptors, so Sender Reports map RTP timestamps to it and remote receivers
+// are able to synchronize tracks. Samples without a Timestamp use the RTP timestamp
+// in Sample.PacketTimestamp if it is set, and Sample.Duration otherwise.
+//
+// Sample.AudioLevel is sent to every PeerConnection that negotiated the ssrc-audio-level
+// header extension, see MediaEngine.RegisterHeaderExtension.
 func (s *TrackLocalStaticSample) WriteSample(sample media.Sample) error {
 	s.rtpTrack.mu.RLock()
 	p := s.packetizer
@@ -265,13 +625,56 @@ func (s *TrackLocalStaticSample) WriteSample(sample media.Sample) error {
 		p.(rtp.Packetizer).SkipSamples(samples * uint32(sample.PrevDroppedPackets))
 	}
 	packets := p.(rtp.Packetizer).Packetize(sample.Data, samples)
+	if len(packets) == 0 {
+		return nil
+	}
+
+	if rtpTimestamp, ok := s.sampleRTPTimestamp(sample, packets[0].Timestamp, clockRate); ok {
+		// Keep the packetizer in sync, so samples without a timestamp continue from here
+		p.(rtp.Packetizer).SkipSamples(rtpTimestamp - packets[0].Timestamp)
+		for _, packet := range packets {
+			packet.Timestamp = rtpTimestamp
+		}
+	}
+
+	var attributes interceptor.Attributes
+	if !sample.Timestamp.IsZero() || sample.AudioLevel != nil {
+		attributes = interceptor.Attributes{}
+	}
+	if !sample.Timestamp.IsZero() {
+		senderreport.SetCaptureTime(attributes, sample.Timestamp)
+	}
+	if sample.AudioLevel != nil {
+		attributes.Set(audioLevelAttributeKey, *sample.AudioLevel)
+	}
 
 	writeErrs := []error{}
-	for _, p := range packets {
-		if err := s.rtpTrack.WriteRTP(p); err != nil {
+	for _, packet := range packets {
+		if err := s.rtpTrack.writeRTP(packet, attributes); err != nil {
 			writeErrs = append(writeErrs, err)
 		}
 	}
 
 	return util.FlattenErrs(writeErrs)
 }
+
+// sampleRTPTimestamp returns the RTP timestamp of a sample derived from its Timestamp or
+// PacketTimestamp. nextRTPTimestamp is the RTP timestamp the packetizer would use, it becomes
+// the base of the first sample with a timestamp. It returns false if the sample has neither.
+func (s *TrackLocalStaticSample) sampleRTPTimestamp(sample media.Sample, nextRTPTimestamp uint32, clockRate float64) (uint32, bool) {
+	switch {
+	case !sample.Timestamp.IsZero():
+		if s.captureTimeBase == nil {
+			s.captureTimeBase = &sampleTimestampBase{captureTime: sample.Timestamp, rtpTimestamp: nextRTPTimestamp}
+		}
+		elapsed := sample.Timestamp.Sub(s.captureTimeBase.captureTime)
+		return s.captureTimeBase.rtpTimestamp + uint32(int64(elapsed.Seconds()*clockRate)), true
+	case sample.PacketTimestamp != 0:
+		if s.packetTimestampBase == nil {
+			s.packetTimestampBase = &sampleTimestampBase{packetTimestamp: sample.PacketTimestamp, rtpTimestamp: nextRTPTimestamp}
+		}
+		return s.packetTimestampBase.rtpTimestamp + sample.PacketTimestamp - s.packetTimestampBase.packetTimestamp, true
+	default:
+		return 0, false
+	}
+}
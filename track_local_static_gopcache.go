@@ -0,0 +1,188 @@
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+const defaultKeyframeRequestInterval = time.Second
+
+// WithGOPCache enables a cache of every packet since the last keyframe, up to
+// maxPackets. When a new binding is created it is sent the cached packets right
+// away, so new subscribers don't have to wait for the next keyframe. The timestamps
+// of the cached frames are squeezed together so they are decoded immediately
+// instead of being played back at the original pace.
+//
+// The cache is only filled for codecs where keyframes can be detected. Tracks with a
+// GOP cache always use a per-binding queue (see WithBindingQueue) that holds at
+// least twice maxPackets, as the cache is sent before the binding is able to send.
+func WithGOPCache(maxPackets int) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.gopCache = &gopCache{maxPackets: maxPackets}
+	}
+}
+
+// WithKeyframeRequestInterval sets the minimum time between two calls of the
+// OnKeyframeRequest handler. Keyframe requests from all subscribers in between are
+// coalesced into one.
+func WithKeyframeRequestInterval(interval time.Duration) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.keyframeRequestInterval = interval
+	}
+}
+
+// gopCache holds the packets of the current group of pictures
+type gopCache struct {
+	mu         sync.Mutex
+	maxPackets int
+	packets    [][]byte
+
+	// valid is false until a keyframe is seen, and after the cache overflowed
+	valid bool
+
+	// keyframeTimestamp is the RTP timestamp of the latest keyframe, only set if hasKeyframe is
+	keyframeTimestamp uint32
+	hasKeyframe       bool
+}
+
+// push adds p to the cache, resetting it if p starts a new keyframe.
+// It returns false if p didn't fit into the cache.
+//
+// A keyframe may start with several packets, like the parameter sets of H264 and H265
+// followed by the first slice of the IDR picture. Only the first of them resets the
+// cache, the packets of a frame share its RTP timestamp.
+func (g *gopCache) push(mimeType string, p *rtp.Packet) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if isKeyframeStart(mimeType, p.Payload) && (!g.hasKeyframe || p.Timestamp != g.keyframeTimestamp) {
+		g.packets = g.packets[:0]
+		g.valid = true
+		g.keyframeTimestamp = p.Timestamp
+		g.hasKeyframe = true
+	}
+
+	if !g.valid {
+		return true
+	}
+
+	if len(g.packets) >= g.maxPackets {
+		g.packets = g.packets[:0]
+		g.valid = false
+		return false
+	}
+
+	raw, err := p.Marshal()
+	if err != nil {
+		return true
+	}
+	g.packets = append(g.packets, raw)
+	return true
+}
+
+// snapshot returns the cached packets with the timestamps of all frames but the
+// last one rewritten to directly precede it
+func (g *gopCache) snapshot() []*rtp.Packet {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.valid || len(g.packets) == 0 {
+		return nil
+	}
+
+	packets := make([]*rtp.Packet, 0, len(g.packets))
+	for _, raw := range g.packets {
+		p := &rtp.Packet{}
+		if err := p.Unmarshal(raw); err != nil {
+			continue
+		}
+		packets = append(packets, p)
+	}
+
+	// Walk backwards, every time the timestamp changes we are in the previous frame
+	last := packets[len(packets)-1].Timestamp
+	previous := last
+	framesFromEnd := uint32(0)
+	for i := len(packets) - 1; i >= 0; i-- {
+		if packets[i].Timestamp != previous {
+			framesFromEnd++
+			previous = packets[i].Timestamp
+		}
+		packets[i].Timestamp = last - framesFromEnd
+	}
+
+	return packets
+}
+
+// replayGOPCache queues the cached GOP for a new binding. Caller must hold the lock.
+func (s *TrackLocalStaticRTP) replayGOPCache(b *trackBinding) error {
+	packets := s.gopCache.snapshot()
+	if len(packets) == 0 {
+		s.requestKeyframe()
+		return nil
+	}
+
+	for _, p := range packets {
+		source := p.Header
+		p.Header.SSRC = uint32(b.ssrc)
+		p.Header.PayloadType = uint8(b.payloadType)
+		p.Header.Extensions = nil
+		if err := s.setHeaderExtensions(&p.Header, &source, b, nil); err != nil {
+			return err
+		}
+
+		if _, err := b.queue.push(p, nil, s.slowThreshold); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// OnKeyframeRequest sets an event handler which is invoked when subscribers need a
+// keyframe, usually to send a PLI to the source of this track. It is invoked at most
+// once per interval set by WithKeyframeRequestInterval.
+func (s *TrackLocalStaticRTP) OnKeyframeRequest(f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onKeyframeRequest = f
+}
+
+// HandleRTCP processes RTCP read from the RTPSender of a subscriber. PLIs and FIRs
+// are coalesced and passed to the OnKeyframeRequest handler.
+func (s *TrackLocalStaticRTP) HandleRTCP(pkts []rtcp.Packet) {
+	for _, pkt := range pkts {
+		switch pkt.(type) {
+		case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+			s.mu.RLock()
+			s.requestKeyframe()
+			s.mu.RUnlock()
+			return
+		}
+	}
+}
+
+// requestKeyframe fires the OnKeyframeRequest handler unless it was fired less
+// than keyframeRequestInterval ago. Caller must hold the lock.
+func (s *TrackLocalStaticRTP) requestKeyframe() {
+	handler := s.onKeyframeRequest
+	if handler == nil {
+		return
+	}
+
+	s.keyframeRequestMu.Lock()
+	defer s.keyframeRequestMu.Unlock()
+
+	now := time.Now()
+	if !s.lastKeyframeRequest.IsZero() && now.Sub(s.lastKeyframeRequest) < s.keyframeRequestInterval {
+		return
+	}
+	s.lastKeyframeRequest = now
+
+	go handler()
+}
@@ -0,0 +1,264 @@
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+)
+
+// BindingDropPolicy controls which packets are discarded when the send queue
+// of a TrackLocalStaticRTP binding is full
+type BindingDropPolicy int
+
+const (
+	// BindingDropOldest discards the oldest queued packet to make room for the new one
+	BindingDropOldest BindingDropPolicy = iota + 1
+
+	// BindingDropUntilKeyframe discards the whole queue and every following packet
+	// until the start of the next keyframe. This avoids sending partial frames that
+	// can't be decoded anyway. Codecs without keyframe detection fall back to BindingDropOldest
+	BindingDropUntilKeyframe
+)
+
+func (p BindingDropPolicy) String() string {
+	switch p {
+	case BindingDropOldest:
+		return "drop-oldest"
+	case BindingDropUntilKeyframe:
+		return "drop-until-keyframe"
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// TrackLocalBindingStats describes the send queue of a single TrackLocalStaticRTP binding
+type TrackLocalBindingStats struct {
+	// ID is the ID of the TrackLocalContext this binding was created with
+	ID string
+
+	// SSRC is the SSRC packets are sent with for this binding
+	SSRC SSRC
+
+	// QueueDepth is the number of packets waiting to be sent
+	QueueDepth int
+
+	// PacketsDropped is the total number of packets discarded because the queue was full
+	PacketsDropped uint64
+}
+
+// WithBindingQueue enables asynchronous fan-out for a TrackLocalStaticRTP. Every
+// binding gets its own queue of up to size packets and a goroutine that writes them,
+// so a single slow PeerConnection no longer delays the others. When a queue is full
+// packets are dropped according to policy.
+func WithBindingQueue(size int, policy BindingDropPolicy) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.queueSize = size
+		s.dropPolicy = policy
+	}
+}
+
+// WithSlowBindingThreshold sets how long a binding has to continuously drop packets
+// before the OnSlowBinding handler is fired. Only used together with WithBindingQueue.
+func WithSlowBindingThreshold(threshold time.Duration) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.slowThreshold = threshold
+	}
+}
+
+const defaultSlowBindingThreshold = 5 * time.Second
+
+// bindingQueue is a bounded queue of marshaled packets for a single binding,
+// drained by its own goroutine
+type bindingQueue struct {
+	mu         sync.Mutex
+	packets    [][]byte
+	attributes []interceptor.Attributes
+	head       int
+	length     int
+
+	policy          BindingDropPolicy
+	mimeType        string
+	waitForKeyframe bool
+
+	dropped   uint64
+	dropStart time.Time
+	reported  bool
+
+	notify, done chan struct{}
+}
+
+func newBindingQueue(size int, policy BindingDropPolicy, mimeType string) *bindingQueue {
+	if policy != BindingDropUntilKeyframe || !canDetectKeyframes(mimeType) {
+		policy = BindingDropOldest
+	}
+
+	return &bindingQueue{
+		packets:    make([][]byte, size),
+		attributes: make([]interceptor.Attributes, size),
+		policy:     policy,
+		mimeType:   mimeType,
+		notify:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// push adds a packet to the queue, dropping packets if it is full. It returns
+// true if the binding has been dropping packets for longer than slowThreshold.
+// The caller is only told once for every period of congestion. The attributes
+// are passed to the interceptors when the packet is written.
+func (q *bindingQueue) push(p *rtp.Packet, attributes interceptor.Attributes, slowThreshold time.Duration) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.waitForKeyframe {
+		if !isKeyframeStart(q.mimeType, p.Payload) {
+			return q.drop(1, slowThreshold), nil
+		}
+		q.waitForKeyframe = false
+	}
+
+	isSlow := false
+	if q.length == len(q.packets) {
+		if q.policy == BindingDropUntilKeyframe && !isKeyframeStart(q.mimeType, p.Payload) {
+			dropped := q.length + 1
+			q.clear()
+			q.waitForKeyframe = true
+			return q.drop(dropped, slowThreshold), nil
+		}
+
+		q.release(q.head)
+		q.head = (q.head + 1) % len(q.packets)
+		q.length--
+		isSlow = q.drop(1, slowThreshold)
+	}
+
+	// Every packet gets its own buffer, as the interceptors may keep the packets
+	// written, for example to retransmit them
+	buf, err := p.Marshal()
+	if err != nil {
+		return isSlow, err
+	}
+
+	tail := (q.head + q.length) % len(q.packets)
+	q.packets[tail] = buf
+	q.attributes[tail] = attributes
+	q.length++
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return isSlow, nil
+}
+
+// pop removes the oldest packet from the queue. When the queue is drained
+// the binding is considered healthy again.
+func (q *bindingQueue) pop() ([]byte, interceptor.Attributes, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.length == 0 {
+		q.dropStart = time.Time{}
+		q.reported = false
+		return nil, nil, false
+	}
+
+	buf, attributes := q.packets[q.head], q.attributes[q.head]
+	q.packets[q.head], q.attributes[q.head] = nil, nil
+	q.head = (q.head + 1) % len(q.packets)
+	q.length--
+	return buf, attributes, true
+}
+
+func (q *bindingQueue) drop(count int, slowThreshold time.Duration) bool {
+	atomic.AddUint64(&q.dropped, uint64(count))
+
+	now := time.Now()
+	if q.dropStart.IsZero() {
+		q.dropStart = now
+	}
+	if !q.reported && now.Sub(q.dropStart) >= slowThreshold {
+		q.reported = true
+		return true
+	}
+	return false
+}
+
+func (q *bindingQueue) release(i int) {
+	q.packets[i] = nil
+	q.attributes[i] = nil
+}
+
+func (q *bindingQueue) clear() {
+	for i := range q.packets {
+		q.release(i)
+	}
+	q.head, q.length = 0, 0
+}
+
+func (q *bindingQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.length
+}
+
+func (q *bindingQueue) close() {
+	close(q.done)
+}
+
+// run writes queued packets to writeStream until the queue is closed, adding the
+// sendTimeExtensions as they are written. Nothing is written before ready is closed,
+// a nil ready channel means it can write right away.
+func (q *bindingQueue) run(writeStream TrackLocalWriter, extensions *sendTimeExtensions, ready <-chan struct{}) {
+	if ready != nil {
+		select {
+		case <-q.done:
+			q.mu.Lock()
+			q.clear()
+			q.mu.Unlock()
+			return
+		case <-ready:
+		}
+	}
+
+	for {
+		select {
+		case <-q.done:
+			q.mu.Lock()
+			q.clear()
+			q.mu.Unlock()
+			return
+		case <-q.notify:
+		}
+
+		for {
+			buf, attributes, ok := q.pop()
+			if !ok {
+				break
+			}
+
+			// The interceptors may keep the packet, so it isn't reused
+			packet := &rtp.Packet{}
+			if err := packet.Unmarshal(buf); err == nil && extensions.set(&packet.Header, time.Now()) == nil {
+				// Errors can't be returned to the caller of WriteRTP anymore, so a packet
+				// that fails to be written is lost like a packet dropped by the network
+				_, _ = writeRTPWithAttributes(writeStream, &packet.Header, packet.Payload, attributes)
+			}
+
+			select {
+			case <-q.done:
+				q.mu.Lock()
+				q.clear()
+				q.mu.Unlock()
+				return
+			default:
+			}
+		}
+	}
+}
@@ -3,13 +3,20 @@
 package webrtc
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/nack"
+	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v3/internal/senderreport"
+	"github.com/pion/webrtc/v3/pkg/media"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -248,3 +255,598 @@ func BenchmarkTrackLocalWrite(b *testing.B) {
 		assert.NoError(b, err)
 	}
 }
+
+// blockingTrackLocalWriter blocks every write until unblock is closed
+type blockingTrackLocalWriter struct {
+	unblock chan struct{}
+}
+
+func (b *blockingTrackLocalWriter) WriteRTP(*rtp.Header, []byte) (int, error) {
+	<-b.unblock
+	return 0, nil
+}
+
+func (b *blockingTrackLocalWriter) Write([]byte) (int, error) {
+	<-b.unblock
+	return 0, nil
+}
+
+// countingTrackLocalWriter sends every written packet to a channel
+type countingTrackLocalWriter struct {
+	packets chan rtp.Header
+}
+
+func (c *countingTrackLocalWriter) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	c.packets <- *header
+	return len(payload), nil
+}
+
+func (c *countingTrackLocalWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func newTestTrackLocalContext(id string, ssrc SSRC, writeStream TrackLocalWriter) TrackLocalContext {
+	return TrackLocalContext{
+		id: id,
+		params: RTPParameters{
+			Codecs: []RTPCodecParameters{{
+				RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000},
+				PayloadType:        96,
+			}},
+		},
+		ssrc:        ssrc,
+		writeStream: writeStream,
+	}
+}
+
+func Test_TrackLocalStaticRTP_BindingQueue(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	vp8Keyframe := []byte{0x10, 0x00}
+	vp8Interframe := []byte{0x10, 0x01}
+
+	t.Run("SlowBindingIsolated", func(t *testing.T) {
+		track, err := NewTrackLocalStaticRTP(
+			RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion",
+			WithBindingQueue(4, BindingDropOldest),
+			WithSlowBindingThreshold(0),
+		)
+		assert.NoError(t, err)
+
+		slowBindingFired := make(chan TrackLocalBindingStats, 1)
+		track.OnSlowBinding(func(s TrackLocalBindingStats) {
+			slowBindingFired <- s
+		})
+
+		slow := &blockingTrackLocalWriter{unblock: make(chan struct{})}
+		fast := &countingTrackLocalWriter{packets: make(chan rtp.Header, 20)}
+
+		slowCtx := newTestTrackLocalContext("slow", 1, slow)
+		fastCtx := newTestTrackLocalContext("fast", 2, fast)
+		_, err = track.Bind(slowCtx)
+		assert.NoError(t, err)
+		_, err = track.Bind(fastCtx)
+		assert.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{SequenceNumber: uint16(i)}, Payload: vp8Interframe}))
+			header := <-fast.packets
+			assert.Equal(t, uint16(i), header.SequenceNumber)
+			assert.Equal(t, uint32(2), header.SSRC)
+		}
+
+		stats := <-slowBindingFired
+		assert.Equal(t, "slow", stats.ID)
+		assert.Equal(t, SSRC(1), stats.SSRC)
+
+		for _, s := range track.BindingStats() {
+			if s.ID == "slow" {
+				// The first packet is stuck in the writer, 4 are queued and 5 have been dropped
+				assert.Equal(t, 4, s.QueueDepth)
+				assert.Equal(t, uint64(5), s.PacketsDropped)
+			} else {
+				assert.Equal(t, uint64(0), s.PacketsDropped)
+			}
+		}
+
+		assert.NoError(t, track.Unbind(slowCtx))
+		assert.NoError(t, track.Unbind(fastCtx))
+		close(slow.unblock)
+	})
+
+	t.Run("DropUntilKeyframe", func(t *testing.T) {
+		track, err := NewTrackLocalStaticRTP(
+			RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion",
+			WithBindingQueue(2, BindingDropUntilKeyframe),
+		)
+		assert.NoError(t, err)
+
+		slow := &blockingTrackLocalWriter{unblock: make(chan struct{})}
+		slowCtx := newTestTrackLocalContext("slow", 1, slow)
+		_, err = track.Bind(slowCtx)
+		assert.NoError(t, err)
+
+		queueDepth := func() int {
+			return track.BindingStats()[0].QueueDepth
+		}
+
+		// First packet is picked up by the writer and blocks
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{Payload: vp8Interframe}))
+		assert.Eventually(t, func() bool { return queueDepth() == 0 }, time.Second, time.Millisecond)
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, track.WriteRTP(&rtp.Packet{Payload: vp8Interframe}))
+		}
+		assert.Equal(t, 0, queueDepth())
+		assert.Equal(t, uint64(3), track.BindingStats()[0].PacketsDropped)
+
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{Payload: vp8Interframe}))
+		assert.Equal(t, 0, queueDepth())
+
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{Payload: vp8Keyframe}))
+		assert.Equal(t, 1, queueDepth())
+		assert.Equal(t, uint64(4), track.BindingStats()[0].PacketsDropped)
+
+		assert.NoError(t, track.Unbind(slowCtx))
+		close(slow.unblock)
+	})
+}
+
+// newNACKTrackLocalWriter returns a TrackLocalWriter with the NACK responder, which
+// sends every packet written to packets, and a function that NACKs a packet
+func newNACKTrackLocalWriter(t *testing.T, ssrc SSRC, packets chan rtp.Packet) (TrackLocalWriter, func(sequenceNumber uint16)) {
+	responder, err := nack.NewResponderInterceptor()
+	assert.NoError(t, err)
+
+	info := &interceptor.StreamInfo{SSRC: uint32(ssrc), RTCPFeedback: []interceptor.RTCPFeedback{{Type: "nack"}}}
+	writer := &interceptorToTrackLocalWriter{}
+	writer.interceptor.Store(responder.BindLocalStream(info, interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+		packet := rtp.Packet{Header: *header, Payload: append([]byte{}, payload...)}
+		packet.Header.Extensions = nil
+		for _, id := range header.GetExtensionIDs() {
+			assert.NoError(t, packet.Header.SetExtension(id, append([]byte{}, header.GetExtension(id)...)))
+		}
+		packets <- packet
+		return len(payload), nil
+	})))
+
+	return writer, func(sequenceNumber uint16) {
+		raw, err := (&rtcp.TransportLayerNack{
+			MediaSSRC: uint32(ssrc),
+			Nacks:     []rtcp.NackPair{{PacketID: sequenceNumber}},
+		}).Marshal()
+		assert.NoError(t, err)
+
+		reader := responder.BindRTCPReader(interceptor.RTCPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+			return copy(b, raw), a, nil
+		}))
+		_, _, err = reader.Read(make([]byte, receiveMTU), nil)
+		assert.NoError(t, err)
+	}
+}
+
+func Test_TrackLocalStaticRTP_BindingQueueNACK(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	track, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion",
+		WithBindingQueue(4, BindingDropOldest),
+	)
+	assert.NoError(t, err)
+
+	packets := make(chan rtp.Packet, 10)
+	writer, sendNACK := newNACKTrackLocalWriter(t, 1, packets)
+	ctx := newTestTrackLocalContext("binding", 1, writer)
+	_, err = track.Bind(ctx)
+	assert.NoError(t, err)
+
+	payload := func(i int) []byte {
+		return append([]byte{0x10, 0x01}, bytes.Repeat([]byte{byte(i)}, 100)...)
+	}
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{SequenceNumber: uint16(i)}, Payload: payload(i)}))
+		assert.Equal(t, payload(i), (<-packets).Payload)
+	}
+
+	// The packet retransmitted is the one sent, even though the queue sent others since
+	sendNACK(2)
+	retransmitted := <-packets
+	assert.Equal(t, uint16(2), retransmitted.SequenceNumber)
+	assert.Equal(t, payload(2), retransmitted.Payload)
+
+	assert.NoError(t, track.Unbind(ctx))
+}
+
+func Test_TrackLocalStaticRTP_HeaderExtensions(t *testing.T) {
+	const audioLevelURI = "urn:ietf:params:rtp-hdrext:ssrc-audio-level"
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	writer := &countingTrackLocalWriter{packets: make(chan rtp.Header, 1)}
+	ctx := newTestTrackLocalContext("binding", 1, writer)
+	ctx.mid = "5"
+	ctx.params.HeaderExtensions = []RTPHeaderExtensionParameter{
+		{URI: sdp.SDESMidURI, ID: 1},
+		{URI: sdp.ABSSendTimeURI, ID: 2},
+		{URI: sdp.TransportCCURI, ID: 3},
+		{URI: audioLevelURI, ID: 4},
+	}
+	transportSequence := uint16(0)
+	ctx.transportSequencer = func() uint16 {
+		transportSequence++
+		return transportSequence
+	}
+
+	_, err = track.Bind(ctx)
+	assert.NoError(t, err)
+
+	newSourcePacket := func() *rtp.Packet {
+		p := &rtp.Packet{Header: rtp.Header{Extension: true, ExtensionProfile: extensionProfileOneByte}}
+		assert.NoError(t, p.SetExtension(7, []byte{0xAA}))  // audio level in the source
+		assert.NoError(t, p.SetExtension(8, []byte("src"))) // mid in the source
+		assert.NoError(t, p.SetExtension(9, []byte{0x01}))  // not negotiated by the binding
+		return p
+	}
+
+	t.Run("Unknown source", func(t *testing.T) {
+		// The extensions of the source are forwarded unchanged
+		assert.NoError(t, track.WriteRTP(newSourcePacket()))
+
+		header := <-writer.packets
+		assert.Equal(t, []uint8{7, 8, 9, 1, 2, 3}, header.GetExtensionIDs())
+		assert.Equal(t, []byte{0xAA}, header.GetExtension(7))
+		assert.Equal(t, []byte("5"), header.GetExtension(1))
+		assert.Equal(t, []byte{0x00, 0x01}, header.GetExtension(3))
+	})
+
+	t.Run("Remapped", func(t *testing.T) {
+		track.SetSourceHeaderExtensions([]RTPHeaderExtensionParameter{
+			{URI: audioLevelURI, ID: 7},
+			{URI: sdp.SDESMidURI, ID: 8},
+			{URI: "urn:ietf:params:rtp-hdrext:toffset", ID: 9},
+		})
+		assert.NoError(t, track.WriteRTP(newSourcePacket()))
+
+		header := <-writer.packets
+		assert.Equal(t, []uint8{4, 1, 2, 3}, header.GetExtensionIDs())
+		assert.Equal(t, []byte{0xAA}, header.GetExtension(4))
+		assert.Equal(t, []byte("5"), header.GetExtension(1))
+		assert.Equal(t, []byte{0x00, 0x02}, header.GetExtension(3))
+	})
+
+	t.Run("No extensions negotiated", func(t *testing.T) {
+		assert.NoError(t, track.Unbind(ctx))
+
+		ctx.params.HeaderExtensions = nil
+		_, err = track.Bind(ctx)
+		assert.NoError(t, err)
+
+		assert.NoError(t, track.WriteRTP(newSourcePacket()))
+
+		header := <-writer.packets
+		assert.False(t, header.Extension)
+		assert.Empty(t, header.Extensions)
+	})
+}
+
+func Test_TrackLocalStaticRTP_SendTimeExtensions(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	newContext := func(writeStream TrackLocalWriter) TrackLocalContext {
+		ctx := newTestTrackLocalContext("binding", 1, writeStream)
+		ctx.params.HeaderExtensions = []RTPHeaderExtensionParameter{
+			{URI: sdp.ABSSendTimeURI, ID: 2},
+			{URI: sdp.TransportCCURI, ID: 3},
+		}
+		transportSequence := uint16(0)
+		ctx.transportSequencer = func() uint16 {
+			transportSequence++
+			return transportSequence
+		}
+		return ctx
+	}
+
+	transportSequence := func(header rtp.Header) uint16 {
+		ext := rtp.TransportCCExtension{}
+		assert.NoError(t, ext.Unmarshal(header.GetExtension(3)))
+		return ext.TransportSequence
+	}
+
+	t.Run("Retransmitted", func(t *testing.T) {
+		track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		assert.NoError(t, err)
+
+		packets := make(chan rtp.Packet, 10)
+		writer, sendNACK := newNACKTrackLocalWriter(t, 1, packets)
+		ctx := newContext(writer)
+		_, err = track.Bind(ctx)
+		assert.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{SequenceNumber: uint16(i)}, Payload: []byte{0x10, 0x01}}))
+			assert.Equal(t, uint16(i+1), transportSequence((<-packets).Header))
+		}
+
+		// The extensions of a packet written aren't overwritten by the following packets
+		sendNACK(2)
+		assert.Equal(t, uint16(3), transportSequence((<-packets).Header))
+
+		assert.NoError(t, track.Unbind(ctx))
+	})
+
+	t.Run("Binding queue", func(t *testing.T) {
+		track, err := NewTrackLocalStaticRTP(
+			RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion",
+			WithBindingQueue(2, BindingDropOldest),
+		)
+		assert.NoError(t, err)
+
+		writer := &countingTrackLocalWriter{packets: make(chan rtp.Header)}
+		ctx := newContext(writer)
+		_, err = track.Bind(ctx)
+		assert.NoError(t, err)
+
+		// The first packet is picked up by the writer and blocks, while only the last
+		// two of the following packets are kept
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{Payload: []byte{0x10, 0x01}}))
+		assert.Eventually(t, func() bool { return track.BindingStats()[0].QueueDepth == 0 }, time.Second, time.Millisecond)
+		for i := 1; i < 6; i++ {
+			assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{SequenceNumber: uint16(i)}, Payload: []byte{0x10, 0x01}}))
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		dequeued := time.Now()
+
+		// Dropped packets don't use transport-wide sequence numbers, and the send time is
+		// the time a packet leaves the queue
+		for _, expected := range []struct {
+			seq, transportSequence uint16
+		}{{0, 1}, {4, 2}, {5, 3}} {
+			header := <-writer.packets
+			assert.Equal(t, expected.seq, header.SequenceNumber)
+			assert.Equal(t, expected.transportSequence, transportSequence(header))
+
+			if expected.seq != 0 {
+				absSendTime := rtp.AbsSendTimeExtension{}
+				assert.NoError(t, absSendTime.Unmarshal(header.GetExtension(2)))
+				assert.False(t, absSendTime.Estimate(time.Now()).Before(dequeued.Add(-time.Millisecond)))
+			}
+		}
+
+		assert.NoError(t, track.Unbind(ctx))
+	})
+}
+
+func Test_TrackLocalStaticRTP_GOPCache(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	vp8Keyframe := []byte{0x10, 0x00}
+	vp8Interframe := []byte{0x10, 0x01}
+
+	track, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion",
+		WithGOPCache(4),
+		WithKeyframeRequestInterval(time.Hour),
+	)
+	assert.NoError(t, err)
+
+	keyframeRequests := make(chan struct{}, 10)
+	track.OnKeyframeRequest(func() {
+		keyframeRequests <- struct{}{}
+	})
+
+	write := func(seq uint16, ts uint32, payload []byte) {
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{SequenceNumber: seq, Timestamp: ts}, Payload: payload}))
+	}
+
+	// Nothing cached yet, binding requests a keyframe
+	first := &countingTrackLocalWriter{packets: make(chan rtp.Header, 10)}
+	firstCtx := newTestTrackLocalContext("first", 1, first)
+	_, err = track.Bind(firstCtx)
+	assert.NoError(t, err)
+	<-keyframeRequests
+
+	write(1, 1000, vp8Interframe)
+	write(2, 4000, vp8Keyframe)
+	write(3, 4000, vp8Interframe)
+	write(4, 7000, vp8Interframe)
+	write(5, 10000, vp8Interframe)
+
+	// Late joiner receives everything since the keyframe, squeezed before the last frame
+	ready := make(chan struct{})
+	late := &countingTrackLocalWriter{packets: make(chan rtp.Header, 10)}
+	lateCtx := newTestTrackLocalContext("late", 2, late)
+	lateCtx.writeReady = ready
+	_, err = track.Bind(lateCtx)
+	assert.NoError(t, err)
+
+	write(6, 13000, vp8Interframe)
+	close(ready)
+
+	for _, expected := range []struct {
+		seq uint16
+		ts  uint32
+	}{{2, 9998}, {3, 9998}, {4, 9999}, {5, 10000}, {6, 13000}} {
+		header := <-late.packets
+		assert.Equal(t, expected.seq, header.SequenceNumber)
+		assert.Equal(t, expected.ts, header.Timestamp)
+		assert.Equal(t, uint32(2), header.SSRC)
+	}
+
+	// Keyframe requests from subscribers are coalesced
+	track.HandleRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{}})
+	track.HandleRTCP([]rtcp.Packet{&rtcp.FullIntraRequest{}})
+	select {
+	case <-keyframeRequests:
+		assert.Fail(t, "Keyframe request should have been coalesced")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.NoError(t, track.Unbind(firstCtx))
+	assert.NoError(t, track.Unbind(lateCtx))
+}
+
+func Test_TrackLocalStaticRTP_GOPCacheH264(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	track, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeH264}, "video", "pion",
+		WithGOPCache(10),
+	)
+	assert.NoError(t, err)
+
+	write := func(seq uint16, ts uint32, payload []byte) {
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{SequenceNumber: seq, Timestamp: ts}, Payload: payload}))
+	}
+
+	bindLate := func(id string) []uint16 {
+		writer := &countingTrackLocalWriter{packets: make(chan rtp.Header, 10)}
+		ctx := newTestTrackLocalContext(id, 2, writer)
+		ctx.params.Codecs[0].RTPCodecCapability = RTPCodecCapability{MimeType: MimeTypeH264, ClockRate: 90000}
+		_, err := track.Bind(ctx)
+		assert.NoError(t, err)
+
+		var sequenceNumbers []uint16
+		assert.Eventually(t, func() bool {
+			for {
+				select {
+				case header := <-writer.packets:
+					sequenceNumbers = append(sequenceNumbers, header.SequenceNumber)
+				default:
+					return track.BindingStats()[0].QueueDepth == 0
+				}
+			}
+		}, time.Second, time.Millisecond)
+		assert.NoError(t, track.Unbind(ctx))
+		return sequenceNumbers
+	}
+
+	stapA := []byte{0x78, 0x00, 0x01, 0x67, 0x00, 0x01, 0x68}
+	write(1, 3000, stapA)                    // SPS and PPS
+	write(2, 3000, []byte{0x7c, 0x85, 0xAA}) // FU-A start of the first IDR slice
+	write(3, 3000, []byte{0x7c, 0x45, 0xBB}) // FU-A end of the first IDR slice
+	write(4, 3000, []byte{0x65, 0xCC})       // Second IDR slice
+	write(5, 6000, []byte{0x41, 0xDD})       // Non-IDR slice
+
+	// The parameter sets are kept with the slices of the IDR picture
+	assert.Equal(t, []uint16{1, 2, 3, 4, 5}, bindLate("late"))
+
+	// A keyframe with a new timestamp resets the cache
+	write(6, 9000, stapA)
+	write(7, 9000, []byte{0x65, 0xEE})
+	assert.Equal(t, []uint16{6, 7}, bindLate("later"))
+}
+
+func Test_TrackLocalStaticSample_Timestamps(t *testing.T) {
+	type writtenPacket struct {
+		timestamp   uint32
+		captureTime time.Time
+	}
+
+	newTrack := func(t *testing.T) (*TrackLocalStaticSample, chan writtenPacket) {
+		track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		assert.NoError(t, err)
+
+		written := make(chan writtenPacket, 10)
+		writer := &interceptorToTrackLocalWriter{}
+		writer.interceptor.Store(interceptor.RTPWriter(interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, a interceptor.Attributes) (int, error) {
+			captureTime, _ := senderreport.CaptureTime(a)
+			written <- writtenPacket{timestamp: header.Timestamp, captureTime: captureTime}
+			return len(payload), nil
+		})))
+
+		_, err = track.Bind(newTestTrackLocalContext("video", 1, writer))
+		assert.NoError(t, err)
+		return track, written
+	}
+
+	t.Run("Timestamp", func(t *testing.T) {
+		track, written := newTrack(t)
+
+		captureTime := time.Now()
+		assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second, Timestamp: captureTime}))
+		first := <-written
+		assert.Equal(t, captureTime, first.captureTime)
+
+		// The RTP timestamp follows the capture time, not the duration of the previous sample
+		assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second, Timestamp: captureTime.Add(100 * time.Millisecond)}))
+		second := <-written
+		assert.Equal(t, first.timestamp+9000, second.timestamp)
+
+		// Samples without a timestamp continue from the previous one
+		assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second}))
+		third := <-written
+		assert.Equal(t, second.timestamp+90000, third.timestamp)
+		assert.True(t, third.captureTime.IsZero())
+	})
+
+	t.Run("PacketTimestamp", func(t *testing.T) {
+		track, written := newTrack(t)
+
+		assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second, PacketTimestamp: 0xFFFFFF00}))
+		first := <-written
+		assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second, PacketTimestamp: 0x00000100}))
+		second := <-written
+		assert.Equal(t, first.timestamp+0x200, second.timestamp)
+		assert.True(t, second.captureTime.IsZero())
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		track, written := newTrack(t)
+
+		assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second}))
+		first := <-written
+		assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second}))
+		second := <-written
+		assert.Equal(t, first.timestamp+90000, second.timestamp)
+	})
+}
+
+func Test_TrackLocalStaticSample_AudioLevel(t *testing.T) {
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "audio", "pion")
+	assert.NoError(t, err)
+
+	newBinding := func(id string, headerExtensions []RTPHeaderExtensionParameter) chan rtp.Header {
+		written := make(chan rtp.Header, 10)
+		writer := &interceptorToTrackLocalWriter{}
+		writer.interceptor.Store(interceptor.RTPWriter(interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+			written <- *header
+			return len(payload), nil
+		})))
+
+		ctx := newTestTrackLocalContext(id, 1, writer)
+		ctx.params.HeaderExtensions = headerExtensions
+		_, err = track.Bind(ctx)
+		assert.NoError(t, err)
+		return written
+	}
+
+	negotiated := newBinding("negotiated", []RTPHeaderExtensionParameter{{URI: sdp.AudioLevelURI, ID: 3}})
+	notNegotiated := newBinding("not-negotiated", nil)
+
+	assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second, AudioLevel: &media.AudioLevel{Level: 40, Voice: true}}))
+	header := <-negotiated
+	assert.Equal(t, []byte{0x80 | 40}, header.GetExtension(3))
+	assert.False(t, (<-notNegotiated).Extension)
+
+	// Samples without a level don't carry the extension
+	assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second}))
+	header = <-negotiated
+	assert.Nil(t, header.GetExtension(3))
+	<-notNegotiated
+}
@@ -3,13 +3,36 @@
 package webrtc
 
 import (
+	"encoding/binary"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/pion/interceptor"
 	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3/pkg/flexfec"
+	"github.com/pion/webrtc/v3/pkg/media"
 )
 
+const (
+	rtpHeaderSize            = 12
+	rtpHeaderTimestampOffset = 4
+	rtpHeaderCSRCCountMask   = 0x0F
+	rtpHeaderExtensionMask   = 0x10
+	rtpPayloadTypeMask       = 0x7F
+	rtpAudioLevelMask        = 0x7F
+)
+
+// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and the Unix epoch (1970)
+const ntpEpochOffset = 2208988800
+
+// senderReportMapping is the NTP to RTP timestamp mapping of a RTCP Sender Report
+type senderReportMapping struct {
+	ntpTime uint64
+	rtpTime uint32
+}
+
 // TrackRemote represents a single inbound source of media
 type TrackRemote struct {
 	mu sync.RWMutex
@@ -27,6 +50,36 @@ type TrackRemote struct {
 	receiver         *RTPReceiver
 	peeked           []byte
 	peekedAttributes interceptor.Attributes
+
+	senderReport *senderReportMapping
+
+	telephoneEvents *telephoneEventReceiver
+	onDTMFHandler   func(DTMFEvent)
+
+	red *redReceiver
+
+	// fec is only set if FlexFEC was negotiated, recovered packets are
+	// kept until they are returned by Read
+	fec          *flexfec.Decoder
+	fecRecovered [][]byte
+
+	// latest packet received, the audio level is only valid if hasAudioLevel is set
+	lastReceived     time.Time
+	lastRTPTimestamp uint32
+	audioLevel       media.AudioLevel
+	hasAudioLevel    bool
+
+	contributingSources map[SSRC]*contributingSource
+}
+
+// contributingSource is the latest packet a CSRC contributed to, the audio level
+// is only valid if hasAudioLevel is set
+type contributingSource struct {
+	lastReceived         time.Time
+	lastRTPTimestamp     uint32
+	audioLevel           uint8
+	hasAudioLevel        bool
+	packetsContributedTo uint32
 }
 
 func newTrackRemote(kind RTPCodecType, ssrc SSRC, rid string, receiver *RTPReceiver) *TrackRemote {
@@ -97,47 +150,504 @@ func (t *TrackRemote) Codec() RTPCodecParameters {
 	return t.codec
 }
 
+// HeaderExtensions returns the negotiated RTPHeaderExtensionParameters of this track.
+// Pass them to TrackLocalStaticRTP.SetSourceHeaderExtensions when forwarding packets.
+func (t *TrackRemote) HeaderExtensions() []RTPHeaderExtensionParameter {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.params.HeaderExtensions
+}
+
+// SenderTime converts the RTP timestamp of a packet of this track into the wall clock time
+// of the sender. It uses the NTP to RTP timestamp mapping of the latest RTCP Sender Report,
+// which is updated as Sender Reports are received, even if RTCP isn't read.
+// Tracks of the same StreamID can be synchronized by comparing their sender times.
+// It returns false if no Sender Report has been received yet.
+func (t *TrackRemote) SenderTime(rtpTimestamp uint32) (time.Time, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.senderReport == nil || t.codec.ClockRate == 0 {
+		return time.Time{}, false
+	}
+
+	// The difference is signed so timestamps before the Sender Report and wrap arounds are handled
+	diff := int64(int32(rtpTimestamp - t.senderReport.rtpTime))
+	offset := time.Duration(diff * int64(time.Second) / int64(t.codec.ClockRate))
+
+	return ntpToTime(t.senderReport.ntpTime).Add(offset), true
+}
+
+// handleSenderReport keeps the mapping of the latest Sender Report for this track
+func (t *TrackRemote) handleSenderReport(ntpTime uint64, rtpTime uint32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.senderReport = &senderReportMapping{ntpTime: ntpTime, rtpTime: rtpTime}
+}
+
+// ntpToTime converts a 64 bit NTP timestamp into a time.Time
+func ntpToTime(ntp uint64) time.Time {
+	seconds := int64(ntp>>32) - ntpEpochOffset
+	nanoseconds := int64((ntp & 0xFFFFFFFF) * uint64(time.Second) >> 32)
+	return time.Unix(seconds, nanoseconds)
+}
+
 // Read reads data from the track.
 func (t *TrackRemote) Read(b []byte) (n int, attributes interceptor.Attributes, err error) {
-	t.mu.RLock()
-	r := t.receiver
-	peeked := t.peeked != nil
-	t.mu.RUnlock()
+	n, attributes, _, err = t.read(b, true)
+	return n, attributes, err
+}
 
-	if peeked {
+// read reads a packet like Read. Unless block is set, it returns false instead of
+// blocking once no packet can be read immediately.
+func (t *TrackRemote) read(b []byte, block bool) (int, interceptor.Attributes, bool, error) {
+	for {
 		t.mu.Lock()
-		data := t.peeked
-		attributes = t.peekedAttributes
+		r, fec, ssrc := t.receiver, t.fec, t.ssrc
 
-		t.peeked = nil
-		t.peekedAttributes = nil
+		// Packets decoded from a RED packet were handled with it, while the packet
+		// peeked and the packets recovered with FlexFEC are handled like the packets
+		// received
+		n, attributes, peeked := t.popPeeked(b)
+		var fromRED, fromFEC bool
+		if !peeked {
+			n, attributes, fromRED = t.popRED(b)
+		}
+		if !peeked && !fromRED {
+			n, attributes, fromFEC = t.popFEC(b)
+		}
 		t.mu.Unlock()
-		// someone else may have stolen our packet when we
-		// released the lock.  Deal with it.
-		if data != nil {
-			n = copy(b, data)
-			return
+
+		if !fromRED && !fromFEC {
+			if !peeked {
+				if !block && !r.transport.rtpBuffered(ssrc) {
+					return 0, nil, false, nil
+				}
+
+				var err error
+				if n, attributes, err = r.readRTP(b, t); err != nil {
+					return n, attributes, false, err
+				}
+			}
+
+			if fec != nil && !t.handleFECMedia(fec, b[:n]) {
+				continue
+			}
 		}
+
+		if t.handlePacket(b[:n], attributes, !fromRED) {
+			return n, attributes, true, nil
+		}
+	}
+}
+
+// popPeeked copies the packet peeked into b.
+// Caller must hold the lock.
+func (t *TrackRemote) popPeeked(b []byte) (int, interceptor.Attributes, bool) {
+	if t.peeked == nil {
+		return 0, nil, false
 	}
 
-	return r.readRTP(b, t)
+	n, attributes := copy(b, t.peeked), t.peekedAttributes
+	t.peeked = nil
+	t.peekedAttributes = nil
+	return n, attributes, true
 }
 
-// ReadRTP is a convenience method that wraps Read and unmarshals for you.
-func (t *TrackRemote) ReadRTP() (*rtp.Packet, interceptor.Attributes, error) {
-	b := make([]byte, receiveMTU)
-	i, attributes, err := t.Read(b)
+// handlePacket keeps the sources of a packet read, and returns false if it must not be
+// returned by Read: RED packets are decoded into the packets they carry, and DTMF tones
+// are passed to the OnDTMF handler. Only the packets received or recovered with FlexFEC
+// are checked for sources and RED.
+func (t *TrackRemote) handlePacket(buf []byte, attributes interceptor.Attributes, received bool) bool {
+	t.mu.Lock()
+	if received {
+		t.handleSources(buf)
+
+		if t.red != nil && len(buf) >= 2 && t.red.isRED(PayloadType(buf[1]&rtpPayloadTypeMask)) {
+			t.red.handle(buf, attributes)
+			t.mu.Unlock()
+			return false
+		}
+	}
+
+	if t.telephoneEvents == nil || len(buf) < 2 || !t.telephoneEvents.isTelephoneEvent(PayloadType(buf[1]&rtpPayloadTypeMask)) {
+		t.mu.Unlock()
+		return true
+	}
+
+	var events []DTMFEvent
+	header := rtp.Header{}
+	if err := header.Unmarshal(buf); err == nil {
+		t.telephoneEvents.handle(&header, buf[header.PayloadOffset:], func(e DTMFEvent) {
+			events = append(events, e)
+		})
+	}
+	handler := t.onDTMFHandler
+	t.mu.Unlock()
+
+	// telephone-event packets are never returned by Read, so they stay out of the audio
+	if handler != nil {
+		for _, e := range events {
+			handler(e)
+		}
+	}
+	return false
+}
+
+// popRED copies the next packet decoded from a RED packet into b.
+// Caller must hold the lock.
+func (t *TrackRemote) popRED(b []byte) (int, interceptor.Attributes, bool) {
+	if t.red == nil {
+		return 0, nil, false
+	}
+	return t.red.pop(b)
+}
+
+// handleFECMedia passes a media packet to the FlexFEC decoder, so it can recover the packets
+// lost before it. It returns false if the packet was recovered already, so it must be dropped.
+func (t *TrackRemote) handleFECMedia(fec *flexfec.Decoder, buf []byte) bool {
+	recovered, isNew := fec.PushMedia(buf)
+	t.pushFEC(recovered)
+	return isNew
+}
+
+// handleFEC passes a FlexFEC packet of this track to the decoder. The packets recovered
+// with it are returned by the following calls to Read, possibly after later packets.
+func (t *TrackRemote) handleFEC(buf []byte) {
+	t.mu.RLock()
+	fec := t.fec
+	t.mu.RUnlock()
+
+	if fec == nil {
+		return
+	}
+
+	recovered, err := fec.PushFEC(buf)
 	if err != nil {
-		return nil, nil, err
+		return
+	}
+	t.pushFEC(recovered)
+}
+
+func (t *TrackRemote) pushFEC(recovered [][]byte) {
+	if len(recovered) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fecRecovered = append(t.fecRecovered, recovered...)
+}
+
+// popFEC copies the oldest packet recovered with FlexFEC into b.
+// Caller must hold the lock.
+func (t *TrackRemote) popFEC(b []byte) (int, interceptor.Attributes, bool) {
+	if len(t.fecRecovered) == 0 {
+		return 0, nil, false
+	}
+
+	n := copy(b, t.fecRecovered[0])
+	t.fecRecovered = t.fecRecovered[1:]
+	return n, interceptor.Attributes{}, true
+}
+
+// handleSources keeps the RTP timestamp and audio level of the latest packet, and
+// of the latest packet every CSRC contributed to. Caller must hold the lock.
+func (t *TrackRemote) handleSources(buf []byte) {
+	if len(buf) < rtpHeaderSize {
+		return
+	}
+	csrcCount := int(buf[0] & rtpHeaderCSRCCountMask)
+	if len(buf) < rtpHeaderSize+csrcCount*4 {
+		return
+	}
+
+	now := time.Now()
+	rtpTimestamp := binary.BigEndian.Uint32(buf[rtpHeaderTimestampOffset:])
+
+	t.lastReceived = now
+	t.lastRTPTimestamp = rtpTimestamp
+	t.hasAudioLevel = false
+
+	if id, ok := t.headerExtensionID(sdp.AudioLevelURI); ok {
+		ext := rtp.AudioLevelExtension{}
+		if payload, ok := rtpHeaderExtension(buf, id); ok && ext.Unmarshal(payload) == nil {
+			t.audioLevel = media.AudioLevel{Level: ext.Level, Voice: ext.Voice}
+			t.hasAudioLevel = true
+		}
+	}
+
+	if csrcCount == 0 {
+		return
+	}
+
+	// The csrc-audio-level extension has one level per CSRC, in the order of the CSRC list
+	var levels []byte
+	if id, ok := t.headerExtensionID(CSRCAudioLevelURI); ok {
+		levels, _ = rtpHeaderExtension(buf, id)
+	}
+
+	for i := 0; i < csrcCount; i++ {
+		csrc := SSRC(binary.BigEndian.Uint32(buf[rtpHeaderSize+i*4:]))
+		source, ok := t.contributingSources[csrc]
+		if !ok {
+			t.pruneContributingSources(now)
+			source = &contributingSource{}
+			t.contributingSources[csrc] = source
+		}
+
+		source.lastReceived = now
+		source.lastRTPTimestamp = rtpTimestamp
+		source.hasAudioLevel = i < len(levels)
+		if source.hasAudioLevel {
+			source.audioLevel = levels[i] & rtpAudioLevelMask
+		}
+		source.packetsContributedTo++
+	}
+}
+
+// rtpHeaderExtension returns the payload of a one-byte or two-byte header extension of a
+// marshaled RTP packet. It doesn't allocate, unlike unmarshaling the header.
+func rtpHeaderExtension(buf []byte, id uint8) ([]byte, bool) {
+	if len(buf) < rtpHeaderSize || buf[0]&rtpHeaderExtensionMask == 0 {
+		return nil, false
+	}
+
+	offset := rtpHeaderSize + int(buf[0]&rtpHeaderCSRCCountMask)*4
+	if len(buf) < offset+4 {
+		return nil, false
+	}
+	profile := binary.BigEndian.Uint16(buf[offset:])
+	end := offset + 4 + int(binary.BigEndian.Uint16(buf[offset+2:]))*4
+	if len(buf) < end {
+		return nil, false
+	}
+
+	oneByte := profile == extensionProfileOneByte
+	if !oneByte && profile&0xFFF0 != extensionProfileTwoByte {
+		return nil, false
+	}
+
+	for offset += 4; offset < end; {
+		// Padding between the elements
+		if buf[offset] == 0 {
+			offset++
+			continue
+		}
+
+		var extID uint8
+		var length int
+		if oneByte {
+			extID = buf[offset] >> 4
+			length = int(buf[offset]&0x0F) + 1
+			offset++
+			// The ID 15 stops the processing of the extensions
+			if extID == 15 {
+				return nil, false
+			}
+		} else {
+			if offset+2 > end {
+				return nil, false
+			}
+			extID = buf[offset]
+			length = int(buf[offset+1])
+			offset += 2
+		}
+
+		if offset+length > end {
+			return nil, false
+		}
+		if extID == id {
+			return buf[offset : offset+length], true
+		}
+		offset += length
+	}
+	return nil, false
+}
+
+// pruneContributingSources removes the contributing sources that didn't contribute to
+// a packet within the timeout, so the map doesn't grow forever. Caller must hold the lock.
+func (t *TrackRemote) pruneContributingSources(now time.Time) {
+	if t.contributingSources == nil {
+		t.contributingSources = map[SSRC]*contributingSource{}
+	}
+
+	for csrc, source := range t.contributingSources {
+		if now.Sub(source.lastReceived) > synchronizationSourceTimeout {
+			delete(t.contributingSources, csrc)
+		}
+	}
+}
+
+// headerExtensionID returns the negotiated ID of a header extension.
+// Caller must hold the lock.
+func (t *TrackRemote) headerExtensionID(uri string) (uint8, bool) {
+	for _, e := range t.params.HeaderExtensions {
+		if e.URI == uri {
+			return uint8(e.ID), true
+		}
+	}
+	return 0, false
+}
+
+// SynchronizationSource returns information about the latest packet read from this track.
+// It returns false if no packet has been read yet.
+func (t *TrackRemote) SynchronizationSource() (RTPSynchronizationSource, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.lastReceived.IsZero() {
+		return RTPSynchronizationSource{}, false
+	}
+
+	source := RTPSynchronizationSource{
+		Timestamp:    t.lastReceived,
+		Source:       t.ssrc,
+		RTPTimestamp: t.lastRTPTimestamp,
+	}
+	if t.hasAudioLevel {
+		audioLevel := t.audioLevel
+		source.AudioLevel = &audioLevel
+	}
+	return source, true
+}
+
+// AudioLevel returns the audio level of the latest packet read from this track, taken from
+// the ssrc-audio-level header extension. It returns false if the latest packet didn't carry
+// it, for example because the extension wasn't negotiated.
+func (t *TrackRemote) AudioLevel() (media.AudioLevel, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.audioLevel, t.hasAudioLevel
+}
+
+// ContributingSources returns information about the latest packet every CSRC contributed
+// to, for the CSRCs in packets read from this track within the last 10 seconds.
+func (t *TrackRemote) ContributingSources() []RTPContributingSource {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pruneContributingSources(time.Now())
+	sources := []RTPContributingSource{}
+	for csrc, s := range t.contributingSources {
+		source := RTPContributingSource{
+			Timestamp:    s.lastReceived,
+			Source:       csrc,
+			RTPTimestamp: s.lastRTPTimestamp,
+		}
+		if s.hasAudioLevel {
+			source.AudioLevel = &media.AudioLevel{Level: s.audioLevel}
+		}
+		sources = append(sources, source)
 	}
+	return sources
+}
+
+// collectStats adds a RTPContributingSourceStats for every contributing source of this track
+// that contributed to a packet within the last 10 seconds
+func (t *TrackRemote) collectStats(collector *statsReportCollector) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pruneContributingSources(time.Now())
+	for csrc, s := range t.contributingSources {
+		collector.Collecting()
 
+		stats := RTPContributingSourceStats{
+			Timestamp:            statsTimestampNow(),
+			Type:                 StatsTypeCSRC,
+			ID:                   fmt.Sprintf("RTPContributingSource-%d-%d", t.ssrc, csrc),
+			ContributorSSRC:      csrc,
+			InboundRTPStreamID:   fmt.Sprintf("InboundRTPStream-%d", t.ssrc),
+			PacketsContributedTo: s.packetsContributedTo,
+		}
+		if s.hasAudioLevel {
+			stats.AudioLevel = media.AudioLevel{Level: s.audioLevel}.Linear()
+		}
+
+		collector.Collect(stats.ID, stats)
+	}
+}
+
+// OnDTMF sets an event handler which is invoked for every DTMF tone received as
+// a RFC 4733 telephone-event. It is called from the goroutine reading the track, so
+// tones are delivered in order and only while the track is read.
+//
+// telephone-event packets are never returned by Read, so they stay out of the audio.
+func (t *TrackRemote) OnDTMF(f func(DTMFEvent)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onDTMFHandler = f
+}
+
+// ReadRTP is a convenience method that wraps Read and unmarshals for you.
+// It allocates a new buffer and packet on every call, use ReadRTPInto to avoid this.
+func (t *TrackRemote) ReadRTP() (*rtp.Packet, interceptor.Attributes, error) {
 	r := &rtp.Packet{}
-	if err := r.Unmarshal(b[:i]); err != nil {
+	attributes, err := t.ReadRTPInto(r, make([]byte, receiveMTU))
+	if err != nil {
 		return nil, nil, err
 	}
 	return r, attributes, nil
 }
 
+// ReadRTPInto reads a single packet into buf and unmarshals it into pkt.
+// buf should be at least receiveMTU (1460) bytes long.
+//
+// No memory is allocated if pkt and buf are reused between calls. pkt does not
+// own its data: Payload, Raw and the header extensions all point into buf and are
+// only valid until buf is written to again. Callers that need to keep a packet
+// must copy it before the next call.
+func (t *TrackRemote) ReadRTPInto(pkt *rtp.Packet, buf []byte) (interceptor.Attributes, error) {
+	n, attributes, err := t.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pkt.Unmarshal(buf[:n]); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// ReadRTPBatch reads multiple packets at once into pkts, packing the raw data of
+// each packet back to back into buf. The attributes of every packet are stored in
+// the same element of attributes, which must be at least as long as pkts unless it
+// is nil. It returns the number of packets read.
+//
+// It blocks until a packet is read, then reads the packets that can be read
+// without blocking, until every element of pkts is filled or less than receiveMTU
+// bytes of buf remain. If an error occurs, the packets read so far are returned
+// alongside it.
+//
+// The same ownership rules as ReadRTPInto apply: every packet in pkts points into
+// buf and is only valid until buf is written to again.
+func (t *TrackRemote) ReadRTPBatch(pkts []rtp.Packet, attributes []interceptor.Attributes, buf []byte) (int, error) {
+	offset := 0
+	for i := range pkts {
+		if len(buf)-offset < receiveMTU {
+			return i, nil
+		}
+
+		n, a, ok, err := t.read(buf[offset:], i == 0)
+		if err != nil || !ok {
+			return i, err
+		}
+
+		if err := pkts[i].Unmarshal(buf[offset : offset+n]); err != nil {
+			return i, err
+		}
+		if attributes != nil {
+			attributes[i] = a
+		}
+		offset += n
+	}
+
+	return len(pkts), nil
+}
+
 // determinePayloadType blocks and reads a single packet to determine the PayloadType for this Track
 // this is useful because we can't announce it to the user until we know the payloadType
 func (t *TrackRemote) determinePayloadType() error {
@@ -152,15 +662,28 @@ func (t *TrackRemote) determinePayloadType() error {
 	}
 
 	t.mu.Lock()
-	t.payloadType = PayloadType(r.PayloadType)
 	defer t.mu.Unlock()
 
+	// The packet peeked isn't decoded yet, RED packets have the payload type of
+	// the encoding they carry
+	t.payloadType = PayloadType(r.PayloadType)
+	if t.red != nil && t.red.isRED(t.payloadType) {
+		if _, primary, err := unmarshalRED(r.Payload); err == nil {
+			t.payloadType = PayloadType(primary.payloadType)
+		}
+	}
+
 	return nil
 }
 
-// peek is like Read, but it doesn't discard the packet read
+// peek reads a packet without discarding it. The packet isn't handled until it is
+// read, as the parameters of the track may not be known yet.
 func (t *TrackRemote) peek(b []byte) (n int, a interceptor.Attributes, err error) {
-	n, a, err = t.Read(b)
+	t.mu.RLock()
+	r := t.receiver
+	t.mu.RUnlock()
+
+	n, a, err = r.readRTP(b, t)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,495 @@
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/transport/packetio"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTrackRemoteWithReader creates a TrackRemote that reads from the given
+// RTPReader without any transport underneath
+func newTrackRemoteWithReader(reader interceptor.RTPReader) *TrackRemote {
+	r := &RTPReceiver{
+		kind:     RTPCodecTypeVideo,
+		closed:   make(chan interface{}),
+		received: make(chan interface{}),
+	}
+	close(r.received)
+
+	track := newTrackRemote(RTPCodecTypeVideo, 5000, "", r)
+	r.tracks = []trackStreams{{track: track, rtpInterceptor: reader}}
+	return track
+}
+
+func newStaticRTPReader(tb testing.TB) interceptor.RTPReader {
+	return newStaticRTPReaderWithPacket(tb, &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			SequenceNumber: 5,
+			Timestamp:      10,
+			SSRC:           5000,
+		},
+		Payload: make([]byte, 1200),
+	})
+}
+
+func newStaticRTPReaderWithPacket(tb testing.TB, packet *rtp.Packet) interceptor.RTPReader {
+	raw, err := packet.Marshal()
+	assert.NoError(tb, err)
+
+	return interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		return copy(b, raw), a, nil
+	})
+}
+
+// newAudioLevelTrackRemote creates a TrackRemote that negotiated the ssrc-audio-level and
+// csrc-audio-level header extensions, reading packets that carry both
+func newAudioLevelTrackRemote(tb testing.TB) *TrackRemote {
+	packet := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			SequenceNumber: 5,
+			Timestamp:      10,
+			SSRC:           5000,
+			CSRC:           []uint32{6000, 7000},
+		},
+		Payload: make([]byte, 160),
+	}
+	assert.NoError(tb, packet.Header.SetExtension(1, []byte{0x80 | 30}))
+	assert.NoError(tb, packet.Header.SetExtension(2, []byte{40, 50}))
+
+	track := newTrackRemoteWithReader(newStaticRTPReaderWithPacket(tb, packet))
+	track.params.HeaderExtensions = []RTPHeaderExtensionParameter{
+		{URI: sdp.AudioLevelURI, ID: 1},
+		{URI: CSRCAudioLevelURI, ID: 2},
+	}
+	return track
+}
+
+func TestTrackRemote_ReadRTPInto(t *testing.T) {
+	track := newTrackRemoteWithReader(newStaticRTPReader(t))
+
+	pkt := &rtp.Packet{}
+	buf := make([]byte, receiveMTU)
+	_, err := track.ReadRTPInto(pkt, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(5), pkt.SequenceNumber)
+	assert.Equal(t, uint32(5000), pkt.SSRC)
+	assert.Equal(t, 1200, len(pkt.Payload))
+	assert.Equal(t, &buf[0], &pkt.Raw[0], "Packet should point into the supplied buffer")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, err = track.ReadRTPInto(pkt, buf)
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, allocs)
+
+	t.Run("Audio level", func(t *testing.T) {
+		track := newAudioLevelTrackRemote(t)
+
+		_, err := track.ReadRTPInto(pkt, buf)
+		assert.NoError(t, err)
+
+		audioLevel, ok := track.AudioLevel()
+		assert.True(t, ok)
+		assert.Equal(t, media.AudioLevel{Level: 30, Voice: true}, audioLevel)
+		assert.Len(t, track.ContributingSources(), 2)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			_, err = track.ReadRTPInto(pkt, buf)
+		})
+		assert.NoError(t, err)
+		assert.Zero(t, allocs)
+	})
+}
+
+func TestRTPHeaderExtension(t *testing.T) {
+	for _, profile := range []uint16{extensionProfileOneByte, extensionProfileTwoByte} {
+		packet := &rtp.Packet{Header: rtp.Header{Version: 2, CSRC: []uint32{1}}}
+		if profile == extensionProfileTwoByte {
+			// The two-byte profile is only used when an extension doesn't fit the one-byte one
+			assert.NoError(t, packet.Header.SetExtension(1, make([]byte, 17)))
+		} else {
+			assert.NoError(t, packet.Header.SetExtension(1, []byte{1}))
+		}
+		assert.NoError(t, packet.Header.SetExtension(3, []byte{3, 3}))
+		raw, err := packet.Marshal()
+		assert.NoError(t, err)
+
+		payload, ok := rtpHeaderExtension(raw, 3)
+		assert.True(t, ok)
+		assert.Equal(t, []byte{3, 3}, payload)
+
+		_, ok = rtpHeaderExtension(raw, 2)
+		assert.False(t, ok)
+
+		// A truncated extension is ignored
+		_, ok = rtpHeaderExtension(raw[:len(raw)-4], 3)
+		assert.False(t, ok)
+	}
+
+	raw, err := (&rtp.Packet{Header: rtp.Header{Version: 2}}).Marshal()
+	assert.NoError(t, err)
+	_, ok := rtpHeaderExtension(raw, 1)
+	assert.False(t, ok, "Packet without header extensions")
+}
+
+// newBufferedTrackRemote creates a TrackRemote that reads the packets written to
+// the buffer of its SRTP read stream
+func newBufferedTrackRemote(tb testing.TB, reader func(interceptor.RTPReader) interceptor.RTPReader) (*TrackRemote, func(count int)) {
+	transport := &DTLSTransport{api: NewAPI()}
+	buffer := transport.bufferFactory(packetio.RTPBufferPacket, 5000)
+
+	track := newTrackRemoteWithReader(reader(interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, err := buffer.Read(b)
+		return n, a, err
+	})))
+	track.receiver.transport = transport
+
+	raw, err := (&rtp.Packet{
+		Header:  rtp.Header{Version: 2, SequenceNumber: 5, Timestamp: 10, SSRC: 5000},
+		Payload: make([]byte, 1200),
+	}).Marshal()
+	assert.NoError(tb, err)
+
+	return track, func(count int) {
+		for i := 0; i < count; i++ {
+			_, err := buffer.Write(raw)
+			assert.NoError(tb, err)
+		}
+	}
+}
+
+func TestTrackRemote_ReadRTPBatch(t *testing.T) {
+	read := 0
+	track, write := newBufferedTrackRemote(t, func(reader interceptor.RTPReader) interceptor.RTPReader {
+		return interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+			read++
+			if read%2 == 0 {
+				a = interceptor.Attributes{"read": read}
+			}
+			return reader.Read(b, a)
+		})
+	})
+
+	t.Run("Fill packets", func(t *testing.T) {
+		write(4)
+		pkts := make([]rtp.Packet, 4)
+		attributes := make([]interceptor.Attributes, 4)
+		n, err := track.ReadRTPBatch(pkts, attributes, make([]byte, receiveMTU*4))
+		assert.NoError(t, err)
+		assert.Equal(t, 4, n)
+		for i := range pkts {
+			assert.Equal(t, 1200, len(pkts[i].Payload))
+		}
+		assert.Equal(t, []interceptor.Attributes{nil, {"read": 2}, nil, {"read": 4}}, attributes)
+	})
+
+	t.Run("Buffer exhausted", func(t *testing.T) {
+		write(4)
+		pkts := make([]rtp.Packet, 4)
+		n, err := track.ReadRTPBatch(pkts, nil, make([]byte, receiveMTU*2))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		n, err = track.ReadRTPBatch(pkts, nil, make([]byte, receiveMTU*4))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+	})
+
+	t.Run("Packets buffered", func(t *testing.T) {
+		write(3)
+		pkts := make([]rtp.Packet, 4)
+		n, err := track.ReadRTPBatch(pkts, nil, make([]byte, receiveMTU*4))
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n, "Only the packets buffered are read after the first one")
+
+		read := make(chan int)
+		go func() {
+			n, err := track.ReadRTPBatch(pkts, nil, make([]byte, receiveMTU*4))
+			assert.NoError(t, err)
+			read <- n
+		}()
+
+		select {
+		case <-read:
+			t.Fatal("The first packet of a batch should be waited for")
+		case <-time.After(10 * time.Millisecond):
+		}
+		write(1)
+		assert.Equal(t, 1, <-read)
+	})
+
+	t.Run("No allocations", func(t *testing.T) {
+		track, write := newBufferedTrackRemote(t, func(reader interceptor.RTPReader) interceptor.RTPReader {
+			return reader
+		})
+		pkts := make([]rtp.Packet, 4)
+		attributes := make([]interceptor.Attributes, 4)
+		buf := make([]byte, receiveMTU*4)
+
+		var n int
+		var err error
+		allocs := testing.AllocsPerRun(100, func() {
+			write(4)
+			n, err = track.ReadRTPBatch(pkts, attributes, buf)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, n)
+		assert.Zero(t, allocs)
+	})
+}
+
+func TestTrackRemote_ReadPeeked(t *testing.T) {
+	track := newAudioLevelTrackRemote(t)
+	params := track.params
+	track.params = RTPParameters{}
+
+	// The parameters aren't known yet when the first packet is peeked
+	buf := make([]byte, receiveMTU)
+	_, _, err := track.peek(buf)
+	assert.NoError(t, err)
+	_, ok := track.AudioLevel()
+	assert.False(t, ok)
+
+	track.params = params
+	_, _, err = track.Read(buf)
+	assert.NoError(t, err)
+
+	audioLevel, ok := track.AudioLevel()
+	assert.True(t, ok)
+	assert.Equal(t, media.AudioLevel{Level: 30, Voice: true}, audioLevel)
+	assert.Len(t, track.ContributingSources(), 2)
+}
+
+func BenchmarkTrackRemoteReadRTP(b *testing.B) {
+	track := newTrackRemoteWithReader(newStaticRTPReader(b))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := track.ReadRTP(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTrackRemoteReadRTPInto(b *testing.B) {
+	track := newTrackRemoteWithReader(newStaticRTPReader(b))
+	pkt := &rtp.Packet{}
+	buf := make([]byte, receiveMTU)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := track.ReadRTPInto(pkt, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTrackRemoteReadRTPIntoAudioLevel(b *testing.B) {
+	track := newAudioLevelTrackRemote(b)
+	pkt := &rtp.Packet{}
+	buf := make([]byte, receiveMTU)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := track.ReadRTPInto(pkt, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTrackRemoteReadRTPBatch(b *testing.B) {
+	track, write := newBufferedTrackRemote(b, func(reader interceptor.RTPReader) interceptor.RTPReader {
+		return reader
+	})
+	pkts := make([]rtp.Packet, 16)
+	attributes := make([]interceptor.Attributes, len(pkts))
+	buf := make([]byte, receiveMTU*len(pkts))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		write(len(pkts))
+		if _, err := track.ReadRTPBatch(pkts, attributes, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTrackRemote_SenderTime(t *testing.T) {
+	track := newTrackRemote(RTPCodecTypeAudio, 5000, "", nil)
+	track.codec = RTPCodecParameters{RTPCodecCapability: RTPCodecCapability{MimeType: "audio/opus", ClockRate: 48000}}
+
+	transport := &DTLSTransport{api: NewAPI()}
+	transport.addSenderReportTrack(track)
+	buffer := transport.bufferFactory(packetio.RTCPBufferPacket, 5000)
+
+	_, ok := track.SenderTime(0)
+	assert.False(t, ok, "No Sender Report has been received")
+
+	// 2021-01-01 00:00:00.5 UTC
+	ntpTime := uint64(3818448000)<<32 | 1<<31
+	raw, err := rtcp.Marshal([]rtcp.Packet{
+		&rtcp.ReceiverReport{SSRC: 5000},
+		&rtcp.SenderReport{SSRC: 6000, NTPTime: 0, RTPTime: 0},
+		&rtcp.SenderReport{SSRC: 5000, NTPTime: ntpTime, RTPTime: 0xFFFFFFFF - 47999},
+	})
+	assert.NoError(t, err)
+
+	// The Sender Report is handled when it is received, even if RTCP isn't read
+	_, err = buffer.Write(raw)
+	assert.NoError(t, err)
+
+	srTime := time.Date(2021, time.January, 1, 0, 0, 0, int(500*time.Millisecond), time.UTC)
+	for _, test := range []struct {
+		Name         string
+		RTPTimestamp uint32
+		Expected     time.Time
+	}{
+		{"Sender Report", 0xFFFFFFFF - 47999, srTime},
+		{"Before", 0xFFFFFFFF - 71999, srTime.Add(-500 * time.Millisecond)},
+		{"Wrapped around", 48000, srTime.Add(2 * time.Second)},
+	} {
+		senderTime, ok := track.SenderTime(test.RTPTimestamp)
+		assert.True(t, ok, test.Name)
+		assert.True(t, test.Expected.Equal(senderTime), "%s: expected %s, got %s", test.Name, test.Expected, senderTime)
+	}
+
+	// The packet is still read unchanged
+	b := make([]byte, receiveMTU)
+	n, err := buffer.Read(b)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, b[:n])
+
+	assert.NoError(t, buffer.(*senderReportBuffer).SetReadDeadline(time.Now()))
+	_, err = buffer.Read(b)
+	assert.Error(t, err)
+	assert.NoError(t, buffer.Close())
+
+	// Sender Reports of removed tracks and truncated packets are ignored
+	transport.handleSenderReports(raw[:len(raw)-4])
+	transport.removeSenderReportTrack(track)
+	raw, err = (&rtcp.SenderReport{SSRC: 5000, NTPTime: ntpTime, RTPTime: 0}).Marshal()
+	assert.NoError(t, err)
+	transport.handleSenderReports(raw)
+
+	senderTime, ok := track.SenderTime(0xFFFFFFFF - 47999)
+	assert.True(t, ok)
+	assert.True(t, srTime.Equal(senderTime))
+}
+
+func TestTrackRemote_AudioLevel(t *testing.T) {
+	packet := &rtp.Packet{Header: rtp.Header{Version: 2, Timestamp: 10, SSRC: 5000}, Payload: []byte{0x00}}
+	raw, err := packet.Marshal()
+	assert.NoError(t, err)
+
+	assert.NoError(t, packet.Header.SetExtension(1, []byte{0x80 | 30}))
+	rawWithAudioLevel, err := packet.Marshal()
+	assert.NoError(t, err)
+
+	next := rawWithAudioLevel
+	track := newTrackRemoteWithReader(interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		return copy(b, next), a, nil
+	}))
+	track.params.HeaderExtensions = []RTPHeaderExtensionParameter{{URI: sdp.AudioLevelURI, ID: 1}}
+
+	_, ok := track.AudioLevel()
+	assert.False(t, ok)
+	assert.Empty(t, track.receiver.GetSynchronizationSources())
+
+	_, _, err = track.ReadRTP()
+	assert.NoError(t, err)
+
+	audioLevel, ok := track.AudioLevel()
+	assert.True(t, ok)
+	assert.Equal(t, media.AudioLevel{Level: 30, Voice: true}, audioLevel)
+
+	sources := track.receiver.GetSynchronizationSources()
+	assert.Len(t, sources, 1)
+	assert.Equal(t, SSRC(5000), sources[0].Source)
+	assert.Equal(t, uint32(10), sources[0].RTPTimestamp)
+	assert.Equal(t, &media.AudioLevel{Level: 30, Voice: true}, sources[0].AudioLevel)
+	assert.WithinDuration(t, time.Now(), sources[0].Timestamp, time.Second)
+
+	// The level is reset by packets without the extension
+	next = raw
+	_, _, err = track.ReadRTP()
+	assert.NoError(t, err)
+
+	_, ok = track.AudioLevel()
+	assert.False(t, ok)
+	source, ok := track.SynchronizationSource()
+	assert.True(t, ok)
+	assert.Nil(t, source.AudioLevel)
+}
+
+func TestTrackRemote_ContributingSources(t *testing.T) {
+	packet := &rtp.Packet{Header: rtp.Header{Version: 2, Timestamp: 10, SSRC: 5000, CSRC: []uint32{1, 2}}, Payload: []byte{0x00}}
+	assert.NoError(t, packet.Header.SetExtension(2, []byte{10, 0x80 | 20}))
+	raw, err := packet.Marshal()
+	assert.NoError(t, err)
+
+	track := newTrackRemoteWithReader(interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		return copy(b, raw), a, nil
+	}))
+	track.params.HeaderExtensions = []RTPHeaderExtensionParameter{{URI: CSRCAudioLevelURI, ID: 2}}
+	assert.Empty(t, track.receiver.GetContributingSources())
+
+	for i := 0; i < 3; i++ {
+		_, _, err = track.ReadRTP()
+		assert.NoError(t, err)
+	}
+
+	sources := track.receiver.GetContributingSources()
+	assert.Len(t, sources, 2)
+	for _, source := range sources {
+		assert.Equal(t, uint32(10), source.RTPTimestamp)
+		assert.WithinDuration(t, time.Now(), source.Timestamp, time.Second)
+		switch source.Source {
+		case 1:
+			assert.Equal(t, &media.AudioLevel{Level: 10}, source.AudioLevel)
+		case 2:
+			assert.Equal(t, &media.AudioLevel{Level: 20}, source.AudioLevel)
+		default:
+			assert.Fail(t, "unexpected contributing source", source.Source)
+		}
+	}
+
+	collector := newStatsReportCollector()
+	track.receiver.collectStats(collector)
+	report := collector.Ready()
+	assert.Len(t, report, 2)
+
+	stats, ok := report["RTPContributingSource-5000-2"].(RTPContributingSourceStats)
+	assert.True(t, ok)
+	assert.Equal(t, StatsTypeCSRC, stats.Type)
+	assert.Equal(t, SSRC(2), stats.ContributorSSRC)
+	assert.Equal(t, "InboundRTPStream-5000", stats.InboundRTPStreamID)
+	assert.Equal(t, uint32(3), stats.PacketsContributedTo)
+	assert.InDelta(t, 0.1, stats.AudioLevel, 0.001)
+
+	// CSRCs that didn't contribute within the timeout are dropped, even if no new CSRC arrived
+	track.mu.Lock()
+	track.contributingSources[1].lastReceived = time.Now().Add(-2 * synchronizationSourceTimeout)
+	track.mu.Unlock()
+
+	sources = track.receiver.GetContributingSources()
+	assert.Len(t, sources, 1)
+	assert.Equal(t, SSRC(2), sources[0].Source)
+
+	collector = newStatsReportCollector()
+	track.receiver.collectStats(collector)
+	report = collector.Ready()
+	assert.Len(t, report, 1)
+	_, ok = report["RTPContributingSource-5000-1"]
+	assert.False(t, ok)
+}